@@ -73,6 +73,11 @@ func main() {
 	jobRouter.Use(mtbulkRESTAPI.AuthorizeMiddleware)
 	jobRouter.HandleFunc("", mtbulkRESTAPI.JobHandler(ctx)).Methods("POST")
 
+	// backups history
+	backupsRouter := router.PathPrefix("/backups").Subrouter()
+	backupsRouter.Use(mtbulkRESTAPI.AuthorizeMiddleware)
+	backupsRouter.HandleFunc("/{host}", mtbulkRESTAPI.BackupsHandler(ctx)).Methods("GET")
+
 	tlsConfig := &tls.Config{
 		MinVersion:               tls.VersionTLS12,
 		CurvePreferences:         []tls.CurveID{tls.CurveP521, tls.CurveP384, tls.CurveP256},
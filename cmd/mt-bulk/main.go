@@ -19,12 +19,34 @@ Usage:
   mt-bulk gen-ssh-keys [options]
   mt-bulk init-secure-api [options] [<hosts>...]
   mt-bulk init-publickey-ssh [options] [<hosts>...]
-  mt-bulk change-password (--new=<newpass>) [--user=<user>] [options] [<hosts>...]  
+  mt-bulk change-password (--new=<newpass>) [--user=<user>] [--plain-api] [options] [<hosts>...]  
   mt-bulk system-backup (--name=<name>) (--backup-store=<backups>) [options] [<hosts>...]  
+  mt-bulk system-restore (--file=<file>) [--password=<password>] [--wait-timeout=<time>] [options] [<hosts>...]  
+  mt-bulk system-upgrade [--channel=<channel>] [--package=<npk>] [--firmware] [--wait-timeout=<time>] [options] [<hosts>...]  
   mt-bulk sftp <source> <target> [options] [<hosts>...]  
   mt-bulk custom-api [--commands-file=<commands>] [options] [<hosts>...]  
+  mt-bulk custom-api-plain [--commands-file=<commands>] [options] [<hosts>...]  
+  mt-bulk custom-rest [--commands-file=<commands>] [options] [<hosts>...]  
   mt-bulk custom-ssh [--commands-file=<commands>] [options] [<hosts>...]  
-  mt-bulk security-audit [options] [<hosts>...] 
+  mt-bulk config-diff [--hide-sensitive] [--from-backups] [--ignore=<regexp>] [options] [<hosts>...]  
+  mt-bulk compliance (--rules=<rules>) [--fix] [options] [<hosts>...]  
+  mt-bulk security-audit [--fix [--dry-run]] [options] [<hosts>...] 
+  mt-bulk collect-facts [options] [<hosts>...]
+  mt-bulk discover [--depth=<hops>] [--scope=<subnets>] [--hosts-file=<file>] [--topology-file=<file>] [options] [<hosts>...]
+  mt-bulk api-certs list [options]
+  mt-bulk api-certs revoke [options] [<hosts>...]
+  mt-bulk api-certs reissue [options] [<hosts>...]
+  mt-bulk backups list [options] [<hosts>...]
+  mt-bulk backups prune [options] [<hosts>...]
+  mt-bulk cve import <file> [options]
+  mt-bulk cve status [options]
+  mt-bulk cve waive <id> [--host=<pattern>] [--expires=<date>] [--justification=<text>] [options]
+  mt-bulk cve unwaive <id> [--host=<pattern>] [options]
+  mt-bulk cve waivers [options]
+  mt-bulk inventory [--format=<format>] [--output=<file>] [options] [<hosts>...]
+  mt-bulk known-hosts list [options]
+  mt-bulk known-hosts accept [options] [<hosts>...]
+  mt-bulk known-hosts revoke [options] [<hosts>...]
   mt-bulk -h | --help
   mt-bulk --version
 
@@ -32,6 +54,13 @@ Options:
   -C <config-file>         Use configuration file, e.g. certs locations, ports, commands sequences, custom commands, etc...
   --source-db              Load hosts using database configured by -C <config-file>
   --source-file=<file-in>  Load hosts from file <file-in>
+  --exclude=<hosts>        Skip hosts, comma separated list of IPs, CIDRs or ranges, e.g. 10.0.0.0/28,10.0.1.1-10.0.1.9
+  --probe                  Skip hosts not accepting TCP connection
+  --select=<selector>      Process only hosts matching tags, groups or variables, e.g. site=waw,role=core,!lab
+  --job-timeout=<time>     Limit processing time of each job, e.g. 90s or 10m
+  --json                   Print results of each job as JSON, including structured records of API and REST replies
+  --report=<format>        Print report of audit findings and CVEs of all hosts in given format, json or sarif
+  --report-file=<file>     Write report to file <file> instead of standard output
 `
 
 var version string
@@ -0,0 +1,142 @@
+// Package backups keeps history of devices' backups and rotates them according to retention policy.
+package backups
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger"
+
+	"github.com/migotom/mt-bulk/internal/kvdb"
+)
+
+const kvTagBackup = "Backup:"
+
+// directoryTimeFormat is format of timestamped directories holding backups of a host.
+const directoryTimeFormat = "20060102-150405"
+
+// File is single file of backup stored locally.
+type File struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// NewFile returns description of locally stored file with its size and SHA256 checksum.
+func NewFile(path string) (File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return File{}, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return File{}, fmt.Errorf("can't calculate checksum of %s: %v", path, err)
+	}
+	return File{Path: path, Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// Backup is single backup of device.
+type Backup struct {
+	Host    string    `json:"host"`
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+	Files   []File    `json:"files"`
+}
+
+func (b Backup) String() string {
+	files := make([]string, 0, len(b.Files))
+	for _, file := range b.Files {
+		files = append(files, fmt.Sprintf("%s (%d bytes, sha256:%s)", file.Path, file.Size, file.SHA256))
+	}
+	return fmt.Sprintf("%s %s %s %s", b.Host, b.Created.Format(time.RFC3339), b.Name, strings.Join(files, " "))
+}
+
+func (b Backup) key() string {
+	return fmt.Sprintf("%s%s/%s", kvTagBackup, b.Host, b.Created.UTC().Format(time.RFC3339Nano))
+}
+
+// Directory returns timestamped directory of host's backup created at given time within backups store.
+func Directory(store, host string, created time.Time) string {
+	return filepath.Join(store, host, created.UTC().Format(directoryTimeFormat))
+}
+
+// Index is index of backups stored locally.
+type Index interface {
+	Add(Backup) error
+	Remove(Backup) error
+	List(host string) ([]Backup, error)
+}
+
+// NewIndex returns index of backups stored in MT-bulk database.
+func NewIndex(kv kvdb.KV) Index {
+	return &indexKV{kv: kv}
+}
+
+type indexKV struct {
+	kv kvdb.KV
+}
+
+func (i *indexKV) Add(backup Backup) error {
+	txn := i.kv.NewTransaction()
+	defer txn.Discard()
+
+	if err := txn.Store(backup.key(), backup); err != nil {
+		return err
+	}
+	return txn.Commit()
+}
+
+func (i *indexKV) Remove(backup Backup) error {
+	txn := i.kv.NewTransaction()
+	defer txn.Discard()
+
+	if err := txn.Delete(backup.key()); err != nil && err != badger.ErrKeyNotFound {
+		return err
+	}
+	return txn.Commit()
+}
+
+// List returns backups of given host or all hosts if host is empty, ordered by host and creation time.
+func (i *indexKV) List(host string) (list []Backup, err error) {
+	prefix := kvTagBackup
+	if host != "" {
+		prefix += host + "/"
+	}
+
+	err = i.kv.View(func(txn kvdb.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: []byte(prefix)})
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			key := string(it.Item().KeyCopy(nil))
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+
+			var backup Backup
+			if err := txn.GetCopy(key, &backup); err != nil {
+				return err
+			}
+			list = append(list, backup)
+		}
+		return nil
+	})
+
+	sort.SliceStable(list, func(a, b int) bool {
+		if list[a].Host != list[b].Host {
+			return list[a].Host < list[b].Host
+		}
+		return list[a].Created.Before(list[b].Created)
+	})
+	return list, err
+}
@@ -0,0 +1,131 @@
+package backups
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/migotom/mt-bulk/internal/clients"
+	"github.com/migotom/mt-bulk/internal/kvdb"
+)
+
+func TestRetentionExpired(t *testing.T) {
+	now := time.Date(2020, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	// two backups a day during last 60 days
+	var list []Backup
+	for day := 0; day < 60; day++ {
+		for _, hour := range []int{1, 13} {
+			list = append(list, Backup{Host: "10.0.0.1", Created: now.AddDate(0, 0, -day).Add(-time.Duration(hour) * time.Hour)})
+		}
+	}
+
+	cases := []struct {
+		Name      string
+		Retention Retention
+		Kept      int
+	}{
+		{Name: "Disabled", Retention: Retention{}, Kept: 120},
+		{Name: "Keep last", Retention: Retention{KeepLast: 5}, Kept: 5},
+		{Name: "Keep daily", Retention: Retention{KeepDaily: 7}, Kept: 7},
+		{Name: "Keep last and daily", Retention: Retention{KeepLast: 2, KeepDaily: 3}, Kept: 3},
+		{Name: "Keep weekly", Retention: Retention{KeepWeekly: 4}, Kept: 4},
+		{Name: "Keep monthly", Retention: Retention{KeepMonthly: 12}, Kept: 3},
+		{Name: "Max age", Retention: Retention{MaxAge: clients.Duration{Duration: 10 * 24 * time.Hour}}, Kept: 20},
+		{Name: "Max age limits daily", Retention: Retention{KeepDaily: 30, MaxAge: clients.Duration{Duration: 72 * time.Hour}}, Kept: 4},
+		{Name: "Most recent always kept", Retention: Retention{MaxAge: clients.Duration{Duration: time.Minute}}, Kept: 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			expired := tc.Retention.Expired(list, now)
+			if kept := len(list) - len(expired); kept != tc.Kept {
+				t.Errorf("got:%v, expected:%v", kept, tc.Kept)
+			}
+			for _, backup := range expired {
+				if backup.Created.Equal(list[0].Created) {
+					t.Errorf("most recent backup expired")
+				}
+			}
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backups")
+	if err != nil {
+		t.Fatalf("can't create temporary directory %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	kv, err := kvdb.OpenKV(zap.NewNop().Sugar(), filepath.Join(dir, "db"))
+	if err != nil {
+		t.Fatalf("can't open database %v", err)
+	}
+	defer kv.Close()
+
+	index := NewIndex(kv)
+	now := time.Now()
+
+	var stored []Backup
+	for _, host := range []string{"10.0.0.1", "10.0.0.2"} {
+		for age := 3; age >= 0; age-- {
+			created := now.Add(-time.Duration(age) * time.Hour)
+			directory := Directory(filepath.Join(dir, "store"), host, created)
+			if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+				t.Fatalf("can't create directory %v", err)
+			}
+			path := filepath.Join(directory, "backup.rsc")
+			if err := ioutil.WriteFile(path, []byte("/system identity set name="+host), 0600); err != nil {
+				t.Fatalf("can't write backup %v", err)
+			}
+
+			file, err := NewFile(path)
+			if err != nil {
+				t.Fatalf("not expected error %v", err)
+			}
+			backup := Backup{Host: host, Name: "backup", Created: created, Files: []File{file}}
+			if err := index.Add(backup); err != nil {
+				t.Fatalf("not expected error %v", err)
+			}
+			stored = append(stored, backup)
+		}
+	}
+
+	pruned, err := Prune(index, Retention{KeepLast: 2}, "10.0.0.1", now)
+	if err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	if len(pruned) != 2 {
+		t.Errorf("got:%v, expected:%v", len(pruned), 2)
+	}
+	for _, backup := range pruned {
+		if _, err := os.Stat(filepath.Dir(backup.Files[0].Path)); !os.IsNotExist(err) {
+			t.Errorf("backup directory %s not removed", filepath.Dir(backup.Files[0].Path))
+		}
+	}
+
+	list, err := index.List("")
+	if err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	expected := append(append([]Backup{}, stored[2:4]...), stored[4:]...)
+	if len(list) != len(expected) {
+		t.Fatalf("got:%v, expected:%v", list, expected)
+	}
+	for idx := range list {
+		if list[idx].Host != expected[idx].Host || !list[idx].Created.Equal(expected[idx].Created) || !reflect.DeepEqual(list[idx].Files, expected[idx].Files) {
+			t.Errorf("got:%v, expected:%v", list[idx], expected[idx])
+		}
+	}
+	checksum := sha256.Sum256([]byte("/system identity set name=10.0.0.1"))
+	if list[0].Files[0].SHA256 != hex.EncodeToString(checksum[:]) {
+		t.Errorf("got:%v, expected:%v", list[0].Files[0].SHA256, hex.EncodeToString(checksum[:]))
+	}
+}
@@ -0,0 +1,92 @@
+package backups
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger"
+
+	"github.com/migotom/mt-bulk/internal/kvdb"
+)
+
+const kvTagBaseline = "ConfigBaseline:"
+
+// Baseline is configuration exported from device used as reference to detect configuration drift.
+type Baseline struct {
+	Host    string
+	Export  string
+	Created time.Time
+}
+
+// Baselines is a store of devices' configuration baselines.
+type Baselines interface {
+	Get(host string) (Baseline, bool, error)
+	Store(Baseline) error
+}
+
+// NewBaselines returns store of configuration baselines kept in MT-bulk database.
+func NewBaselines(kv kvdb.KV) Baselines {
+	return &baselinesKV{kv: kv}
+}
+
+// NewBackupBaselines returns read-only store of configuration baselines taken from exports (.rsc) of most recent indexed backups.
+func NewBackupBaselines(index Index) Baselines {
+	return &baselinesBackup{index: index}
+}
+
+type baselinesKV struct {
+	kv kvdb.KV
+}
+
+func (b *baselinesKV) Get(host string) (baseline Baseline, found bool, err error) {
+	err = b.kv.View(func(txn kvdb.Txn) error {
+		return txn.GetCopy(kvTagBaseline+host, &baseline)
+	})
+	if err == badger.ErrKeyNotFound {
+		return Baseline{}, false, nil
+	}
+	return baseline, err == nil, err
+}
+
+func (b *baselinesKV) Store(baseline Baseline) error {
+	txn := b.kv.NewTransaction()
+	defer txn.Discard()
+
+	if err := txn.Store(kvTagBaseline+baseline.Host, baseline); err != nil {
+		return err
+	}
+	return txn.Commit()
+}
+
+type baselinesBackup struct {
+	index Index
+}
+
+func (b *baselinesBackup) Get(host string) (Baseline, bool, error) {
+	list, err := b.index.List(host)
+	if err != nil {
+		return Baseline{}, false, err
+	}
+
+	for idx := len(list) - 1; idx >= 0; idx-- {
+		for _, file := range list[idx].Files {
+			if !strings.EqualFold(filepath.Ext(file.Path), ".rsc") {
+				continue
+			}
+
+			export, err := ioutil.ReadFile(file.Path)
+			if err != nil {
+				return Baseline{}, false, err
+			}
+			return Baseline{Host: host, Export: string(export), Created: list[idx].Created}, true, nil
+		}
+	}
+	return Baseline{}, false, nil
+}
+
+// Store does nothing, baselines are updated by next backups.
+func (b *baselinesBackup) Store(Baseline) error {
+	return nil
+}
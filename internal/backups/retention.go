@@ -0,0 +1,134 @@
+package backups
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/migotom/mt-bulk/internal/clients"
+)
+
+// Retention is policy of keeping host's backups, backups not kept by any of rules are pruned.
+// Most recent backup of host is always kept.
+type Retention struct {
+	// KeepLast keeps given number of most recent backups.
+	KeepLast int `toml:"keep_last" yaml:"keep_last"`
+	// KeepDaily keeps most recent backup of each of given number of last days having backups.
+	KeepDaily int `toml:"keep_daily" yaml:"keep_daily"`
+	// KeepWeekly keeps most recent backup of each of given number of last weeks having backups.
+	KeepWeekly int `toml:"keep_weekly" yaml:"keep_weekly"`
+	// KeepMonthly keeps most recent backup of each of given number of last months having backups.
+	KeepMonthly int `toml:"keep_monthly" yaml:"keep_monthly"`
+	// MaxAge prunes backups older than given age, regardless of other rules.
+	MaxAge clients.Duration `toml:"max_age" yaml:"max_age"`
+}
+
+// Enabled returns true if any rule of retention policy is defined.
+func (r Retention) Enabled() bool {
+	return r.keeps() || r.MaxAge.Duration > 0
+}
+
+func (r Retention) keeps() bool {
+	return r.KeepLast > 0 || r.KeepDaily > 0 || r.KeepWeekly > 0 || r.KeepMonthly > 0
+}
+
+// Expired returns backups of single host not kept by retention policy.
+func (r Retention) Expired(list []Backup, now time.Time) []Backup {
+	if !r.Enabled() || len(list) == 0 {
+		return nil
+	}
+
+	sorted := make([]Backup, len(list))
+	copy(sorted, list)
+	sort.SliceStable(sorted, func(a, b int) bool { return sorted[a].Created.After(sorted[b].Created) })
+
+	keep := make([]bool, len(sorted))
+	keep[0] = true
+
+	if r.keeps() {
+		for idx := 0; idx < r.KeepLast && idx < len(sorted); idx++ {
+			keep[idx] = true
+		}
+		keepPeriods(sorted, keep, r.KeepDaily, func(t time.Time) string { return t.Format("2006-01-02") })
+		keepPeriods(sorted, keep, r.KeepWeekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-%d", year, week)
+		})
+		keepPeriods(sorted, keep, r.KeepMonthly, func(t time.Time) string { return t.Format("2006-01") })
+	} else {
+		for idx := range keep {
+			keep[idx] = true
+		}
+	}
+
+	var expired []Backup
+	for idx, backup := range sorted {
+		tooOld := r.MaxAge.Duration > 0 && now.Sub(backup.Created) > r.MaxAge.Duration
+		if idx > 0 && (!keep[idx] || tooOld) {
+			expired = append(expired, backup)
+		}
+	}
+	return expired
+}
+
+// keepPeriods marks most recent backup of each of given number of last periods, backups has to be sorted from most recent one.
+func keepPeriods(sorted []Backup, keep []bool, periods int, period func(time.Time) string) {
+	last := ""
+	for idx := 0; idx < len(sorted) && periods > 0; idx++ {
+		current := period(sorted[idx].Created.UTC())
+		if current == last {
+			continue
+		}
+		keep[idx] = true
+		last = current
+		periods--
+	}
+}
+
+// Prune removes files and index entries of backups of given host (or all hosts if empty) expired by retention policy.
+func Prune(index Index, retention Retention, host string, now time.Time) (pruned []Backup, err error) {
+	if !retention.Enabled() {
+		return nil, nil
+	}
+
+	list, err := index.List(host)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make(map[string][]Backup)
+	for _, backup := range list {
+		hosts[backup.Host] = append(hosts[backup.Host], backup)
+	}
+
+	for _, hostBackups := range hosts {
+		for _, backup := range retention.Expired(hostBackups, now) {
+			if err := remove(backup); err != nil {
+				return pruned, err
+			}
+			if err := index.Remove(backup); err != nil {
+				return pruned, err
+			}
+			pruned = append(pruned, backup)
+		}
+	}
+	return pruned, nil
+}
+
+// remove removes backup's files and timestamped directories left empty.
+func remove(backup Backup) error {
+	directories := make(map[string]struct{})
+	for _, file := range backup.Files {
+		if err := os.Remove(file.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("can't remove backup file %s: %v", file.Path, err)
+		}
+		directories[filepath.Dir(file.Path)] = struct{}{}
+	}
+	for directory := range directories {
+		// directory still holding other files is left untouched
+		os.Remove(directory)
+	}
+	return nil
+}
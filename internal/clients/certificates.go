@@ -3,17 +3,21 @@ package clients
 import (
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/hex"
 	"encoding/pem"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"math/big"
+	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/ssh"
@@ -80,68 +84,158 @@ func GenerateCA(store string) error {
 func GenerateCerts(store, subject string) error {
 	log.Println("[CONFIG] Generating " + subject + " certificate")
 
+	csr := &x509.Certificate{
+		SerialNumber: big.NewInt(1658),
+		Subject: pkix.Name{
+			Organization: []string{"IT"},
+			Country:      []string{"PL"},
+			Province:     []string{"PL"},
+			Locality:     []string{"FakeTown"},
+			CommonName:   "mtbulk" + subject + ".cell-crm.com",
+		},
+		DNSNames:     []string{"mtbulk" + subject + ".cell-crm.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().AddDate(10, 0, 0),
+		SubjectKeyId: []byte{1, 2, 3, 4, 6},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+
+	_, err := signCertificate(store, store, subject, csr)
+	return err
+}
+
+// GenerateDeviceCerts generates and sign using CA certificate unique key and certificate of single device.
+// Certificate is issued for device's IP address and optionally its identity, key and certificate are stored in target directory.
+func GenerateDeviceCerts(store, target, IP, identity string) (DeviceCertificate, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return DeviceCertificate{}, fmt.Errorf("can't generate serial number: %s", err)
+	}
+
+	csr := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			Organization: []string{"IT"},
+			Country:      []string{"PL"},
+			Province:     []string{"PL"},
+			Locality:     []string{"FakeTown"},
+			CommonName:   IP,
+		},
+		NotBefore:   time.Now(),
+		NotAfter:    time.Now().AddDate(10, 0, 0),
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+	}
+	if ip := net.ParseIP(IP); ip != nil {
+		csr.IPAddresses = []net.IP{ip}
+	} else {
+		csr.DNSNames = append(csr.DNSNames, IP)
+	}
+	// identity is free text, only valid host name is bound to certificate
+	if identity = strings.TrimSpace(identity); validHostname(identity) {
+		csr.DNSNames = append(csr.DNSNames, identity)
+	}
+
+	if err := os.MkdirAll(target, 0700); err != nil {
+		return DeviceCertificate{}, fmt.Errorf("can't create device certificates directory (%s), %s", target, err)
+	}
+
+	certificate, err := signCertificate(store, target, "device", csr)
+	if err != nil {
+		return DeviceCertificate{}, err
+	}
+
+	fingerprint := sha256.Sum256(certificate.Raw)
+	return DeviceCertificate{
+		Host:         IP,
+		Identity:     identity,
+		SerialNumber: certificate.SerialNumber.Text(16),
+		Fingerprint:  hex.EncodeToString(fingerprint[:]),
+		NotBefore:    certificate.NotBefore,
+		NotAfter:     certificate.NotAfter,
+	}, nil
+}
+
+func signCertificate(store, target, subject string, csr *x509.Certificate) (*x509.Certificate, error) {
 	if store == "" {
-		return errors.New("certificates directory not provided")
+		return nil, errors.New("certificates directory not provided")
 	}
 
 	if _, err := os.Stat(store); err != nil {
-		return fmt.Errorf("can't locate certificates directory (%s), %s", store, err)
+		return nil, fmt.Errorf("can't locate certificates directory (%s), %s", store, err)
 	}
 
 	tlsCA, err := tls.LoadX509KeyPair(filepath.FromSlash(filepath.Join(store, "ca.crt")), filepath.FromSlash(filepath.Join(store, "ca.key")))
 	if err != nil {
-		return fmt.Errorf("can't load CA key pair: %s", err)
+		return nil, fmt.Errorf("can't load CA key pair: %s", err)
 	}
 
 	certificateCA, err := x509.ParseCertificate(tlsCA.Certificate[0])
 	if err != nil {
-		return fmt.Errorf("invalid CA certificate: %s", err)
+		return nil, fmt.Errorf("invalid CA certificate: %s", err)
 	}
 
 	private, err := rsa.GenerateKey(rand.Reader, 2048)
 	if err != nil {
-		return fmt.Errorf("can't generate %s private key: %s", subject, err)
+		return nil, fmt.Errorf("can't generate %s private key: %s", subject, err)
 	}
 
-	privateFile, err := os.OpenFile(filepath.FromSlash(filepath.Join(store, subject+".key")), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	privateFile, err := os.OpenFile(filepath.FromSlash(filepath.Join(target, subject+".key")), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
-		return fmt.Errorf("can't create %s private key: %s", subject, err)
+		return nil, fmt.Errorf("can't create %s private key: %s", subject, err)
 	}
 	pem.Encode(privateFile, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})
 	privateFile.Close()
 
 	public := &private.PublicKey
 
-	csr := &x509.Certificate{
-		SerialNumber: big.NewInt(1658),
-		Subject: pkix.Name{
-			Organization: []string{"IT"},
-			Country:      []string{"PL"},
-			Province:     []string{"PL"},
-			Locality:     []string{"FakeTown"},
-			CommonName:   "mtbulk" + subject + ".cell-crm.com",
-		},
-		NotBefore:    time.Now(),
-		NotAfter:     time.Now().AddDate(10, 0, 0),
-		SubjectKeyId: []byte{1, 2, 3, 4, 6},
-		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
-		KeyUsage:     x509.KeyUsageDigitalSignature,
-	}
-
 	certificate, err := x509.CreateCertificate(rand.Reader, csr, certificateCA, public, tlsCA.PrivateKey)
 	if err != nil {
-		return fmt.Errorf("can't generate %s certificate: %s", subject, err)
+		return nil, fmt.Errorf("can't generate %s certificate: %s", subject, err)
 	}
 
-	certificateFile, err := os.Create(filepath.FromSlash(filepath.Join(store, subject+".crt")))
+	certificateFile, err := os.Create(filepath.FromSlash(filepath.Join(target, subject+".crt")))
 	if err != nil {
-		return fmt.Errorf("can't create %s certificate: %s", subject, err)
+		return nil, fmt.Errorf("can't create %s certificate: %s", subject, err)
 	}
 
 	pem.Encode(certificateFile, &pem.Block{Type: "CERTIFICATE", Bytes: certificate})
 	certificateFile.Close()
 
-	return nil
+	return x509.ParseCertificate(certificate)
+}
+
+// validHostname returns true if name is valid DNS host name (RFC 1123).
+func validHostname(name string) bool {
+	if name == "" || len(name) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// LoadCA loads pool with CA certificate used to sign device/clients certificates.
+func LoadCA(store string) (*x509.CertPool, error) {
+	certificate, err := ioutil.ReadFile(filepath.FromSlash(filepath.Join(store, "ca.crt")))
+	if err != nil {
+		return nil, fmt.Errorf("can't load CA certificate: %s", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(certificate) {
+		return nil, errors.New("invalid CA certificate")
+	}
+	return pool, nil
 }
 
 // GenerateKeys generates private and public keys.
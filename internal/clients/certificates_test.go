@@ -0,0 +1,63 @@
+package clients
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGenerateDeviceCerts(t *testing.T) {
+	store, err := ioutil.TempDir("", "certs")
+	if err != nil {
+		t.Fatalf("can't create temporary directory %v", err)
+	}
+	defer os.RemoveAll(store)
+
+	if err := GenerateCA(store); err != nil {
+		t.Fatalf("can't generate CA %v", err)
+	}
+
+	cases := []struct {
+		Name             string
+		Identity         string
+		ExpectedDNSNames []string
+	}{
+		{Name: "OK, identity bound", Identity: "core-1.waw ", ExpectedDNSNames: []string{"core-1.waw"}},
+		{Name: "OK, identity with spaces not bound", Identity: "core router 1"},
+		{Name: "OK, identity with invalid characters not bound", Identity: "core_1/waw"},
+		{Name: "OK, without identity"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			target := filepath.Join(store, tc.Name)
+			issued, err := GenerateDeviceCerts(store, target, "10.0.0.1", tc.Identity)
+			if err != nil {
+				t.Fatalf("not expected error %v", err)
+			}
+
+			device, err := tls.LoadX509KeyPair(filepath.Join(target, "device.crt"), filepath.Join(target, "device.key"))
+			if err != nil {
+				t.Fatalf("not expected error %v", err)
+			}
+			certificate, err := x509.ParseCertificate(device.Certificate[0])
+			if err != nil {
+				t.Fatalf("not expected error %v", err)
+			}
+			if !reflect.DeepEqual(certificate.DNSNames, tc.ExpectedDNSNames) {
+				t.Errorf("got:%v, expected:%v", certificate.DNSNames, tc.ExpectedDNSNames)
+			}
+
+			if err := VerifyDeviceCertificate(deviceCertificatesMap{"10.0.0.1": issued}, "10.0.0.1", certificate); err != nil {
+				t.Errorf("not expected error %v", err)
+			}
+			if err := VerifyDeviceCertificate(deviceCertificatesMap{}, "10.0.0.1", certificate); err == nil {
+				t.Errorf("expected error of device without issued certificate")
+			}
+		})
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/migotom/mt-bulk/internal/console"
 	"github.com/migotom/mt-bulk/internal/entities"
 )
 
@@ -26,6 +28,16 @@ type Copier interface {
 	CopyFile(ctx context.Context, source, target string) (entities.CommandResult, error)
 }
 
+// Recorder interface for clients capable to return reply as structured records.
+type Recorder interface {
+	RunCmdRecords(string, *regexp.Regexp) (string, []map[string]string, error)
+}
+
+// ContextRecorder interface for clients capable to abort command once context is done and return reply as structured records.
+type ContextRecorder interface {
+	RunCmdRecordsContext(context.Context, string, *regexp.Regexp) (string, []map[string]string, error)
+}
+
 // EstablishConnection tries to establish connection for provided host by specified client.
 // It tries to connect by retries number and list of passwords defined in client's configuration.
 func EstablishConnection(ctx context.Context, sugar *zap.SugaredLogger, client Client, job *entities.Job) (result entities.CommandResult, err error) {
@@ -74,13 +86,47 @@ func EstablishConnection(ctx context.Context, sugar *zap.SugaredLogger, client C
 
 // ExecuteCommands executes provided list of commands using specified client.
 func ExecuteCommands(ctx context.Context, d Client, commands []entities.Command) ([]entities.CommandResult, map[string]string, error) {
+	return executeCommands(ctx, d, commands, nil)
+}
+
+func executeCommands(ctx context.Context, d Client, commands []entities.Command, data *TemplateData) ([]entities.CommandResult, map[string]string, error) {
 	allMatches := make(map[string]string)
-	run := func(c entities.Command, responseChan chan<- string, errChan chan<- error) {
+	run := func(ctx context.Context, c entities.Command, records *[]map[string]string, responseChan chan<- string, errChan chan<- error) {
 		defer close(responseChan)
 		defer close(errChan)
 
-		for match, value := range allMatches {
-			c.Body = regexp.MustCompile(match).ReplaceAllString(c.Body, value)
+		replace := func(s string) string {
+			for match, value := range allMatches {
+				s = regexp.MustCompile(match).ReplaceAllString(s, value)
+			}
+			return s
+		}
+
+		var renderErr error
+		render := func(s string) string {
+			if data != nil && c.Template && renderErr == nil {
+				s, renderErr = data.render(s, allMatches)
+			}
+			return replace(s)
+		}
+
+		c.Body = render(c.Body)
+		if c.Path != "" {
+			// substitute matches in each value before rendering, so replaced values are quoted properly
+			attributes := make(map[string]string, len(c.Attributes))
+			for key, value := range c.Attributes {
+				attributes[key] = render(value)
+			}
+			queries := make([]string, 0, len(c.Queries))
+			for _, query := range c.Queries {
+				queries = append(queries, render(query))
+			}
+			c.Attributes, c.Queries = attributes, queries
+			c.Body = c.Sentence()
+		}
+		if renderErr != nil {
+			errChan <- fmt.Errorf("command template error: %s", renderErr)
+			return
 		}
 
 		var expect *regexp.Regexp
@@ -88,10 +134,21 @@ func ExecuteCommands(ctx context.Context, d Client, commands []entities.Command)
 			expect = regexp.MustCompile(c.Expect)
 		}
 
-		result, err := d.RunCmd(c.Body, expect)
+		var result string
+		var err error
+		if recorder, ok := d.(ContextRecorder); ok {
+			result, *records, err = recorder.RunCmdRecordsContext(ctx, c.Body, expect)
+		} else if recorder, ok := d.(Recorder); ok {
+			result, *records, err = recorder.RunCmdRecords(c.Body, expect)
+		} else {
+			result, err = d.RunCmd(c.Body, expect)
+			if err == nil && c.Parse != "" {
+				*records, err = console.Parse(c.Parse, result)
+			}
+		}
 		if err != nil {
 			responseChan <- result
-			errChan <- fmt.Errorf("command processing error: %s", err)
+			errChan <- fmt.Errorf("command processing error: %w", err)
 			return
 		}
 
@@ -107,6 +164,20 @@ func ExecuteCommands(ctx context.Context, d Client, commands []entities.Command)
 
 		var counter int
 		for _, match := range c.Matches {
+			if strings.HasPrefix(match, RecordMatchPrefix) {
+				recordMatch, err := ParseRecordMatch(match)
+				if err != nil {
+					errChan <- err
+					return
+				}
+				for _, value := range recordMatch.Find(*records) {
+					counter++
+					allMatches[fmt.Sprintf("(%%{%s%d})", c.MatchPrefix, counter)] = value
+					responseChan <- fmt.Sprintf("/<mt-bulk:record> \"%s\" set key \"%s\" with value %v", match, fmt.Sprintf("%%{%s%d}", c.MatchPrefix, counter), value)
+				}
+				continue
+			}
+
 			if commandMatches := regexp.MustCompile(match).FindStringSubmatch(result); len(commandMatches) > 1 {
 				for i := 1; i < len(commandMatches); i++ {
 					counter++
@@ -120,23 +191,36 @@ func ExecuteCommands(ctx context.Context, d Client, commands []entities.Command)
 	var executeError error
 	executed := make([]entities.CommandResult, 0, len(commands))
 
+	defaultTimeout := d.GetConfig().CommandTimeout.OrDefault(DefaultCommandTimeout)
 	for _, c := range commands {
 		errChan := make(chan error)
 		responseChan := make(chan string)
 
-		go run(c, responseChan, errChan)
+		timeout := defaultTimeout
+		if c.TimeoutMs > 0 {
+			timeout = time.Duration(c.TimeoutMs) * time.Millisecond
+		}
+		commandCtx, cancel := context.WithTimeout(ctx, timeout)
 
-		commandResult := entities.CommandResult{Body: c.Body}
+		var records []map[string]string
+		go run(commandCtx, c, &records, responseChan, errChan)
+
+		commandResult := entities.CommandResult{Body: c.Sentence()}
 	commandParseLoop:
 		for {
 			select {
-			case <-ctx.Done():
-				return executed, nil, errors.New("interrupted")
-			case <-time.After(30 * time.Second):
-				return executed, nil, fmt.Errorf("timeouted")
+			case <-commandCtx.Done():
+				cancel()
+				switch ctx.Err() {
+				case context.DeadlineExceeded:
+					return executed, nil, errors.New("job timeouted")
+				case context.Canceled:
+					return executed, nil, errors.New("interrupted")
+				}
+				return executed, nil, fmt.Errorf("timeouted after %s (%s)", timeout, c)
 			case err, ok := <-errChan:
 				if ok {
-					executeError = fmt.Errorf("%v (%s)", err, c)
+					executeError = fmt.Errorf("%w (%s)", err, c)
 				}
 			case response, ok := <-responseChan:
 				if !ok {
@@ -145,6 +229,8 @@ func ExecuteCommands(ctx context.Context, d Client, commands []entities.Command)
 				commandResult.Responses = append(commandResult.Responses, response)
 			}
 		}
+		cancel()
+		commandResult.Records = records
 		commandResult.Error = executeError
 		executed = append(executed, commandResult)
 
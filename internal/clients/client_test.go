@@ -0,0 +1,167 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+type slowClient struct {
+	delay time.Duration
+	Config
+}
+
+func (c slowClient) GetConfig() Config { return c.Config }
+
+func (c slowClient) RunCmd(body string, expect *regexp.Regexp) (string, error) {
+	time.Sleep(c.delay)
+	return body, nil
+}
+
+func (c slowClient) Connect(ctx context.Context, IP, Port, User, Password string) error { return nil }
+
+func (c slowClient) Close() {}
+
+func TestExecuteCommandsTimeouts(t *testing.T) {
+	cases := []struct {
+		Name          string
+		Config        Config
+		Command       entities.Command
+		JobTimeout    time.Duration
+		ExpectedError string
+	}{
+		{Name: "OK, default timeout", Command: entities.Command{Body: "/export"}},
+		{Name: "OK, command timeout longer than execution", Config: Config{CommandTimeout: Duration{10 * time.Millisecond}}, Command: entities.Command{Body: "/export", TimeoutMs: 500}},
+		{Name: "Wrong, configured command timeout", Config: Config{CommandTimeout: Duration{10 * time.Millisecond}}, Command: entities.Command{Body: "/export"}, ExpectedError: "timeouted after 10ms"},
+		{Name: "Wrong, command timeout", Command: entities.Command{Body: "/export", TimeoutMs: 10}, ExpectedError: "timeouted after 10ms"},
+		{Name: "Wrong, job timeout", Command: entities.Command{Body: "/export"}, JobTimeout: 10 * time.Millisecond, ExpectedError: "job timeouted"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.JobTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.JobTimeout)
+				defer cancel()
+			}
+
+			_, _, err := ExecuteCommands(ctx, slowClient{delay: 100 * time.Millisecond, Config: tc.Config}, []entities.Command{tc.Command})
+			if tc.ExpectedError == "" && err != nil {
+				t.Errorf("not expected error %v", err)
+			}
+			if tc.ExpectedError != "" && (err == nil || !strings.HasPrefix(err.Error(), tc.ExpectedError)) {
+				t.Errorf("got:%v, expected:%v", err, tc.ExpectedError)
+			}
+		})
+	}
+}
+
+func TestDurationUnmarshal(t *testing.T) {
+	var tomlConfig Config
+	if _, err := toml.Decode(`command_timeout = "5m"`, &tomlConfig); err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	if tomlConfig.CommandTimeout.Duration != 5*time.Minute {
+		t.Errorf("got:%v, expected:%v", tomlConfig.CommandTimeout, 5*time.Minute)
+	}
+
+	var yamlConfig Config
+	if err := yaml.Unmarshal([]byte(`idle_timeout: "1m30s"`), &yamlConfig); err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	if yamlConfig.IdleTimeout.Duration != 90*time.Second {
+		t.Errorf("got:%v, expected:%v", yamlConfig.IdleTimeout, 90*time.Second)
+	}
+
+	if err := yaml.Unmarshal([]byte(`idle_timeout: "soon"`), &yamlConfig); err == nil {
+		t.Errorf("expected error of invalid duration")
+	}
+}
+
+func TestExecuteCommandTemplates(t *testing.T) {
+	data := NewTemplateData(&entities.Job{
+		Host: entities.Host{IP: "10.0.0.1", Password: "secret", Vars: map[string]string{"site": "waw"}},
+		Data: map[string]string{"vlan": "100"},
+	})
+
+	cases := []struct {
+		Name          string
+		Commands      []entities.Command
+		Expected      []string
+		ExpectedError string
+	}{
+		{Name: "OK, no templates", Commands: []entities.Command{{Body: "/export", Template: true}}, Expected: []string{"/export"}},
+		{Name: "OK, templates disabled", Commands: []entities.Command{{Body: "/log info {{ .Vars.address }}"}}, Expected: []string{"/log info {{ .Vars.address }}"}},
+		{Name: "OK, host, vars and data", Commands: []entities.Command{{Body: "/system identity set name={{ .Vars.site }}-{{ .Host.IP }} vlan={{ .Data.vlan }}", Template: true}}, Expected: []string{"/system identity set name=waw-10.0.0.1 vlan=100"}},
+		{
+			Name: "OK, matches",
+			Commands: []entities.Command{
+				{Body: "/system identity print waw", Match: `(waw)`, MatchPrefix: "m"},
+				{Body: `{{ if eq .Matches.m1 "waw" }}/log info central{{ end }}`, Template: true},
+			},
+			Expected: []string{"/system identity print waw", "/log info central"},
+		},
+		{Name: "Wrong, unknown variable", Commands: []entities.Command{{Body: "/ip address add address={{ .Vars.address }}", Template: true}}, ExpectedError: "command template error"},
+		{Name: "Wrong, host's password", Commands: []entities.Command{{Body: "/log info {{ .Host.Password }}", Template: true}}, ExpectedError: "command template error"},
+		{Name: "Wrong, template syntax", Commands: []entities.Command{{Body: "/export {{ .Vars.site", Template: true}}, ExpectedError: "command template error"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			results, _, err := ExecuteCommandTemplates(context.Background(), slowClient{}, tc.Commands, data)
+			if tc.ExpectedError == "" && err != nil {
+				t.Fatalf("not expected error %v", err)
+			}
+			if tc.ExpectedError != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tc.ExpectedError) {
+					t.Errorf("got:%v, expected:%v", err, tc.ExpectedError)
+				}
+				return
+			}
+
+			// client echoes executed commands, so first response is rendered body
+			var executed []string
+			for _, result := range results {
+				executed = append(executed, result.Responses[0])
+			}
+			if !reflect.DeepEqual(executed, tc.Expected) {
+				t.Errorf("got:%v, expected:%v", executed, tc.Expected)
+			}
+		})
+	}
+}
+
+func TestIsConnectionLost(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Err      error
+		Expected bool
+	}{
+		{Name: "Closed by device", Err: fmt.Errorf("command processing error: %w", io.EOF), Expected: true},
+		{Name: "Reset by device", Err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}, Expected: true},
+		{Name: "Network timeout", Err: &net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}, Expected: false},
+		{Name: "Command error", Err: errors.New("timeout on waiting to expected result"), Expected: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if IsConnectionLost(tc.Err) != tc.Expected {
+				t.Errorf("got:%v, expected:%v", !tc.Expected, tc.Expected)
+			}
+		})
+	}
+}
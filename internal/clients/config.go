@@ -1,9 +1,22 @@
 package clients
 
+import "time"
+
+const (
+	// DefaultCommandTimeout is default time limit of single command execution.
+	DefaultCommandTimeout = 30 * time.Second
+	// DefaultIdleTimeout is default time limit of waiting for any new output of command.
+	DefaultIdleTimeout = 3 * time.Second
+	// DefaultTransferTimeout is default time limit of single file transfer.
+	DefaultTransferTimeout = 30 * time.Second
+)
+
 // Clients represents list of supported clients types.
 type Clients struct {
-	SSH         Config `toml:"ssh" yaml:"ssh"`
-	MikrotikAPI Config `toml:"mikrotik_api" yaml:"mikrotik_api"`
+	SSH              Config `toml:"ssh" yaml:"ssh"`
+	MikrotikAPI      Config `toml:"mikrotik_api" yaml:"mikrotik_api"`
+	MikrotikAPIPlain Config `toml:"mikrotik_api_plain" yaml:"mikrotik_api_plain"`
+	REST             Config `toml:"rest" yaml:"rest"`
 }
 
 // Config of client.
@@ -13,6 +26,18 @@ type Config struct {
 	KeyStore      string `toml:"keys_store" yaml:"keys_store"`
 	Pty           Pty    `toml:"pty" yaml:"pty"`
 
+	VerifyCertificate  bool               `toml:"verify_certificate" yaml:"verify_certificate"`
+	CertificateName    string             `toml:"certificate_name" yaml:"certificate_name"`
+	DeviceCertificates DeviceCertificates `toml:"-" yaml:"-"`
+
+	HostKeyPolicy  string     `toml:"host_key_policy" yaml:"host_key_policy"`
+	KnownHostsFile string     `toml:"known_hosts" yaml:"known_hosts"`
+	KnownHosts     KnownHosts `toml:"-" yaml:"-"`
+
+	CommandTimeout  Duration `toml:"command_timeout" yaml:"command_timeout"`
+	IdleTimeout     Duration `toml:"idle_timeout" yaml:"idle_timeout"`
+	TransferTimeout Duration `toml:"transfer_timeout" yaml:"transfer_timeout"`
+
 	DefaultPort     string `toml:"port" yaml:"port"`
 	DefaultUser     string `toml:"user" yaml:"user"`
 	DefaultPassword string `toml:"password" yaml:"password"`
@@ -29,6 +54,11 @@ func NewConfig(port string) Config {
 	return Config{
 		VerifySleepMs: 1000,
 		Retries:       2,
+		HostKeyPolicy: HostKeyPolicyTOFU,
+
+		CommandTimeout:  Duration{DefaultCommandTimeout},
+		IdleTimeout:     Duration{DefaultIdleTimeout},
+		TransferTimeout: Duration{DefaultTransferTimeout},
 		Pty: Pty{
 			Width:  120,
 			Height: 200,
@@ -36,3 +66,36 @@ func NewConfig(port string) Config {
 		DefaultPort: port,
 	}
 }
+
+// Duration is time duration configured in human readable form, e.g. "90s" or "5m".
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalText parses duration from text, used by TOML decoder.
+func (d *Duration) UnmarshalText(text []byte) (err error) {
+	d.Duration, err = time.ParseDuration(string(text))
+	return err
+}
+
+// UnmarshalYAML parses duration from YAML string.
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var text string
+	if err := unmarshal(&text); err != nil {
+		return err
+	}
+	return d.UnmarshalText([]byte(text))
+}
+
+// MarshalText returns duration in text form.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// OrDefault returns duration or given default one if duration is not set.
+func (d Duration) OrDefault(defaultDuration time.Duration) time.Duration {
+	if d.Duration <= 0 {
+		return defaultDuration
+	}
+	return d.Duration
+}
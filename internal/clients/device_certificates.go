@@ -0,0 +1,161 @@
+package clients
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger"
+
+	"github.com/migotom/mt-bulk/internal/kvdb"
+)
+
+const kvTagDeviceCertificate = "DeviceCertificate:"
+
+// DeviceCertificate describes certificate issued by MT-bulk CA for single device.
+type DeviceCertificate struct {
+	Host         string
+	Identity     string
+	SerialNumber string
+	Fingerprint  string
+	NotBefore    time.Time
+	NotAfter     time.Time
+	Revoked      bool
+	RevokedAt    time.Time
+}
+
+func (c DeviceCertificate) String() string {
+	status := "valid"
+	if c.Revoked {
+		status = fmt.Sprintf("revoked at %s", c.RevokedAt.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("%s %s serial:%s expires:%s %s", c.Host, c.Identity, c.SerialNumber, c.NotAfter.Format(time.RFC3339), status)
+}
+
+// DeviceCertificates is a registry of certificates issued for devices.
+type DeviceCertificates interface {
+	Get(host string) (DeviceCertificate, bool, error)
+	Store(DeviceCertificate) error
+	Revoke(host string) error
+	List() ([]DeviceCertificate, error)
+}
+
+// NewDeviceCertificates returns registry of certificates issued for devices stored in MT-bulk database.
+func NewDeviceCertificates(kv kvdb.KV) DeviceCertificates {
+	return &deviceCertificatesKV{kv: kv}
+}
+
+// VerifyDeviceCertificate verifies that certificate presented by device is the one issued for it and not revoked,
+// devices without issued certificate (e.g. still using shared certificate) are rejected.
+func VerifyDeviceCertificate(deviceCertificates DeviceCertificates, host string, certificate *x509.Certificate) error {
+	issued, found, err := deviceCertificates.Get(host)
+	if err != nil {
+		return fmt.Errorf("can't read certificate issued for %s: %v", host, err)
+	}
+	if !found {
+		return fmt.Errorf("certificate for %s not issued by MT-bulk, initialize device by init-secure-api", host)
+	}
+	if issued.Revoked {
+		return fmt.Errorf("certificate issued for %s revoked", host)
+	}
+	if issued.SerialNumber != certificate.SerialNumber.Text(16) {
+		return fmt.Errorf("certificate presented by %s is not the one issued for device", host)
+	}
+	return nil
+}
+
+// setDeviceVerification configures TLS verification of certificate presented by device, it has to be signed by MT-bulk CA and bound to device's IP.
+// Configured certificate name is accepted instead of device's IP only together with verification against registry of issued certificates,
+// so certificate of one device can't be used to impersonate another one. Error of registry verification is stored in certificateErr.
+func setDeviceVerification(tlsCfg *tls.Config, config Config, IP string, certificateErr *error) (err error) {
+	if !config.VerifyCertificate {
+		return nil
+	}
+
+	if tlsCfg.RootCAs, err = LoadCA(config.KeyStore); err != nil {
+		return err
+	}
+	tlsCfg.ServerName = IP
+	if config.CertificateName != "" {
+		if config.DeviceCertificates == nil {
+			return errors.New("certificate_name requires verification against registry of issued device certificates")
+		}
+		tlsCfg.ServerName = config.CertificateName
+	}
+	tlsCfg.InsecureSkipVerify = false
+
+	if config.DeviceCertificates != nil {
+		tlsCfg.VerifyPeerCertificate = func(_ [][]byte, chains [][]*x509.Certificate) error {
+			if len(chains) == 0 || len(chains[0]) == 0 {
+				*certificateErr = errors.New("device certificate not provided")
+				return *certificateErr
+			}
+			*certificateErr = VerifyDeviceCertificate(config.DeviceCertificates, IP, chains[0][0])
+			return *certificateErr
+		}
+	}
+	return nil
+}
+
+type deviceCertificatesKV struct {
+	kv kvdb.KV
+}
+
+func (d *deviceCertificatesKV) Get(host string) (certificate DeviceCertificate, found bool, err error) {
+	err = d.kv.View(func(txn kvdb.Txn) error {
+		return txn.GetCopy(kvTagDeviceCertificate+host, &certificate)
+	})
+	if err == badger.ErrKeyNotFound {
+		return DeviceCertificate{}, false, nil
+	}
+	return certificate, err == nil, err
+}
+
+func (d *deviceCertificatesKV) Store(certificate DeviceCertificate) error {
+	txn := d.kv.NewTransaction()
+	defer txn.Discard()
+
+	if err := txn.Store(kvTagDeviceCertificate+certificate.Host, certificate); err != nil {
+		return err
+	}
+	return txn.Commit()
+}
+
+func (d *deviceCertificatesKV) Revoke(host string) error {
+	certificate, found, err := d.Get(host)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("certificate for %s not issued", host)
+	}
+
+	certificate.Revoked = true
+	certificate.RevokedAt = time.Now()
+	return d.Store(certificate)
+}
+
+func (d *deviceCertificatesKV) List() (list []DeviceCertificate, err error) {
+	err = d.kv.View(func(txn kvdb.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: []byte(kvTagDeviceCertificate)})
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			key := string(it.Item().KeyCopy(nil))
+			if !strings.HasPrefix(key, kvTagDeviceCertificate) {
+				continue
+			}
+
+			var certificate DeviceCertificate
+			if err := txn.GetCopy(key, &certificate); err != nil {
+				return err
+			}
+			list = append(list, certificate)
+		}
+		return nil
+	})
+	return list, err
+}
@@ -1,5 +1,7 @@
 package clients
 
+import "fmt"
+
 // ErrorRetryable represents retryable type error.
 type ErrorRetryable struct {
 	Err error
@@ -17,3 +19,35 @@ type ErrorWrongPassword struct {
 func (e ErrorWrongPassword) Error() string {
 	return e.Err.Error()
 }
+
+// ErrorHostKey represents host key verification failure, it is not retryable.
+type ErrorHostKey struct {
+	Err error
+}
+
+func (e ErrorHostKey) Error() string {
+	return e.Err.Error()
+}
+
+// ErrorCertificate represents device certificate verification failure, it is not retryable.
+type ErrorCertificate struct {
+	Err error
+}
+
+func (e ErrorCertificate) Error() string {
+	return e.Err.Error()
+}
+
+// ErrorREST represents error response of RouterOS REST API.
+type ErrorREST struct {
+	StatusCode int    `json:"error"`
+	Message    string `json:"message"`
+	Detail     string `json:"detail"`
+}
+
+func (e ErrorREST) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("REST API error %d %s: %s", e.StatusCode, e.Message, e.Detail)
+	}
+	return fmt.Sprintf("REST API error %d %s", e.StatusCode, e.Message)
+}
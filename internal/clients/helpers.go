@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"path"
 	"path/filepath"
 	"regexp"
@@ -26,7 +27,18 @@ func SecurePathJoin(root, name string) (string, error) {
 	return filepath.FromSlash(filepath.Join(root, filepath.FromSlash(path.Clean("/"+name)))), nil
 }
 
-func waitForExpected(reader io.Reader, expect *regexp.Regexp) (result string, err error) {
+// IsConnectionLost returns true if error is caused by connection closed by device, e.g. after reboot or changed addressing.
+func IsConnectionLost(err error) bool {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) {
+		return true
+	}
+
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && !opErr.Timeout()
+}
+
+// waitForExpected reads output until it matches expected regexp, fails if there is no new output for given idle time.
+func waitForExpected(reader io.Reader, expect *regexp.Regexp, idle time.Duration) (result string, err error) {
 	resultChan := make(chan string)
 	errorChan := make(chan error)
 
@@ -62,7 +74,7 @@ func waitForExpected(reader io.Reader, expect *regexp.Regexp) (result string, er
 		case result = <-resultChan:
 		case err = <-errorChan:
 			return
-		case <-time.After(3 * time.Second):
+		case <-time.After(idle):
 			err = fmt.Errorf("timeout on waiting to expected result: %s", expect.String())
 			return
 		}
@@ -0,0 +1,276 @@
+package clients
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger"
+	cryptossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/migotom/mt-bulk/internal/kvdb"
+)
+
+const (
+	// HostKeyPolicyStrict accepts only already known host keys.
+	HostKeyPolicyStrict = "strict"
+	// HostKeyPolicyTOFU stores host key seen for the first time and verifies it on each next connection.
+	HostKeyPolicyTOFU = "tofu"
+	// HostKeyPolicyOff disables host key verification.
+	HostKeyPolicyOff = "off"
+	// HostKeyPolicyAccept replaces stored host key by one presented by device.
+	HostKeyPolicyAccept = "accept"
+)
+
+const kvTagKnownHost = "KnownHost:"
+
+// KnownHost is SSH host key of single device.
+type KnownHost struct {
+	Host  string
+	Key   string
+	Added time.Time
+}
+
+// Fingerprint returns SHA256 fingerprint of host key.
+func (k KnownHost) Fingerprint() string {
+	key, _, _, _, err := cryptossh.ParseAuthorizedKey([]byte(k.Key))
+	if err != nil {
+		return "invalid key"
+	}
+	return cryptossh.FingerprintSHA256(key)
+}
+
+func (k KnownHost) String() string {
+	return fmt.Sprintf("%s %s %s", k.Host, k.Fingerprint(), k.Added.Format(time.RFC3339))
+}
+
+// KnownHosts is a store of known SSH host keys.
+type KnownHosts interface {
+	Get(host string) (KnownHost, bool, error)
+	Store(KnownHost) error
+	Remove(host string) error
+	List() ([]KnownHost, error)
+}
+
+// NewKnownHosts returns known hosts store, file in known_hosts format is used if provided otherwise MT-bulk database.
+func NewKnownHosts(kv kvdb.KV, file string) KnownHosts {
+	if file != "" {
+		return &knownHostsFile{file: file}
+	}
+	return &knownHostsKV{kv: kv}
+}
+
+// KnownHostAddress returns normalized host address used as known hosts store key.
+func KnownHostAddress(IP, Port string) string {
+	return knownhosts.Normalize(net.JoinHostPort(IP, Port))
+}
+
+// HostKeyCallback returns SSH host key callback verifying device's key according to given policy.
+func HostKeyCallback(policy string, knownHosts KnownHosts) cryptossh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key cryptossh.PublicKey) error {
+		if policy == HostKeyPolicyOff {
+			return nil
+		}
+		if knownHosts == nil {
+			return ErrorHostKey{fmt.Errorf("known hosts store not configured")}
+		}
+
+		host := knownhosts.Normalize(hostname)
+		presented := KnownHost{
+			Host:  host,
+			Key:   strings.TrimSpace(string(cryptossh.MarshalAuthorizedKey(key))),
+			Added: time.Now(),
+		}
+
+		stored, found, err := knownHosts.Get(host)
+		if err != nil {
+			return ErrorHostKey{fmt.Errorf("can't read known host %s: %v", host, err)}
+		}
+
+		switch {
+		case policy == HostKeyPolicyAccept:
+			return knownHosts.Store(presented)
+		case found && stored.Key == presented.Key:
+			return nil
+		case found:
+			return ErrorHostKey{fmt.Errorf("host key mismatch for %s, expected %s, got %s", host, stored.Fingerprint(), presented.Fingerprint())}
+		case policy == HostKeyPolicyTOFU:
+			return knownHosts.Store(presented)
+		case policy == HostKeyPolicyStrict:
+			return ErrorHostKey{fmt.Errorf("unknown host key for %s (%s)", host, presented.Fingerprint())}
+		}
+		return ErrorHostKey{fmt.Errorf("unknown host key policy %s", policy)}
+	}
+}
+
+type knownHostsKV struct {
+	kv kvdb.KV
+}
+
+func (k *knownHostsKV) Get(host string) (knownHost KnownHost, found bool, err error) {
+	err = k.kv.View(func(txn kvdb.Txn) error {
+		return txn.GetCopy(kvTagKnownHost+host, &knownHost)
+	})
+	if err == badger.ErrKeyNotFound {
+		return KnownHost{}, false, nil
+	}
+	return knownHost, err == nil, err
+}
+
+func (k *knownHostsKV) Store(knownHost KnownHost) error {
+	txn := k.kv.NewTransaction()
+	defer txn.Discard()
+
+	if err := txn.Store(kvTagKnownHost+knownHost.Host, knownHost); err != nil {
+		return err
+	}
+	return txn.Commit()
+}
+
+func (k *knownHostsKV) Remove(host string) error {
+	txn := k.kv.NewTransaction()
+	defer txn.Discard()
+
+	if err := txn.Delete(kvTagKnownHost + host); err != nil {
+		return err
+	}
+	return txn.Commit()
+}
+
+func (k *knownHostsKV) List() (list []KnownHost, err error) {
+	err = k.kv.View(func(txn kvdb.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: []byte(kvTagKnownHost)})
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			key := string(it.Item().KeyCopy(nil))
+			if !strings.HasPrefix(key, kvTagKnownHost) {
+				continue
+			}
+
+			var knownHost KnownHost
+			if err := txn.GetCopy(key, &knownHost); err != nil {
+				return err
+			}
+			list = append(list, knownHost)
+		}
+		return nil
+	})
+	return list, err
+}
+
+type knownHostsFile struct {
+	sync.Mutex
+
+	file string
+}
+
+func (k *knownHostsFile) Get(host string) (KnownHost, bool, error) {
+	k.Lock()
+	defer k.Unlock()
+
+	list, err := k.read()
+	if err != nil {
+		return KnownHost{}, false, err
+	}
+	for _, knownHost := range list {
+		if knownHost.Host == host {
+			return knownHost, true, nil
+		}
+	}
+	return KnownHost{}, false, nil
+}
+
+func (k *knownHostsFile) Store(knownHost KnownHost) error {
+	k.Lock()
+	defer k.Unlock()
+
+	list, err := k.read()
+	if err != nil {
+		return err
+	}
+
+	updated := make([]KnownHost, 0, len(list)+1)
+	for _, stored := range list {
+		if stored.Host != knownHost.Host {
+			updated = append(updated, stored)
+		}
+	}
+	return k.write(append(updated, knownHost))
+}
+
+func (k *knownHostsFile) Remove(host string) error {
+	k.Lock()
+	defer k.Unlock()
+
+	list, err := k.read()
+	if err != nil {
+		return err
+	}
+
+	updated := make([]KnownHost, 0, len(list))
+	for _, stored := range list {
+		if stored.Host != host {
+			updated = append(updated, stored)
+		}
+	}
+	return k.write(updated)
+}
+
+func (k *knownHostsFile) List() ([]KnownHost, error) {
+	k.Lock()
+	defer k.Unlock()
+
+	return k.read()
+}
+
+func (k *knownHostsFile) read() (list []KnownHost, err error) {
+	content, err := ioutil.ReadFile(k.file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		_, hosts, key, comment, _, err := cryptossh.ParseKnownHosts([]byte(line))
+		if err != nil {
+			return nil, fmt.Errorf("invalid known hosts entry %q: %v", line, err)
+		}
+
+		added, _ := time.Parse(time.RFC3339, comment)
+		for _, host := range hosts {
+			list = append(list, KnownHost{
+				Host:  host,
+				Key:   strings.TrimSpace(string(cryptossh.MarshalAuthorizedKey(key))),
+				Added: added,
+			})
+		}
+	}
+	return list, scanner.Err()
+}
+
+func (k *knownHostsFile) write(list []KnownHost) error {
+	sort.Slice(list, func(i, j int) bool { return list[i].Host < list[j].Host })
+
+	var content strings.Builder
+	for _, knownHost := range list {
+		content.WriteString(fmt.Sprintf("%s %s %s\n", knownHost.Host, knownHost.Key, knownHost.Added.Format(time.RFC3339)))
+	}
+	return ioutil.WriteFile(k.file, []byte(content.String()), 0600)
+}
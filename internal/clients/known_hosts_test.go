@@ -0,0 +1,83 @@
+package clients
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cryptossh "golang.org/x/crypto/ssh"
+)
+
+func newTestHostKey(t *testing.T) cryptossh.PublicKey {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("can't generate key: %v", err)
+	}
+	key, err := cryptossh.NewPublicKey(public)
+	if err != nil {
+		t.Fatalf("can't convert key: %v", err)
+	}
+	return key
+}
+
+func TestHostKeyCallback(t *testing.T) {
+	knownKey := newTestHostKey(t)
+	otherKey := newTestHostKey(t)
+
+	cases := []struct {
+		Name          string
+		Policy        string
+		Known         bool
+		Key           cryptossh.PublicKey
+		ExpectedError bool
+		ExpectedKey   cryptossh.PublicKey
+	}{
+		{Name: "OK, tofu stores unknown key", Policy: HostKeyPolicyTOFU, Key: knownKey, ExpectedKey: knownKey},
+		{Name: "OK, tofu known key", Policy: HostKeyPolicyTOFU, Known: true, Key: knownKey, ExpectedKey: knownKey},
+		{Name: "Wrong, tofu mismatch", Policy: HostKeyPolicyTOFU, Known: true, Key: otherKey, ExpectedError: true, ExpectedKey: knownKey},
+		{Name: "Wrong, strict unknown key", Policy: HostKeyPolicyStrict, Key: knownKey, ExpectedError: true},
+		{Name: "OK, strict known key", Policy: HostKeyPolicyStrict, Known: true, Key: knownKey, ExpectedKey: knownKey},
+		{Name: "OK, off ignores mismatch", Policy: HostKeyPolicyOff, Known: true, Key: otherKey, ExpectedKey: knownKey},
+		{Name: "OK, accept replaces key", Policy: HostKeyPolicyAccept, Known: true, Key: otherKey, ExpectedKey: otherKey},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "known_hosts")
+			if err != nil {
+				t.Fatalf("can't create temporary directory %v", err)
+			}
+			defer os.RemoveAll(dir)
+
+			knownHosts := NewKnownHosts(nil, filepath.Join(dir, "known_hosts"))
+			if tc.Known {
+				if err := knownHosts.Store(KnownHost{Host: "[10.0.0.1]:2222", Key: strings.TrimSpace(string(cryptossh.MarshalAuthorizedKey(knownKey)))}); err != nil {
+					t.Fatalf("can't store known host %v", err)
+				}
+			}
+
+			err = HostKeyCallback(tc.Policy, knownHosts)("10.0.0.1:2222", nil, tc.Key)
+			if _, ok := err.(ErrorHostKey); ok != tc.ExpectedError {
+				t.Errorf("not expected error %v", err)
+			}
+
+			stored, found, err := knownHosts.Get("[10.0.0.1]:2222")
+			if err != nil {
+				t.Fatalf("can't read known host %v", err)
+			}
+			if tc.ExpectedKey == nil {
+				if found {
+					t.Errorf("not expected stored key %v", stored)
+				}
+				return
+			}
+			if !found || stored.Fingerprint() != cryptossh.FingerprintSHA256(tc.ExpectedKey) {
+				t.Errorf("got:%v, expected:%v", stored.Fingerprint(), cryptossh.FingerprintSHA256(tc.ExpectedKey))
+			}
+		})
+	}
+}
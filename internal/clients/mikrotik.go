@@ -3,6 +3,8 @@ package clients
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"regexp"
@@ -10,6 +12,8 @@ import (
 	"time"
 
 	"github.com/migotom/routeros"
+
+	"github.com/migotom/mt-bulk/internal/entities"
 )
 
 // MikrotikAPIDefaultPort is default Mikrotik SSL API server port.
@@ -48,6 +52,12 @@ func (mikrotikAPI *MikrotikAPI) Connect(ctx context.Context, IP, Port, User, Pas
 		InsecureSkipVerify: true,
 	}
 
+	// verify device presents certificate signed by MT-bulk CA, issued for it and not revoked
+	var certificateErr error
+	if err := setDeviceVerification(&tlsCfg, mikrotikAPI.Config, IP, &certificateErr); err != nil {
+		return ErrorCertificate{err}
+	}
+
 	tlsCfg.CipherSuites = append(tlsCfg.CipherSuites, tls.TLS_RSA_WITH_AES_128_CBC_SHA)
 	tlsCfg.CipherSuites = append(tlsCfg.CipherSuites, tls.TLS_RSA_WITH_AES_128_CBC_SHA256)
 	tlsCfg.CipherSuites = append(tlsCfg.CipherSuites, tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA)
@@ -59,6 +69,12 @@ func (mikrotikAPI *MikrotikAPI) Connect(ctx context.Context, IP, Port, User, Pas
 	if err != nil && strings.HasPrefix(err.Error(), "from RouterOS device: invalid user name or password") {
 		return ErrorWrongPassword{err}
 	}
+	if certificateErr != nil {
+		return ErrorCertificate{certificateErr}
+	}
+	if err != nil && isCertificateError(err) {
+		return ErrorCertificate{fmt.Errorf("mikrotik API certificate verification error %v", err)}
+	}
 	if err != nil {
 		return ErrorRetryable{fmt.Errorf("mikrotik API handle sequence error %v", err)}
 	}
@@ -67,6 +83,14 @@ func (mikrotikAPI *MikrotikAPI) Connect(ctx context.Context, IP, Port, User, Pas
 	return nil
 }
 
+func isCertificateError(err error) bool {
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var certificateInvalidErr x509.CertificateInvalidError
+
+	return errors.As(err, &unknownAuthorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &certificateInvalidErr)
+}
+
 // Close Mikrotik API client session.
 func (mikrotikAPI *MikrotikAPI) Close() {
 	mikrotikAPI.mtClient.Close()
@@ -74,14 +98,37 @@ func (mikrotikAPI *MikrotikAPI) Close() {
 
 // RunCmd execues given command on remote device, optionally can compare execution result with provided expect regexp.
 func (mikrotikAPI MikrotikAPI) RunCmd(body string, expect *regexp.Regexp) (result string, err error) {
-	reply, err := mikrotikAPI.mtClient.RunArgs(strings.Split(body, " "))
+	result, _, err = mikrotikAPI.RunCmdRecords(body, expect)
+	return result, err
+}
+
+// RunCmdRecords execues given command on remote device and returns reply's !re sentences as records.
+func (mikrotikAPI MikrotikAPI) RunCmdRecords(body string, expect *regexp.Regexp) (result string, records []map[string]string, err error) {
+	words, err := entities.SplitSentence(body)
+	if err != nil {
+		return "", nil, err
+	}
+
+	reply, err := mikrotikAPI.mtClient.RunArgs(words)
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+
+	records = make([]map[string]string, 0, len(reply.Re))
+	for _, sentence := range reply.Re {
+		record := make(map[string]string, len(sentence.Map))
+		for key, value := range sentence.Map {
+			record[key] = value
+		}
+		records = append(records, record)
+	}
+	if ret, ok := reply.Done.Map["ret"]; ok {
+		records = append(records, map[string]string{"ret": ret})
 	}
 
 	if expect != nil {
-		_, err = waitForExpected(strings.NewReader(reply.String()), expect)
+		_, err = waitForExpected(strings.NewReader(reply.String()), expect, mikrotikAPI.IdleTimeout.OrDefault(DefaultIdleTimeout))
 	}
 
-	return fmt.Sprintf("%s\n%s", body, reply.String()), err
+	return fmt.Sprintf("%s\n%s", body, reply.String()), records, err
 }
@@ -0,0 +1,55 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/migotom/routeros"
+)
+
+// MikrotikAPIPlainDefaultPort is default Mikrotik plain (non-TLS) API server port.
+const MikrotikAPIPlainDefaultPort = "8728"
+
+// NewMikrotikAPIPlainClient returns new Mikrotik plain API client.
+func NewMikrotikAPIPlainClient(config Config) Client {
+	return &MikrotikAPIPlain{
+		MikrotikAPI: MikrotikAPI{
+			Config: config,
+		},
+	}
+}
+
+// MikrotikAPIPlain defines Mikrotik plain (non-TLS) API client.
+type MikrotikAPIPlain struct {
+	MikrotikAPI
+}
+
+// Connect to routerOS by Mikrotik plain API.
+// Login uses MD5 challenge on RouterOS older than 6.43 and plain login on newer ones.
+func (mikrotikAPI *MikrotikAPIPlain) Connect(ctx context.Context, IP, Port, User, Password string) (err error) {
+	dialer := net.Dialer{Timeout: 30 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", fmt.Sprintf("%s:%s", IP, Port))
+	if err != nil {
+		return ErrorRetryable{fmt.Errorf("mikrotik API handle sequence error %v", err)}
+	}
+
+	mikrotikAPI.mtClient, err = routeros.NewClient(conn)
+	if err == nil {
+		err = mikrotikAPI.mtClient.Login(User, Password)
+	}
+	if err != nil {
+		conn.Close()
+	}
+	if err != nil && strings.HasPrefix(err.Error(), "from RouterOS device: invalid user name or password") {
+		return ErrorWrongPassword{err}
+	}
+	if err != nil {
+		return ErrorRetryable{fmt.Errorf("mikrotik API handle sequence error %v", err)}
+	}
+
+	mikrotikAPI.mtClient.Async()
+	return nil
+}
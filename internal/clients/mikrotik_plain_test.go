@@ -0,0 +1,106 @@
+package clients
+
+import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/go-routeros/routeros/proto"
+)
+
+func servePlainAPILogin(t *testing.T, listener net.Listener, challenge bool) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func(conn net.Conn) {
+			defer conn.Close()
+
+			r := proto.NewReader(conn)
+			w := proto.NewWriter(conn)
+			reply := func(words ...string) {
+				w.BeginSentence()
+				for _, word := range words {
+					w.WriteWord(word)
+				}
+				if err := w.EndSentence(); err != nil {
+					t.Errorf("can't write sentence %v", err)
+				}
+			}
+
+			sentence, err := r.ReadSentence()
+			if err != nil {
+				return
+			}
+
+			password := sentence.Map["password"]
+			if challenge {
+				reply("!done", "=ret=abc123")
+				if sentence, err = r.ReadSentence(); err != nil {
+					return
+				}
+
+				h := md5.New()
+				h.Write([]byte{0})
+				io.WriteString(h, "secret")
+				h.Write([]byte{0xab, 0xc1, 0x23})
+				if sentence.Map["response"] == fmt.Sprintf("00%x", h.Sum(nil)) {
+					password = "secret"
+				}
+			}
+
+			if sentence.Map["name"] != "admin" || password != "secret" {
+				reply("!trap", "=message=invalid user name or password (6)")
+			}
+			reply("!done")
+
+			// keep connection open until client closes it
+			_, _ = r.ReadSentence()
+		}(conn)
+	}
+}
+
+func TestMikrotikAPIPlainConnect(t *testing.T) {
+	cases := []struct {
+		Name               string
+		Challenge          bool
+		Password           string
+		ExpectedError      bool
+		ExpectedWrongLogin bool
+	}{
+		{Name: "OK, plain login", Password: "secret"},
+		{Name: "OK, challenge login", Challenge: true, Password: "secret"},
+		{Name: "Wrong, plain login password", Password: "other", ExpectedError: true, ExpectedWrongLogin: true},
+		{Name: "Wrong, challenge login password", Challenge: true, Password: "other", ExpectedError: true, ExpectedWrongLogin: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("can't listen %v", err)
+			}
+			defer listener.Close()
+			go servePlainAPILogin(t, listener, tc.Challenge)
+
+			host, port, _ := net.SplitHostPort(listener.Addr().String())
+
+			client := NewMikrotikAPIPlainClient(Config{})
+			err = client.Connect(context.Background(), host, port, "admin", tc.Password)
+			if (err != nil) != tc.ExpectedError {
+				t.Fatalf("not expected error %v", err)
+			}
+			if _, ok := err.(ErrorWrongPassword); ok != tc.ExpectedWrongLogin {
+				t.Errorf("not expected error %v", err)
+			}
+			if err == nil {
+				client.Close()
+			}
+		})
+	}
+}
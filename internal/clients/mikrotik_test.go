@@ -0,0 +1,131 @@
+package clients
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type deviceCertificatesMap map[string]DeviceCertificate
+
+func (d deviceCertificatesMap) Get(host string) (DeviceCertificate, bool, error) {
+	certificate, found := d[host]
+	return certificate, found, nil
+}
+
+func (d deviceCertificatesMap) Store(certificate DeviceCertificate) error {
+	d[certificate.Host] = certificate
+	return nil
+}
+
+func (d deviceCertificatesMap) Revoke(host string) error {
+	certificate := d[host]
+	certificate.Revoked = true
+	d[host] = certificate
+	return nil
+}
+
+func (d deviceCertificatesMap) List() (list []DeviceCertificate, err error) {
+	for _, certificate := range d {
+		list = append(list, certificate)
+	}
+	return list, nil
+}
+
+func TestMikrotikAPIVerifyCertificate(t *testing.T) {
+	store, err := ioutil.TempDir("", "certs")
+	if err != nil {
+		t.Fatalf("can't create temporary directory %v", err)
+	}
+	defer os.RemoveAll(store)
+
+	for _, generate := range []func() error{
+		func() error { return GenerateCA(store) },
+		func() error { return GenerateCerts(store, "device") },
+		func() error { return GenerateCerts(store, "client") },
+	} {
+		if err := generate(); err != nil {
+			t.Fatalf("can't generate certificates %v", err)
+		}
+	}
+
+	device, err := tls.LoadX509KeyPair(filepath.Join(store, "device.crt"), filepath.Join(store, "device.key"))
+	if err != nil {
+		t.Fatalf("can't load device certificate %v", err)
+	}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{device}})
+	if err != nil {
+		t.Fatalf("can't listen %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	leaf, err := x509.ParseCertificate(device.Certificate[0])
+	if err != nil {
+		t.Fatalf("can't parse device certificate %v", err)
+	}
+	issued := deviceCertificatesMap{host: {Host: host, SerialNumber: leaf.SerialNumber.Text(16)}}
+	otherDevice := deviceCertificatesMap{host: {Host: host, SerialNumber: "1"}}
+
+	cases := []struct {
+		Name                     string
+		Config                   Config
+		ExpectedCertificateError bool
+	}{
+		{
+			Name:   "OK, verification disabled",
+			Config: Config{KeyStore: store},
+		},
+		{
+			Name:   "OK, certificate bound to name and issued for device",
+			Config: Config{KeyStore: store, VerifyCertificate: true, CertificateName: "mtbulkdevice.cell-crm.com", DeviceCertificates: issued},
+		},
+		{
+			Name:                     "Wrong, certificate bound to name without registry of issued certificates",
+			Config:                   Config{KeyStore: store, VerifyCertificate: true, CertificateName: "mtbulkdevice.cell-crm.com"},
+			ExpectedCertificateError: true,
+		},
+		{
+			Name:                     "Wrong, certificate bound to name issued for other device",
+			Config:                   Config{KeyStore: store, VerifyCertificate: true, CertificateName: "mtbulkdevice.cell-crm.com", DeviceCertificates: otherDevice},
+			ExpectedCertificateError: true,
+		},
+		{
+			Name:                     "Wrong, certificate not bound to device IP",
+			Config:                   Config{KeyStore: store, VerifyCertificate: true},
+			ExpectedCertificateError: true,
+		},
+		{
+			Name:                     "Wrong, certificate bound to other name",
+			Config:                   Config{KeyStore: store, VerifyCertificate: true, CertificateName: "other.cell-crm.com", DeviceCertificates: issued},
+			ExpectedCertificateError: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := NewMikrotikAPIClient(tc.Config).Connect(context.Background(), host, port, "admin", "secret")
+			if _, ok := err.(ErrorCertificate); ok != tc.ExpectedCertificateError {
+				t.Errorf("not expected error %v", err)
+			}
+		})
+	}
+}
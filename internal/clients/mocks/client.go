@@ -37,3 +37,15 @@ func (t Client) RunCmd(val string, re *regexp.Regexp) (string, error) {
 // Close mock.
 func (t Client) Close() {
 }
+
+// RecordsClient mocked, replies with given records to each command.
+type RecordsClient struct {
+	Client
+
+	Records []map[string]string
+}
+
+// RunCmdRecords mock.
+func (t RecordsClient) RunCmdRecords(val string, re *regexp.Regexp) (string, []map[string]string, error) {
+	return val, t.Records, nil
+}
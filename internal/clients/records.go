@@ -0,0 +1,95 @@
+package clients
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RecordMatchPrefix is prefix of match addressing field of structured records instead of regexp, e.g. `record:.id where name=ether1`.
+const RecordMatchPrefix = "record:"
+
+var recordMatchRx = regexp.MustCompile(`^\s*(\S+)(?:\s+where\s+(.+))?\s*$`)
+
+// RecordMatch addresses field of structured records optionally filtered by conditions on other fields.
+type RecordMatch struct {
+	Field      string
+	Conditions []RecordCondition
+}
+
+// RecordCondition is condition on record's field value, equal or (if negated) not equal to given value.
+type RecordCondition struct {
+	Key     string
+	Value   string
+	Negated bool
+}
+
+// ParseRecordMatch parses match in format `record:<field>[ where <key>=<value>[ and <key>!=<value>...]]`.
+func ParseRecordMatch(match string) (RecordMatch, error) {
+	parts := recordMatchRx.FindStringSubmatch(strings.TrimPrefix(match, RecordMatchPrefix))
+	if parts == nil || parts[1] == "where" {
+		return RecordMatch{}, fmt.Errorf("invalid record match %q", match)
+	}
+
+	recordMatch := RecordMatch{Field: parts[1]}
+	where := parts[2]
+	if where == "" {
+		return recordMatch, nil
+	}
+	for _, condition := range strings.Split(where, " and ") {
+		kv := strings.SplitN(strings.TrimSpace(condition), "=", 2)
+		if len(kv) != 2 || strings.TrimSuffix(kv[0], "!") == "" {
+			return RecordMatch{}, fmt.Errorf("invalid record match %q, condition %q", match, condition)
+		}
+		recordMatch.Conditions = append(recordMatch.Conditions, RecordCondition{
+			Key:     strings.TrimSuffix(kv[0], "!"),
+			Value:   kv[1],
+			Negated: strings.HasSuffix(kv[0], "!"),
+		})
+	}
+	return recordMatch, nil
+}
+
+// Find returns values of addressed field of all records fulfilling conditions.
+func (m RecordMatch) Find(records []map[string]string) (values []string) {
+recordsScan:
+	for _, record := range records {
+		for _, condition := range m.Conditions {
+			if (record[condition.Key] == condition.Value) == condition.Negated {
+				continue recordsScan
+			}
+		}
+		if value, ok := record[m.Field]; ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// jsonRecords converts JSON object or list of objects into records, non string values are kept in JSON form.
+func jsonRecords(content []byte) []map[string]string {
+	var objects []map[string]interface{}
+	if err := json.Unmarshal(content, &objects); err != nil {
+		var object map[string]interface{}
+		if err := json.Unmarshal(content, &object); err != nil {
+			return nil
+		}
+		objects = append(objects, object)
+	}
+
+	records := make([]map[string]string, 0, len(objects))
+	for _, object := range objects {
+		record := make(map[string]string, len(object))
+		for key, value := range object {
+			if s, ok := value.(string); ok {
+				record[key] = s
+				continue
+			}
+			encoded, _ := json.Marshal(value)
+			record[key] = string(encoded)
+		}
+		records = append(records, record)
+	}
+	return records
+}
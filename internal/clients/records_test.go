@@ -0,0 +1,47 @@
+package clients
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRecordMatch(t *testing.T) {
+	records := []map[string]string{
+		{".id": "*1", "name": "ether1", "type": "ether", "comment": "uplink port"},
+		{".id": "*2", "name": "ether2", "type": "ether"},
+		{".id": "*3", "name": "bridge", "type": "bridge"},
+	}
+
+	cases := []struct {
+		Name          string
+		Match         string
+		Expected      []string
+		ExpectedError bool
+	}{
+		{Name: "OK, all records", Match: "record:.id", Expected: []string{"*1", "*2", "*3"}},
+		{Name: "OK, single condition", Match: "record:.id where name=ether1", Expected: []string{"*1"}},
+		{Name: "OK, many conditions", Match: "record:name where type=ether and comment=uplink port", Expected: []string{"ether1"}},
+		{Name: "OK, negated condition", Match: "record:name where type!=ether", Expected: []string{"bridge"}},
+		{Name: "OK, no records matched", Match: "record:.id where name=ether9"},
+		{Name: "OK, field not present", Match: "record:comment where name=ether2"},
+		{Name: "Wrong, missing field", Match: "record: where name=ether1", ExpectedError: true},
+		{Name: "Wrong, invalid condition", Match: "record:.id where ether1", ExpectedError: true},
+		{Name: "Wrong, missing condition key", Match: "record:.id where !=ether1", ExpectedError: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			recordMatch, err := ParseRecordMatch(tc.Match)
+			if (err != nil) != tc.ExpectedError {
+				t.Fatalf("not expected error %v", err)
+			}
+			if tc.ExpectedError {
+				return
+			}
+
+			if values := recordMatch.Find(records); !reflect.DeepEqual(values, tc.Expected) {
+				t.Errorf("got:%v, expected:%v", values, tc.Expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+// RESTDefaultPort is default RouterOS v7 REST API (www-ssl service) port.
+const RESTDefaultPort = "443"
+
+// NewRESTClient returns new RouterOS REST API client.
+func NewRESTClient(config Config) Client {
+	return &REST{
+		Config: config,
+	}
+}
+
+// REST defines RouterOS v7 REST API client.
+type REST struct {
+	httpClient *http.Client
+	baseURL    string
+	user       string
+	password   string
+
+	Config
+}
+
+// GetConfig returns client's configuration.
+func (rest *REST) GetConfig() Config {
+	return rest.Config
+}
+
+// Connect to routerOS by REST API, credentials are verified by fetching system resources.
+func (rest *REST) Connect(ctx context.Context, IP, Port, User, Password string) (err error) {
+	tlsCfg := tls.Config{
+		InsecureSkipVerify: true,
+	}
+
+	// verify device presents certificate signed by MT-bulk CA, issued for it and not revoked
+	var certificateErr error
+	if err := setDeviceVerification(&tlsCfg, rest.Config, IP, &certificateErr); err != nil {
+		return ErrorCertificate{err}
+	}
+
+	// requests are limited by context of job and command, not by fixed client's timeout
+	rest.httpClient = &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tlsCfg},
+	}
+	rest.baseURL = fmt.Sprintf("https://%s/rest", net.JoinHostPort(IP, Port))
+	rest.user = User
+	rest.password = Password
+
+	connectCtx, cancel := context.WithTimeout(ctx, rest.CommandTimeout.OrDefault(DefaultCommandTimeout))
+	defer cancel()
+
+	_, err = rest.request(connectCtx, http.MethodGet, "/system/resource", nil)
+	if certificateErr != nil {
+		return ErrorCertificate{certificateErr}
+	}
+	if err != nil && isCertificateError(err) {
+		return ErrorCertificate{fmt.Errorf("REST API certificate verification error %v", err)}
+	}
+	var restErr ErrorREST
+	if errors.As(err, &restErr) && restErr.StatusCode == http.StatusUnauthorized {
+		return ErrorWrongPassword{err}
+	}
+	if err != nil {
+		return ErrorRetryable{fmt.Errorf("REST API handle sequence error %v", err)}
+	}
+	return nil
+}
+
+// Close REST API client session.
+func (rest *REST) Close() {
+	if rest.httpClient != nil {
+		rest.httpClient.CloseIdleConnections()
+	}
+}
+
+// RunCmd execues given command on remote device, optionally can compare execution result with provided expect regexp.
+// Command body uses API syntax, e.g. `/interface/set =.id=*1 =comment="uplink port"` or `/ip/address/print ?interface=ether1`,
+// and is mapped onto REST call: print to GET, add to PUT, set to PATCH, remove to DELETE and any other command to POST.
+func (rest *REST) RunCmd(body string, expect *regexp.Regexp) (result string, err error) {
+	result, _, err = rest.RunCmdRecords(body, expect)
+	return result, err
+}
+
+// RunCmdRecords execues given command on remote device and returns JSON response's objects as records.
+// Command is limited by client's command timeout.
+func (rest *REST) RunCmdRecords(body string, expect *regexp.Regexp) (result string, records []map[string]string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), rest.CommandTimeout.OrDefault(DefaultCommandTimeout))
+	defer cancel()
+
+	return rest.RunCmdRecordsContext(ctx, body, expect)
+}
+
+// RunCmdRecordsContext execues given command on remote device like RunCmdRecords, request is aborted once context is done.
+func (rest *REST) RunCmdRecordsContext(ctx context.Context, body string, expect *regexp.Regexp) (result string, records []map[string]string, err error) {
+	method, path, payload, err := restRequest(body)
+	if err != nil {
+		return "", nil, err
+	}
+
+	response, err := rest.request(ctx, method, path, payload)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if expect != nil {
+		_, err = waitForExpected(strings.NewReader(response), expect, rest.IdleTimeout.OrDefault(DefaultIdleTimeout))
+	}
+
+	return fmt.Sprintf("%s\n%s", body, response), jsonRecords([]byte(response)), err
+}
+
+func (rest *REST) request(ctx context.Context, method, path string, payload map[string]string) (string, error) {
+	var body *bytes.Reader
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return "", err
+		}
+		body = bytes.NewReader(encoded)
+	} else {
+		body = bytes.NewReader(nil)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, rest.baseURL+path, body)
+	if err != nil {
+		return "", err
+	}
+	request.SetBasicAuth(rest.user, rest.password)
+	if payload != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := rest.httpClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	content, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		restErr := ErrorREST{StatusCode: response.StatusCode}
+		_ = json.Unmarshal(content, &restErr)
+		return "", restErr
+	}
+	return string(content), nil
+}
+
+// restRequest maps command in API syntax onto REST method, path and JSON payload.
+func restRequest(body string) (method, path string, payload map[string]string, err error) {
+	words, err := entities.SplitSentence(body)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if len(words) == 0 || !strings.HasPrefix(words[0], "/") {
+		return "", "", nil, fmt.Errorf("invalid REST command %q", body)
+	}
+
+	menu := strings.TrimRight(words[0], "/")
+	separator := strings.LastIndex(menu, "/")
+	if separator <= 0 {
+		return "", "", nil, fmt.Errorf("invalid REST command %q, missing menu path", body)
+	}
+	path, command := menu[:separator], menu[separator+1:]
+
+	attributes := make(map[string]string)
+	query := url.Values{}
+	for _, word := range words[1:] {
+		if word == "" {
+			return "", "", nil, fmt.Errorf("invalid empty REST command word")
+		}
+		switch word[0] {
+		case '=':
+			kv := strings.SplitN(word[1:], "=", 2)
+			if len(kv) != 2 {
+				return "", "", nil, fmt.Errorf("invalid attribute %q", word)
+			}
+			attributes[kv[0]] = kv[1]
+		case '?':
+			kv := strings.SplitN(word[1:], "=", 2)
+			if len(kv) != 2 {
+				return "", "", nil, fmt.Errorf("invalid query %q", word)
+			}
+			query.Add(kv[0], kv[1])
+		default:
+			return "", "", nil, fmt.Errorf("invalid REST command word %q", word)
+		}
+	}
+
+	id, hasID := attributes[".id"]
+	switch command {
+	case "print":
+		if proplist, ok := attributes[".proplist"]; ok {
+			query.Set(".proplist", proplist)
+		}
+		if hasID {
+			path += "/" + id
+		}
+		if len(query) > 0 {
+			path += "?" + query.Encode()
+		}
+		return http.MethodGet, path, nil, nil
+	case "add":
+		return http.MethodPut, path, attributes, nil
+	case "set", "remove":
+		if !hasID {
+			return "", "", nil, fmt.Errorf("REST command %q requires .id attribute", body)
+		}
+		delete(attributes, ".id")
+		if command == "remove" {
+			return http.MethodDelete, path + "/" + id, nil, nil
+		}
+		return http.MethodPatch, path + "/" + id, attributes, nil
+	}
+	return http.MethodPost, path + "/" + command, attributes, nil
+}
@@ -0,0 +1,155 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+func TestRESTRunCmd(t *testing.T) {
+	var requested struct {
+		Method  string
+		URI     string
+		Payload map[string]string
+	}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, _ := r.BasicAuth(); user != "admin" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":401,"message":"Unauthorized"}`))
+			return
+		}
+		if r.URL.Path == "/rest/system/resource" {
+			w.Write([]byte(`{"version":"7.1"}`))
+			return
+		}
+		if r.URL.Path == "/rest/interface/*9" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":404,"message":"Not Found"}`))
+			return
+		}
+
+		requested.Method, requested.URI, requested.Payload = r.Method, r.URL.RequestURI(), nil
+		body, _ := ioutil.ReadAll(r.Body)
+		if len(body) > 0 {
+			_ = json.Unmarshal(body, &requested.Payload)
+		}
+		w.Write([]byte(`[{".id":"*1","name":"ether1"}]`))
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	host, port, _ := net.SplitHostPort(serverURL.Host)
+
+	if err := NewRESTClient(Config{}).Connect(context.Background(), host, port, "admin", "wrong"); err == nil {
+		t.Fatalf("expected wrong password error")
+	} else if _, ok := err.(ErrorWrongPassword); !ok {
+		t.Fatalf("not expected error %v", err)
+	}
+
+	client := NewRESTClient(Config{})
+	if err := client.Connect(context.Background(), host, port, "admin", "secret"); err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	defer client.Close()
+
+	cases := []struct {
+		Name            string
+		Body            string
+		ExpectedMethod  string
+		ExpectedURI     string
+		ExpectedPayload map[string]string
+		ExpectedError   bool
+	}{
+		{Name: "OK, print", Body: "/interface/print", ExpectedMethod: http.MethodGet, ExpectedURI: "/rest/interface"},
+		{Name: "OK, print with query", Body: "/interface/print ?type=ether =.proplist=name", ExpectedMethod: http.MethodGet, ExpectedURI: "/rest/interface?.proplist=name&type=ether"},
+		{Name: "OK, add", Body: "/ip/address/add =address=10.0.0.1/24 =interface=ether1", ExpectedMethod: http.MethodPut, ExpectedURI: "/rest/ip/address", ExpectedPayload: map[string]string{"address": "10.0.0.1/24", "interface": "ether1"}},
+		{Name: "OK, set", Body: "/interface/set =.id=*1 =disabled=yes", ExpectedMethod: http.MethodPatch, ExpectedURI: "/rest/interface/*1", ExpectedPayload: map[string]string{"disabled": "yes"}},
+		{Name: "OK, set quoted value", Body: `/interface/set =.id=*1 =comment="uplink port"`, ExpectedMethod: http.MethodPatch, ExpectedURI: "/rest/interface/*1", ExpectedPayload: map[string]string{"comment": "uplink port"}},
+		{Name: "OK, remove", Body: "/ip/address/remove =.id=*2", ExpectedMethod: http.MethodDelete, ExpectedURI: "/rest/ip/address/*2"},
+		{Name: "OK, other command", Body: "/system/reboot", ExpectedMethod: http.MethodPost, ExpectedURI: "/rest/system/reboot", ExpectedPayload: map[string]string{}},
+		{Name: "Wrong, set without id", Body: "/interface/set =disabled=yes", ExpectedError: true},
+		{Name: "Wrong, not existing item", Body: "/interface/print =.id=*9", ExpectedError: true},
+		{Name: "Wrong, console syntax", Body: "/interface print", ExpectedError: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			requested.Method, requested.URI, requested.Payload = "", "", nil
+
+			result, err := client.RunCmd(tc.Body, nil)
+			if (err != nil) != tc.ExpectedError {
+				t.Fatalf("not expected error %v", err)
+			}
+			if tc.ExpectedError {
+				return
+			}
+
+			if requested.Method != tc.ExpectedMethod || requested.URI != tc.ExpectedURI {
+				t.Errorf("got:%s %s, expected:%s %s", requested.Method, requested.URI, tc.ExpectedMethod, tc.ExpectedURI)
+			}
+			if len(requested.Payload) != len(tc.ExpectedPayload) {
+				t.Errorf("got:%v, expected:%v", requested.Payload, tc.ExpectedPayload)
+			}
+			for k, v := range tc.ExpectedPayload {
+				if requested.Payload[k] != v {
+					t.Errorf("got:%v, expected:%v", requested.Payload, tc.ExpectedPayload)
+				}
+			}
+			if expected := tc.Body + "\n" + `[{".id":"*1","name":"ether1"}]`; result != expected {
+				t.Errorf("got:%v, expected:%v", result, expected)
+			}
+		})
+	}
+}
+
+func TestRESTCommandTimeout(t *testing.T) {
+	aborted := make(chan struct{}, 1)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/rest/system/resource" {
+			w.Write([]byte(`{"version":"7.1"}`))
+			return
+		}
+
+		// connection closed by client is detected once request's body is read
+		_, _ = ioutil.ReadAll(r.Body)
+		select {
+		case <-r.Context().Done():
+			aborted <- struct{}{}
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	host, port, _ := net.SplitHostPort(serverURL.Host)
+
+	client := NewRESTClient(Config{})
+	if err := client.Connect(context.Background(), host, port, "admin", "secret"); err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	defer client.Close()
+
+	started := time.Now()
+	_, _, err := ExecuteCommands(context.Background(), client, []entities.Command{{Body: "/system/script/run =.id=*1", TimeoutMs: 50}})
+	if err == nil {
+		t.Fatalf("expected timeout error")
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("got:%v, expected command timeouted after 50ms", elapsed)
+	}
+
+	select {
+	case <-aborted:
+	case <-time.After(time.Second):
+		t.Errorf("request not aborted after command timeout")
+	}
+}
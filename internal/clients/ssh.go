@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -62,15 +63,29 @@ func (ssh *SSH) Connect(ctx context.Context, IP, Port, User, Password string) (e
 	sshConfig.SetDefaults()
 	sshConfig.Ciphers = append(sshConfig.Ciphers, "aes128-cbc", "aes128-ctr", "aes192-ctr", "aes256-ctr", "aes192-cbc", "aes256-cbc", "3des-cbc", "des-cbc", "diffie-hellman-group-exchange-sha256")
 	sshConfig.KeyExchanges = append(sshConfig.KeyExchanges, "diffie-hellman-group-exchange-sha256", "diffie-hellman-group-exchange-sha1", "diffie-hellman-group1-sha1", "diffie-hellman-group14-sha1 diffie-hellman-group1-sha1")
+
+	// keep host key verification error to report it as not retryable
+	var hostKeyErr error
+	hostKeyCallback := HostKeyCallback(ssh.Config.HostKeyPolicy, ssh.Config.KnownHosts)
+
 	clientConfig := &cryptossh.ClientConfig{
-		Config:          sshConfig,
-		Timeout:         30 * time.Second,
-		User:            User + "+ct", // Mikrotik hack to avoid detecting terminal capabilities and disable colors
-		Auth:            sshAuthMethods,
-		HostKeyCallback: cryptossh.InsecureIgnoreHostKey(),
+		Config:  sshConfig,
+		Timeout: 30 * time.Second,
+		User:    User + "+ct", // Mikrotik hack to avoid detecting terminal capabilities and disable colors
+		Auth:    sshAuthMethods,
+		HostKeyCallback: func(hostname string, remote net.Addr, key cryptossh.PublicKey) error {
+			hostKeyErr = hostKeyCallback(hostname, remote, key)
+			return hostKeyErr
+		},
 	}
 
 	ssh.client, err = cryptossh.Dial("tcp", fmt.Sprintf("%s:%s", IP, Port), clientConfig)
+	if hostKeyErr != nil {
+		if _, ok := hostKeyErr.(ErrorHostKey); ok {
+			return hostKeyErr
+		}
+		return ErrorHostKey{hostKeyErr}
+	}
 	if err != nil && err.Error() == "ssh: handshake failed: ssh: unable to authenticate, attempted methods [none password], no supported methods remain" {
 		return ErrorWrongPassword{err}
 	}
@@ -80,8 +95,11 @@ func (ssh *SSH) Connect(ctx context.Context, IP, Port, User, Password string) (e
 	return nil
 }
 
-// CopyFile copies file over SFTP.
+// CopyFile copies file over SFTP, transfer is limited by configured transfer timeout.
 func (ssh *SSH) CopyFile(ctx context.Context, source, target string) (result entities.CommandResult, err error) {
+	ctx, cancel := context.WithTimeout(ctx, ssh.TransferTimeout.OrDefault(DefaultTransferTimeout))
+	defer cancel()
+
 	errorChan := make(chan error)
 	doneChan := make(chan struct{})
 
@@ -124,8 +142,9 @@ func (ssh *SSH) CopyFile(ctx context.Context, source, target string) (result ent
 	select {
 	case <-ctx.Done():
 		result.Error = fmt.Errorf("context cancelled")
-	case <-time.After(30 * time.Second):
-		result.Error = fmt.Errorf("copy file timeouted")
+		if ctx.Err() == context.DeadlineExceeded {
+			result.Error = fmt.Errorf("copy file timeouted")
+		}
 	case err := <-errorChan:
 		result.Error = err
 	case <-doneChan:
@@ -134,25 +153,31 @@ func (ssh *SSH) CopyFile(ctx context.Context, source, target string) (result ent
 	return result, result.Error
 }
 
-// Close SSH client session.
+// Close SSH client session, client may be connected again afterwards.
 func (ssh *SSH) Close() {
-	if ssh.session == nil {
+	if ssh.client == nil {
 		return
 	}
-	defer ssh.session.Close()
+	defer func() {
+		ssh.client.Close()
+		ssh.client = nil
+	}()
 
-	if ssh.client == nil {
+	if ssh.session == nil {
 		return
 	}
-	defer ssh.client.Close()
+	defer func() {
+		ssh.session.Close()
+		ssh.session = nil
+	}()
 
 	ssh.stdinBuf.Write([]byte("/quit\r"))
 
-	wait := make(chan struct{})
-	go func(wait chan struct{}) {
-		ssh.session.Wait()
+	wait := make(chan struct{}, 1)
+	go func(session *cryptossh.Session, wait chan struct{}) {
+		session.Wait()
 		wait <- struct{}{}
-	}(wait)
+	}(ssh.session, wait)
 
 	select {
 	case <-time.After(1 * time.Second):
@@ -169,9 +194,9 @@ func (ssh *SSH) RunCmd(body string, expect *regexp.Regexp) (result string, err e
 	ssh.stdinBuf.Write([]byte(body + "\r"))
 
 	if expect != nil {
-		result, err = waitForExpected(ssh.stdoutBuf, expect)
+		result, err = waitForExpected(ssh.stdoutBuf, expect, ssh.IdleTimeout.OrDefault(DefaultIdleTimeout))
 	} else {
-		result, err = waitForExpected(ssh.stdoutBuf, ssh.prompt)
+		result, err = waitForExpected(ssh.stdoutBuf, ssh.prompt, ssh.IdleTimeout.OrDefault(DefaultIdleTimeout))
 	}
 	result = ssh.prompt.ReplaceAllString(result, "")
 	result = ssh.utf8ArtefactRemover.ReplaceAllString(result, "")
@@ -211,7 +236,7 @@ func (ssh *SSH) initializeSession() (err error) {
 		return fmt.Errorf("failed to start shell: %s", err)
 	}
 
-	_, err = waitForExpected(ssh.stdoutBuf, ssh.prompt)
+	_, err = waitForExpected(ssh.stdoutBuf, ssh.prompt, ssh.IdleTimeout.OrDefault(DefaultIdleTimeout))
 	return
 }
 
@@ -0,0 +1,69 @@
+package clients
+
+import (
+	"context"
+	"strings"
+	"text/template"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+// TemplateData is data commands' templates are rendered against, e.g. `{{ .Vars.site }}`, `{{ .Data.vlan }}`, `{{ .Host.IP }}` or `{{ .Matches.m1 }}`.
+type TemplateData struct {
+	Host TemplateHost
+	Vars map[string]string
+	Data map[string]string
+	// Matches are values matched by already executed commands, keyed by match prefix and number, e.g. m1.
+	Matches map[string]string
+}
+
+// TemplateHost is host's connection details available to templates, password is not exposed.
+type TemplateHost struct {
+	IP   string
+	Port string
+	User string
+}
+
+// NewTemplateData returns template data of host's variables and job's data.
+func NewTemplateData(job *entities.Job) *TemplateData {
+	return &TemplateData{
+		Host: TemplateHost{IP: job.Host.IP, Port: job.Host.Port, User: job.Host.User},
+		Vars: job.Host.Vars,
+		Data: job.Data,
+	}
+}
+
+// ExecuteCommandTemplates executes provided list of commands like ExecuteCommands, bodies, attributes and queries of commands with Template flag are rendered as Go templates against given data.
+func ExecuteCommandTemplates(ctx context.Context, d Client, commands []entities.Command, data *TemplateData) ([]entities.CommandResult, map[string]string, error) {
+	return executeCommands(ctx, d, commands, data)
+}
+
+// render renders text as template, unknown variables are reported as error.
+func (data TemplateData) render(text string, matches map[string]string) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New("command").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	data.Matches = make(map[string]string, len(matches))
+	for match, value := range matches {
+		// matches are stored as expressions replacing %{name} in commands
+		data.Matches[strings.TrimSuffix(strings.TrimPrefix(match, "(%{"), "})")] = value
+	}
+	if data.Vars == nil {
+		data.Vars = map[string]string{}
+	}
+	if data.Data == nil {
+		data.Data = map[string]string{}
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
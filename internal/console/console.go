@@ -0,0 +1,66 @@
+// Package console parses RouterOS console (SSH) output into structured records.
+package console
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// FormatPrint is output of `print` command, table with header row or list of settings in form `key: value`.
+	FormatPrint = "print"
+	// FormatDetail is output of `print detail` command.
+	FormatDetail = "detail"
+	// FormatTerse is output of `print terse` command.
+	FormatTerse = "terse"
+	// FormatExport is output of `export` command.
+	FormatExport = "export"
+)
+
+// Keys of records holding values not being item's properties.
+const (
+	// KeyNumber is item's number printed by console.
+	KeyNumber = ".nr"
+	// KeyFlags is item's flags, e.g. X (disabled) or D (dynamic).
+	KeyFlags = ".flags"
+	// KeyComment is item's comment printed after `;;;`.
+	KeyComment = "comment"
+	// KeyPath is menu path of exported command, e.g. `/interface ethernet`.
+	KeyPath = ".path"
+	// KeyCommand is exported command, e.g. `add` or `set`.
+	KeyCommand = ".command"
+	// KeyFind is find expression of exported command, e.g. `default-name=ether1` of `set [ find default-name=ether1 ]`.
+	KeyFind = ".find"
+	// KeyArgs is list of unnamed arguments of exported command, e.g. `0` of `set 0 name=ether1`.
+	KeyArgs = ".args"
+)
+
+var itemRx = regexp.MustCompile(`^\s*(\d+)(\s.*)?$`)
+
+// Parse parses console output of given format into records.
+func Parse(format, output string) ([]map[string]string, error) {
+	lines := strings.Split(strings.ReplaceAll(output, "\r", ""), "\n")
+
+	switch format {
+	case FormatPrint:
+		if records := parseTable(lines); records != nil {
+			return records, nil
+		}
+		return parseSettings(lines), nil
+	case FormatDetail, FormatTerse:
+		return parseDetail(lines)
+	case FormatExport:
+		return parseExport(lines)
+	}
+	return nil, fmt.Errorf("unknown console output format %q", format)
+}
+
+// splitComment splits line into part before `;;;` and comment following it.
+func splitComment(line string) (string, string, bool) {
+	idx := strings.Index(line, ";;;")
+	if idx < 0 {
+		return line, "", false
+	}
+	return line[:idx], strings.TrimSpace(line[idx+3:]), true
+}
@@ -0,0 +1,168 @@
+package console
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		Name          string
+		Format        string
+		Output        string
+		Expected      []map[string]string
+		ExpectedError bool
+	}{
+		{
+			Name:   "OK, print table",
+			Format: FormatPrint,
+			Output: "/interface print\r\n" +
+				"Flags: D - dynamic, X - disabled, R - running, S - slave \r\n" +
+				" #     NAME                                TYPE       ACTUAL-MTU L2MTU\r\n" +
+				" 0  R  ether1                              ether            1500  1598\r\n" +
+				" 1  X  ;;; spare port\r\n" +
+				"       ether 2                             ether            1500  1598\r\n" +
+				" 2  R  bridge                              bridge           1500  1598\r\n",
+			Expected: []map[string]string{
+				{".nr": "0", ".flags": "R", "name": "ether1", "type": "ether", "actual-mtu": "1500", "l2mtu": "1598"},
+				{".nr": "1", ".flags": "X", "comment": "spare port", "name": "ether 2", "type": "ether", "actual-mtu": "1500", "l2mtu": "1598"},
+				{".nr": "2", ".flags": "R", "name": "bridge", "type": "bridge", "actual-mtu": "1500", "l2mtu": "1598"},
+			},
+		},
+		{
+			Name:   "OK, print v7 table",
+			Format: FormatPrint,
+			Output: "Flags: X - DISABLED\n" +
+				"Columns: NAME, PORT, CERTIFICATE\n" +
+				"#   NAME     PORT  CERTIFICATE\n" +
+				"0   telnet     23\n" +
+				"1 X api-ssl  8729  mtbulkdevice.crt\n",
+			Expected: []map[string]string{
+				{".nr": "0", "name": "telnet", "port": "23"},
+				{".nr": "1", ".flags": "X", "name": "api-ssl", "port": "8729", "certificate": "mtbulkdevice.crt"},
+			},
+		},
+		{
+			Name:   "OK, print settings",
+			Format: FormatPrint,
+			Output: "/ip dns print\n" +
+				"                      servers: 8.8.8.8,\n" +
+				"                               1.1.1.1\n" +
+				"              dynamic-servers: \n" +
+				"        allow-remote-requests: yes\n",
+			Expected: []map[string]string{
+				{"servers": "8.8.8.8,1.1.1.1", "dynamic-servers": "", "allow-remote-requests": "yes"},
+			},
+		},
+		{
+			Name:     "OK, print empty",
+			Format:   FormatPrint,
+			Output:   "Flags: X - disabled\n",
+			Expected: []map[string]string{},
+		},
+		{
+			Name:   "OK, print detail",
+			Format: FormatDetail,
+			Output: "Flags: X - disabled, R - running \n" +
+				" 0  R  name=\"ether1\" default-name=\"ether1\" type=\"ether\" mtu=1500 \n" +
+				"       mac-address=CC:2D:E0:00:00:01 \n" +
+				"\n" +
+				" 1 X   ;;; spare port\n" +
+				"       name=\"ether 2\" comment=\"say \\\"hi\\\"\" \n",
+			Expected: []map[string]string{
+				{".nr": "0", ".flags": "R", "name": "ether1", "default-name": "ether1", "type": "ether", "mtu": "1500", "mac-address": "CC:2D:E0:00:00:01"},
+				{".nr": "1", ".flags": "X", "comment": `say "hi"`, "name": "ether 2"},
+			},
+		},
+		{
+			Name:   "OK, print terse",
+			Format: FormatTerse,
+			Output: " 0 R name=ether1 type=ether mtu=1500\n" +
+				" 1 XS name=ether2 type=ether mtu=1500\n",
+			Expected: []map[string]string{
+				{".nr": "0", ".flags": "R", "name": "ether1", "type": "ether", "mtu": "1500"},
+				{".nr": "1", ".flags": "XS", "name": "ether2", "type": "ether", "mtu": "1500"},
+			},
+		},
+		{
+			Name:   "OK, detail of settings",
+			Format: FormatDetail,
+			Output: "  enabled: yes\n  port: 2000\n",
+			Expected: []map[string]string{
+				{"enabled": "yes", "port": "2000"},
+			},
+		},
+		{
+			Name:   "OK, export",
+			Format: FormatExport,
+			Output: "# jan/02/1970 00:00:00 by RouterOS 6.48\n" +
+				"# software id = ABCD-1234\n" +
+				"#\n" +
+				"/interface bridge\n" +
+				"add name=bridge1\n" +
+				"/interface ethernet\n" +
+				"set [ find default-name=ether1 ] comment=\"uplink port\" \\\n" +
+				"    speed=100Mbps\n" +
+				"/ip firewall filter\n" +
+				"move 3 destination=0\n" +
+				"/system identity set name=router\n" +
+				"/system note\n" +
+				"set note=\"line\\r\\nZa\\C5\\BC\\C3\\B3\\C5\\82\\C4\\87\"\n",
+			Expected: []map[string]string{
+				{".path": "/interface bridge", ".command": "add", "name": "bridge1"},
+				{".path": "/interface ethernet", ".command": "set", ".find": "default-name=ether1", "comment": "uplink port", "speed": "100Mbps"},
+				{".path": "/ip firewall filter", ".command": "move", ".args": "3", "destination": "0"},
+				{".path": "/system identity", ".command": "set", "name": "router"},
+				{".path": "/system note", ".command": "set", "note": "line\r\nZażółć"},
+			},
+		},
+		{
+			Name:          "Wrong, unterminated quoted value",
+			Format:        FormatDetail,
+			Output:        " 0 name=\"ether1\n",
+			ExpectedError: true,
+		},
+		{
+			Name:          "Wrong, unknown format",
+			Format:        "yaml",
+			ExpectedError: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			records, err := Parse(tc.Format, tc.Output)
+			if (err != nil) != tc.ExpectedError {
+				t.Fatalf("not expected error %v", err)
+			}
+			if !reflect.DeepEqual(records, tc.Expected) {
+				t.Errorf("got:%q, expected:%q", records, tc.Expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeExport(t *testing.T) {
+	output := "# mar/15/2020 12:00:00 by RouterOS 6.48.1\r\n" +
+		"# software id = ABCD-1234\r\n" +
+		"#\r\n" +
+		"/interface ethernet\r\n" +
+		"set [ find default-name=ether1 ] comment=\\\r\n" +
+		"    uplink   \r\n" +
+		"\r\n" +
+		"/system clock\r\n" +
+		"set time-zone-name=Europe/Warsaw\r\n"
+	expected := []string{
+		"# software id = ABCD-1234",
+		"#",
+		"/interface ethernet",
+		"set [ find default-name=ether1 ] comment=uplink",
+		"/system clock",
+	}
+
+	normalized := NormalizeExport(output, regexp.MustCompile("^set time-zone-name="))
+	if !reflect.DeepEqual(normalized, expected) {
+		t.Errorf("got:%q, expected:%q", normalized, expected)
+	}
+}
@@ -0,0 +1,59 @@
+package console
+
+import (
+	"strings"
+)
+
+// parseDetail parses items printed by `print detail` or `print terse` command.
+// Each item starts with its number followed by optional flags and comment, properties are printed as `key=value`.
+// Output without numbered items (e.g. settings) is parsed as list of `key: value` settings.
+func parseDetail(lines []string) ([]map[string]string, error) {
+	type item struct {
+		lines []string
+	}
+
+	var items []*item
+	for _, line := range lines {
+		if itemRx.MatchString(line) {
+			items = append(items, &item{})
+		}
+		if len(items) == 0 || strings.TrimSpace(line) == "" {
+			continue
+		}
+		items[len(items)-1].lines = append(items[len(items)-1].lines, line)
+	}
+	if len(items) == 0 {
+		return parseSettings(lines), nil
+	}
+
+	records := make([]map[string]string, 0, len(items))
+	for _, item := range items {
+		first, comment, commented := splitComment(item.lines[0])
+
+		words, err := splitWords(strings.Join(append([]string{first}, item.lines[1:]...), " "))
+		if err != nil {
+			return nil, err
+		}
+
+		record := map[string]string{KeyNumber: words[0]}
+		if commented {
+			record[KeyComment] = comment
+		}
+
+		properties := false
+		for _, word := range words[1:] {
+			kv := strings.SplitN(word, "=", 2)
+			switch {
+			case len(kv) == 2:
+				properties = true
+				record[kv[0]] = kv[1]
+			case !properties:
+				appendValue(record, KeyFlags, word, "")
+			default:
+				appendValue(record, KeyArgs, word, " ")
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
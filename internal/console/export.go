@@ -0,0 +1,123 @@
+package console
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// exportVolatileRx matches lines of export changing on each run, e.g. `# mar/15/2020 12:00:00 by RouterOS 6.48.1`
+// or `# 2024-03-15 12:00:00 by RouterOS 7.14`.
+var exportVolatileRx = regexp.MustCompile(`^#\s*(\w{3}/\d{2}/\d{4}|\d{4}-\d{2}-\d{2})\s+\d{2}:\d{2}:\d{2}\s+by\s+RouterOS`)
+
+// NormalizeExport normalizes configuration printed by `export` command to compare exports made at different times.
+// Commands wrapped into many lines are joined, volatile lines and lines matching any of ignore expressions are removed.
+func NormalizeExport(output string, ignore ...*regexp.Regexp) []string {
+	lines := strings.Split(strings.ReplaceAll(output, "\r", ""), "\n")
+	normalized := make([]string, 0, len(lines))
+
+	var command strings.Builder
+lines:
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if strings.HasSuffix(line, "\\") && !strings.HasSuffix(line, "\\\\") {
+			command.WriteString(strings.TrimSuffix(strings.TrimLeft(line, " "), "\\"))
+			continue
+		}
+		command.WriteString(strings.TrimLeft(line, " "))
+		line = strings.TrimSpace(command.String())
+		command.Reset()
+
+		if line == "" || exportVolatileRx.MatchString(line) {
+			continue
+		}
+		for _, rx := range ignore {
+			if rx.MatchString(line) {
+				continue lines
+			}
+		}
+		normalized = append(normalized, line)
+	}
+	return normalized
+}
+
+// parseExport parses configuration printed by `export` command.
+// Each command is a record with its menu path, command name, find expression, unnamed arguments and properties.
+func parseExport(lines []string) ([]map[string]string, error) {
+	records := make([]map[string]string, 0, len(lines))
+
+	var path string
+	var command strings.Builder
+	for _, line := range lines {
+		// commands are wrapped into many lines ending with backslash
+		if strings.HasSuffix(line, "\\") && !strings.HasSuffix(line, "\\\\") {
+			command.WriteString(strings.TrimSuffix(strings.TrimLeft(line, " "), "\\"))
+			continue
+		}
+		command.WriteString(strings.TrimLeft(line, " "))
+		line = strings.TrimSpace(command.String())
+		command.Reset()
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		words, err := splitWords(line)
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.HasPrefix(words[0], "/") {
+			verb := len(words)
+			for idx, word := range words {
+				if exportCommands[word] {
+					verb = idx
+					break
+				}
+			}
+			path = strings.Join(words[:verb], " ")
+			if words = words[verb:]; len(words) == 0 {
+				continue
+			}
+		}
+		if path == "" {
+			continue
+		}
+
+		record := map[string]string{KeyPath: path, KeyCommand: words[0]}
+		find := -1
+		for _, word := range words[1:] {
+			switch {
+			case word == "[" && find < 0:
+				find = 0
+			case word == "]" && find >= 0:
+				find = -2
+			case find >= 0:
+				if find > 0 || word != "find" {
+					appendValue(record, KeyFind, word, " ")
+				}
+				find++
+			case strings.Contains(word, "="):
+				kv := strings.SplitN(word, "=", 2)
+				record[kv[0]] = kv[1]
+			default:
+				appendValue(record, KeyArgs, word, " ")
+			}
+		}
+		if find >= 0 {
+			return nil, fmt.Errorf("unterminated find expression in %q", line)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+var exportCommands = map[string]bool{
+	"add":     true,
+	"set":     true,
+	"remove":  true,
+	"unset":   true,
+	"enable":  true,
+	"disable": true,
+	"move":    true,
+}
@@ -0,0 +1,150 @@
+package console
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	headerRx  = regexp.MustCompile(`^\s*#(\s+[A-Z][A-Z0-9\-/._()]*)+\s*$`)
+	settingRx = regexp.MustCompile(`^\s*([a-z0-9][a-z0-9\-.]*):(?:\s+(.*))?$`)
+)
+
+type column struct {
+	name  string
+	start int
+	end   int
+}
+
+type token struct {
+	value string
+	start int
+	end   int
+}
+
+// parseTable parses table printed by `print` command, returns nil if output has no table header.
+// Columns are recognized by positions of header's names, each value is assigned to column it overlaps the most.
+func parseTable(lines []string) []map[string]string {
+	header := -1
+	for idx, line := range lines {
+		if headerRx.MatchString(line) {
+			header = idx
+			break
+		}
+	}
+	if header < 0 {
+		return nil
+	}
+
+	var columns []column
+	for _, t := range tokens(lines[header])[1:] {
+		if len(columns) > 0 {
+			columns[len(columns)-1].end = t.start
+		}
+		columns = append(columns, column{name: strings.ToLower(t.value), start: t.start, end: -1})
+	}
+
+	records := make([]map[string]string, 0, len(lines)-header)
+	var record map[string]string
+	for _, line := range lines[header+1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		line, comment, commented := splitComment(line)
+		lineTokens := tokens(line)
+
+		// item's number is printed before first column, long values of first column may be numeric too
+		numbered := itemRx.MatchString(line) && lineTokens[0].end <= columns[0].start
+		if !numbered && record == nil {
+			continue
+		}
+		if numbered {
+			record = map[string]string{KeyNumber: lineTokens[0].value}
+			records = append(records, record)
+			lineTokens = lineTokens[1:]
+		}
+		if commented {
+			record[KeyComment] = comment
+		}
+
+		for _, t := range lineTokens {
+			key := KeyFlags
+			if t.end > columns[0].start {
+				key = columns[columnOf(columns, t)].name
+			}
+			appendValue(record, key, t.value, " ")
+		}
+	}
+	return records
+}
+
+// parseSettings parses settings printed in form `key: value` into single record.
+func parseSettings(lines []string) []map[string]string {
+	record := make(map[string]string)
+
+	var key string
+	for _, line := range lines {
+		if m := settingRx.FindStringSubmatch(line); m != nil {
+			key = m[1]
+			record[key] = strings.TrimSpace(m[2])
+			continue
+		}
+		// long values are wrapped to next lines
+		if value := strings.TrimSpace(line); key != "" && value != "" {
+			separator := " "
+			if strings.HasSuffix(record[key], ",") {
+				separator = ""
+			}
+			appendValue(record, key, value, separator)
+		}
+	}
+
+	if len(record) == 0 {
+		return []map[string]string{}
+	}
+	return []map[string]string{record}
+}
+
+func columnOf(columns []column, t token) (best int) {
+	bestOverlap := 0
+	for idx, c := range columns {
+		end := t.end
+		if c.end >= 0 && c.end < end {
+			end = c.end
+		}
+		start := c.start
+		if start < t.start {
+			start = t.start
+		}
+		if overlap := end - start; idx == 0 || overlap > bestOverlap {
+			best, bestOverlap = idx, overlap
+		}
+	}
+	return best
+}
+
+func tokens(line string) (list []token) {
+	start := -1
+	for idx, r := range line + " " {
+		if r == ' ' || r == '\t' {
+			if start >= 0 {
+				list = append(list, token{value: line[start:idx], start: start, end: idx})
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = idx
+		}
+	}
+	return list
+}
+
+func appendValue(record map[string]string, key, value, separator string) {
+	if current, ok := record[key]; ok && current != "" {
+		record[key] = current + separator + value
+		return
+	}
+	record[key] = value
+}
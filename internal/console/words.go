@@ -0,0 +1,72 @@
+package console
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var escapes = map[byte]string{
+	'n': "\n",
+	'r': "\r",
+	't': "\t",
+	'a': "\a",
+	'b': "\b",
+	'f': "\f",
+	'v': "\v",
+	'_': " ",
+}
+
+// splitWords splits console line into words, double quoted values may contain white spaces and RouterOS escape sequences.
+func splitWords(line string) (words []string, err error) {
+	var word strings.Builder
+	var inWord, quoted bool
+
+	for idx := 0; idx < len(line); idx++ {
+		c := line[idx]
+		switch {
+		case c == '\\':
+			if idx+1 >= len(line) {
+				return nil, errors.New("unfinished escape sequence")
+			}
+			inWord = true
+			idx++
+			if escaped, ok := escapes[line[idx]]; ok {
+				word.WriteString(escaped)
+				continue
+			}
+			// non ASCII characters are escaped as hex codes, e.g. \C3\B3
+			if idx+1 < len(line) {
+				if b, err := strconv.ParseUint(line[idx:idx+2], 16, 8); err == nil && isHexUpper(line[idx:idx+2]) {
+					word.WriteByte(byte(b))
+					idx++
+					continue
+				}
+			}
+			word.WriteByte(line[idx])
+		case c == '"':
+			inWord, quoted = true, !quoted
+		case (c == ' ' || c == '\t') && !quoted:
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			inWord = true
+			word.WriteByte(c)
+		}
+	}
+
+	if quoted {
+		return nil, errors.New("unterminated quoted value")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+func isHexUpper(s string) bool {
+	return strings.Trim(s, "0123456789ABCDEF") == ""
+}
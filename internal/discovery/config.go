@@ -0,0 +1,55 @@
+// Package discovery crawls network by neighbors of devices and exports discovered hosts and topology.
+package discovery
+
+import (
+	"fmt"
+	"net"
+)
+
+// DefaultDepth is default number of hops from seed hosts crawler visits.
+const DefaultDepth = 2
+
+// Config of network discovery.
+type Config struct {
+	// Depth limits number of hops from seed hosts, DefaultDepth if not set, 0 visits seed hosts only.
+	Depth *int `toml:"depth" yaml:"depth"`
+	// Scope limits visited devices to given subnets, e.g. 10.0.0.0/16, seed hosts are always visited.
+	Scope        []string `toml:"scope" yaml:"scope"`
+	HostsFile    string   `toml:"hosts_file" yaml:"hosts_file"`
+	TopologyFile string   `toml:"topology_file" yaml:"topology_file"`
+}
+
+// Validate verifies configuration of discovery.
+func (c Config) Validate() error {
+	if c.Depth != nil && *c.Depth < 0 {
+		return fmt.Errorf("invalid discovery depth %d", *c.Depth)
+	}
+	if _, err := c.subnets(); err != nil {
+		return err
+	}
+	if c.TopologyFile != "" {
+		if _, err := TopologyFormat(c.TopologyFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c Config) depth() int {
+	if c.Depth == nil {
+		return DefaultDepth
+	}
+	return *c.Depth
+}
+
+func (c Config) subnets() ([]*net.IPNet, error) {
+	subnets := make([]*net.IPNet, 0, len(c.Scope))
+	for _, scope := range c.Scope {
+		_, subnet, err := net.ParseCIDR(scope)
+		if err != nil {
+			return nil, fmt.Errorf("invalid discovery scope %s: %v", scope, err)
+		}
+		subnets = append(subnets, subnet)
+	}
+	return subnets, nil
+}
@@ -0,0 +1,122 @@
+package discovery
+
+import (
+	"net"
+	"sync"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+// Crawler tracks hosts visited by discovery and decides which neighbors to visit next.
+// Crawler is safe for concurrent use.
+type Crawler struct {
+	sync.Mutex
+
+	depth    int
+	subnets  []*net.IPNet
+	depths   map[string]int
+	hosts    []entities.Host
+	topology *Topology
+}
+
+// NewCrawler returns new crawler.
+func NewCrawler(config Config) (*Crawler, error) {
+	subnets, err := config.subnets()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Crawler{
+		depth:    config.depth(),
+		subnets:  subnets,
+		depths:   make(map[string]int),
+		topology: NewTopology(),
+	}, nil
+}
+
+// Seed adds host discovery starts from, seed hosts are visited regardless of scope.
+func (c *Crawler) Seed(host entities.Host) {
+	c.Lock()
+	defer c.Unlock()
+
+	if host.IP == "" {
+		return
+	}
+	if _, seen := c.depths[host.IP]; seen {
+		return
+	}
+	c.depths[host.IP] = 0
+	c.hosts = append(c.hosts, host)
+}
+
+// Add adds result of Discover job to topology, returns neighbors not visited yet within depth and scope of discovery.
+func (c *Crawler) Add(result entities.Result) (next []entities.Host) {
+	c.Lock()
+	defer c.Unlock()
+
+	depth, seen := c.depths[result.Job.Host.IP]
+	if !seen {
+		return nil
+	}
+	c.topology.AddHost(result.Job.Host.IP, result.Discovery)
+	if result.Discovery == nil || depth >= c.depth {
+		return nil
+	}
+
+	for _, neighbor := range result.Discovery.Neighbors {
+		IP := net.ParseIP(neighbor.Address).To4()
+		if IP == nil || !neighbor.RouterOS() || !c.inScope(IP) {
+			continue
+		}
+		if _, seen := c.depths[IP.String()]; seen {
+			continue
+		}
+
+		host := entities.Host{IP: IP.String()}
+		c.depths[host.IP] = depth + 1
+		c.hosts = append(c.hosts, host)
+		next = append(next, host)
+	}
+	return next
+}
+
+// Skip removes host from list of visited ones (e.g. host excluded by hosts configuration), skipped host is not returned again by Add.
+func (c *Crawler) Skip(host entities.Host) {
+	c.Lock()
+	defer c.Unlock()
+
+	for idx := range c.hosts {
+		if c.hosts[idx].IP == host.IP {
+			c.hosts = append(c.hosts[:idx], c.hosts[idx+1:]...)
+			return
+		}
+	}
+}
+
+// Hosts returns list of all visited hosts.
+func (c *Crawler) Hosts() []entities.Host {
+	c.Lock()
+	defer c.Unlock()
+
+	return append([]entities.Host(nil), c.hosts...)
+}
+
+// Topology returns topology of discovered network.
+func (c *Crawler) Topology() *Topology {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.topology
+}
+
+func (c *Crawler) inScope(IP net.IP) bool {
+	if len(c.subnets) == 0 {
+		return true
+	}
+	for _, subnet := range c.subnets {
+		if subnet.Contains(IP) {
+			return true
+		}
+	}
+	return false
+}
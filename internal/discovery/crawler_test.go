@@ -0,0 +1,138 @@
+package discovery
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+func discoverResult(IP string, neighbors ...string) entities.Result {
+	discovery := &entities.Discovery{Identity: "router-" + IP}
+	for _, neighbor := range neighbors {
+		discovery.Neighbors = append(discovery.Neighbors, entities.Neighbor{Address: neighbor, Platform: "MikroTik", Protocol: "mndp"})
+	}
+	return entities.Result{Job: entities.Job{Host: entities.Host{IP: IP}}, Discovery: discovery}
+}
+
+func depth(hops int) *int {
+	return &hops
+}
+
+func TestCrawler(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Config   Config
+		Results  []entities.Result
+		Expected [][]entities.Host
+	}{
+		{
+			Name:   "Within depth",
+			Config: Config{Depth: depth(2)},
+			Results: []entities.Result{
+				discoverResult("10.0.0.1", "10.0.0.2", "10.0.0.3"),
+				discoverResult("10.0.0.2", "10.0.0.1", "10.0.0.4"),
+				discoverResult("10.0.0.4", "10.0.0.5"),
+			},
+			Expected: [][]entities.Host{
+				{{IP: "10.0.0.2"}, {IP: "10.0.0.3"}},
+				{{IP: "10.0.0.4"}},
+				nil,
+			},
+		},
+		{
+			Name:   "Seed hosts only",
+			Config: Config{Depth: depth(0)},
+			Results: []entities.Result{
+				discoverResult("10.0.0.1", "10.0.0.2"),
+			},
+			Expected: [][]entities.Host{
+				nil,
+			},
+		},
+		{
+			Name:   "Within scope",
+			Config: Config{Scope: []string{"10.0.0.0/24"}},
+			Results: []entities.Result{
+				discoverResult("10.0.0.1", "10.0.0.2", "10.0.1.1"),
+			},
+			Expected: [][]entities.Host{
+				{{IP: "10.0.0.2"}},
+			},
+		},
+		{
+			Name:   "Skip non RouterOS, unknown and failed hosts",
+			Config: Config{},
+			Results: []entities.Result{
+				{
+					Job: entities.Job{Host: entities.Host{IP: "10.0.0.1"}},
+					Discovery: &entities.Discovery{Neighbors: []entities.Neighbor{
+						{Address: "10.0.0.2", Platform: "cisco WS-C2960", Protocol: "cdp"},
+						{MACAddress: "00:1B:54:00:00:06", Protocol: "lldp"},
+						{Address: "10.0.0.3", Protocol: "ospf"},
+					}},
+				},
+				discoverResult("10.0.9.9", "10.0.0.4"),
+				{Job: entities.Job{Host: entities.Host{IP: "10.0.0.3"}}},
+			},
+			Expected: [][]entities.Host{
+				{{IP: "10.0.0.3"}},
+				nil,
+				nil,
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			crawler, err := NewCrawler(tc.Config)
+			if err != nil {
+				t.Fatalf("not expected error %v", err)
+			}
+			crawler.Seed(entities.Host{IP: "10.0.0.1", Port: "2222"})
+
+			for idx, result := range tc.Results {
+				if next := crawler.Add(result); !reflect.DeepEqual(next, tc.Expected[idx]) {
+					t.Errorf("result %d got:%v, expected:%v", idx, next, tc.Expected[idx])
+				}
+			}
+		})
+	}
+}
+
+func TestCrawlerSkip(t *testing.T) {
+	crawler, err := NewCrawler(Config{})
+	if err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	crawler.Seed(entities.Host{IP: "10.0.0.1"})
+	crawler.Add(discoverResult("10.0.0.1", "10.0.0.2", "10.0.0.3"))
+	crawler.Skip(entities.Host{IP: "10.0.0.2"})
+
+	if next := crawler.Add(discoverResult("10.0.0.3", "10.0.0.2")); next != nil {
+		t.Errorf("got:%v, expected:%v", next, nil)
+	}
+	expected := []entities.Host{{IP: "10.0.0.1"}, {IP: "10.0.0.3"}}
+	if hosts := crawler.Hosts(); !reflect.DeepEqual(hosts, expected) {
+		t.Errorf("got:%v, expected:%v", hosts, expected)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	cases := []struct {
+		Name          string
+		Config        Config
+		ExpectedError bool
+	}{
+		{Name: "OK", Config: Config{Depth: depth(3), Scope: []string{"10.0.0.0/16"}, TopologyFile: "topology.dot"}},
+		{Name: "Wrong, negative depth", Config: Config{Depth: depth(-1)}, ExpectedError: true},
+		{Name: "Wrong, invalid scope", Config: Config{Scope: []string{"10.0.0.0"}}, ExpectedError: true},
+		{Name: "Wrong, unknown topology format", Config: Config{TopologyFile: "topology.png"}, ExpectedError: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if err := tc.Config.Validate(); (err != nil) != tc.ExpectedError {
+				t.Errorf("got:%v, expected error:%v", err, tc.ExpectedError)
+			}
+		})
+	}
+}
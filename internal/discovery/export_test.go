@@ -0,0 +1,134 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/migotom/mt-bulk/internal/driver"
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+func TestWriteHosts(t *testing.T) {
+	hosts := []entities.Host{{IP: "10.0.0.10"}, {IP: "10.0.0.2", Port: "2222", Password: "secret"}}
+	expected := []entities.Host{{IP: "10.0.0.2", Port: "2222"}, {IP: "10.0.0.10"}}
+
+	dir, err := ioutil.TempDir("", "mt-bulk-discovery")
+	if err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"hosts.yml", "hosts.toml", "hosts.txt"} {
+		t.Run(name, func(t *testing.T) {
+			var output bytes.Buffer
+			if err := WriteHosts(&output, HostsFormat(name), hosts); err != nil {
+				t.Fatalf("not expected error %v", err)
+			}
+			if bytes.Contains(output.Bytes(), []byte("secret")) {
+				t.Errorf("credentials written to hosts file: %s", output.String())
+			}
+
+			file := filepath.Join(dir, name)
+			if err := ioutil.WriteFile(file, output.Bytes(), 0600); err != nil {
+				t.Fatalf("not expected error %v", err)
+			}
+			jobs, err := driver.FileLoadJobs(context.Background(), entities.Job{}, file)
+			if err != nil {
+				t.Fatalf("not expected error %v", err)
+			}
+
+			var loaded []entities.Host
+			for _, job := range jobs {
+				loaded = append(loaded, job.Host)
+			}
+			if !reflect.DeepEqual(loaded, expected) {
+				t.Errorf("got:%v, expected:%v", loaded, expected)
+			}
+		})
+	}
+}
+
+func TestTopologyWrite(t *testing.T) {
+	topology := NewTopology()
+	topology.AddHost("10.0.0.1", &entities.Discovery{Identity: "core", Neighbors: []entities.Neighbor{
+		{Address: "10.0.0.2", Identity: "edge", Interface: "ether1", Protocol: "mndp"},
+		{MACAddress: "00:1B:54:00:00:06", Identity: "ap", Protocol: "lldp"},
+	}})
+	topology.AddHost("10.0.0.2", &entities.Discovery{Identity: "edge", Neighbors: []entities.Neighbor{
+		{Address: "10.0.0.1", Identity: "core", Interface: "ether2", Protocol: "mndp"},
+	}})
+
+	cases := []struct {
+		Name     string
+		Format   string
+		Expected string
+	}{
+		{
+			Name:   "DOT",
+			Format: FormatDOT,
+			Expected: "graph topology {\n" +
+				"  \"10.0.0.1\" [label=\"core\\n10.0.0.1\"];\n" +
+				"  \"10.0.0.2\" [label=\"edge\\n10.0.0.2\"];\n" +
+				"  \"00:1B:54:00:00:06\" [label=\"ap\\n00:1B:54:00:00:06\", style=dashed];\n" +
+				"  \"10.0.0.1\" -- \"10.0.0.2\" [label=\"ether1 (mndp)\"];\n" +
+				"  \"10.0.0.1\" -- \"00:1B:54:00:00:06\" [label=\"lldp\"];\n" +
+				"}\n",
+		},
+		{
+			Name:   "JSON",
+			Format: FormatJSON,
+			Expected: `{
+  "nodes": [
+    {
+      "id": "10.0.0.1",
+      "address": "10.0.0.1",
+      "identity": "core",
+      "visited": true
+    },
+    {
+      "id": "10.0.0.2",
+      "address": "10.0.0.2",
+      "identity": "edge",
+      "visited": true
+    },
+    {
+      "id": "00:1B:54:00:00:06",
+      "mac_address": "00:1B:54:00:00:06",
+      "identity": "ap",
+      "visited": false
+    }
+  ],
+  "links": [
+    {
+      "from": "10.0.0.1",
+      "to": "10.0.0.2",
+      "interface": "ether1",
+      "protocol": "mndp"
+    },
+    {
+      "from": "10.0.0.1",
+      "to": "00:1B:54:00:00:06",
+      "protocol": "lldp"
+    }
+  ]
+}
+`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var output bytes.Buffer
+			if err := topology.Write(&output, tc.Format); err != nil {
+				t.Fatalf("not expected error %v", err)
+			}
+			if output.String() != tc.Expected {
+				t.Errorf("got:%v, expected:%v", output.String(), tc.Expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package discovery
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+// Formats of hosts file, the same as read by --source-file option.
+const (
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+	FormatTXT  = "txt"
+)
+
+// hostsFile is hosts file's structure, credentials are never written.
+type hostsFile struct {
+	Host []hostEntry `toml:"host" yaml:"host"`
+}
+
+type hostEntry struct {
+	IP   string `toml:"ip" yaml:"ip"`
+	Port string `toml:"port,omitempty" yaml:"port,omitempty"`
+}
+
+// HostsFormat returns format of hosts file by extension of file name.
+func HostsFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".toml":
+		return FormatTOML
+	case ".yml", ".yaml":
+		return FormatYAML
+	}
+	return FormatTXT
+}
+
+// WriteHosts writes list of hosts sorted by address in given format.
+func WriteHosts(w io.Writer, format string, hosts []entities.Host) error {
+	var file hostsFile
+	for _, host := range hosts {
+		file.Host = append(file.Host, hostEntry{IP: host.IP, Port: host.Port})
+	}
+	sort.Slice(file.Host, func(a, b int) bool {
+		return bytes.Compare(ipKey(file.Host[a].IP), ipKey(file.Host[b].IP)) < 0
+	})
+
+	switch format {
+	case FormatTOML:
+		return toml.NewEncoder(w).Encode(file)
+	case FormatYAML:
+		content, err := yaml.Marshal(file)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(content)
+		return err
+	case FormatTXT:
+		for _, host := range file.Host {
+			line := host.IP
+			if host.Port != "" {
+				line += ":" + host.Port
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown hosts file format %q", format)
+}
+
+// ipKey returns sortable key of IP address.
+func ipKey(IP string) []byte {
+	if parsed := net.ParseIP(IP); parsed != nil {
+		return parsed.To16()
+	}
+	return []byte(IP)
+}
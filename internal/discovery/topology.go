@@ -0,0 +1,172 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+// Formats of topology export.
+const (
+	FormatDOT  = "dot"
+	FormatJSON = "json"
+)
+
+// Topology is graph of discovered devices connected by links to their neighbors.
+type Topology struct {
+	Nodes []Node `json:"nodes"`
+	Links []Link `json:"links"`
+
+	nodes map[string]int
+	links map[string]bool
+}
+
+// Node is discovered device, identified by its address or MAC address if address is unknown.
+type Node struct {
+	ID         string `json:"id"`
+	Address    string `json:"address,omitempty"`
+	MACAddress string `json:"mac_address,omitempty"`
+	Identity   string `json:"identity,omitempty"`
+	Platform   string `json:"platform,omitempty"`
+	Version    string `json:"version,omitempty"`
+	Board      string `json:"board,omitempty"`
+	Visited    bool   `json:"visited"`
+}
+
+// Link connects device with its neighbor, interface is device's local interface.
+type Link struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Interface string `json:"interface,omitempty"`
+	Protocol  string `json:"protocol"`
+}
+
+// NewTopology returns empty topology.
+func NewTopology() *Topology {
+	return &Topology{
+		Nodes: []Node{},
+		Links: []Link{},
+		nodes: make(map[string]int),
+		links: make(map[string]bool),
+	}
+}
+
+// AddHost adds visited host with its neighbors, host without discovery result is added as not visited node.
+func (t *Topology) AddHost(IP string, discovery *entities.Discovery) {
+	if discovery == nil {
+		t.node(Node{ID: IP, Address: IP})
+		return
+	}
+	t.node(Node{ID: IP, Address: IP, Identity: discovery.Identity, Visited: true})
+
+	for _, neighbor := range discovery.Neighbors {
+		ID := neighbor.Address
+		if ID == "" {
+			ID = neighbor.MACAddress
+		}
+		t.node(Node{
+			ID:         ID,
+			Address:    neighbor.Address,
+			MACAddress: neighbor.MACAddress,
+			Identity:   neighbor.Identity,
+			Platform:   neighbor.Platform,
+			Version:    neighbor.Version,
+			Board:      neighbor.Board,
+		})
+		t.link(Link{From: IP, To: ID, Interface: neighbor.Interface, Protocol: neighbor.Protocol})
+	}
+}
+
+// node adds node or fills in missing properties of already known one.
+func (t *Topology) node(node Node) {
+	idx, found := t.nodes[node.ID]
+	if !found {
+		t.nodes[node.ID] = len(t.Nodes)
+		t.Nodes = append(t.Nodes, node)
+		return
+	}
+
+	known := &t.Nodes[idx]
+	for _, field := range []struct{ known, value *string }{
+		{&known.Address, &node.Address},
+		{&known.MACAddress, &node.MACAddress},
+		{&known.Identity, &node.Identity},
+		{&known.Platform, &node.Platform},
+		{&known.Version, &node.Version},
+		{&known.Board, &node.Board},
+	} {
+		if *field.known == "" {
+			*field.known = *field.value
+		}
+	}
+	known.Visited = known.Visited || node.Visited
+}
+
+// link adds link unless the same link was already reported by other side.
+func (t *Topology) link(link Link) {
+	ends := []string{link.From, link.To}
+	if ends[0] > ends[1] {
+		ends[0], ends[1] = ends[1], ends[0]
+	}
+	key := strings.Join(append(ends, link.Protocol), " ")
+	if t.links[key] {
+		return
+	}
+	t.links[key] = true
+	t.Links = append(t.Links, link)
+}
+
+// TopologyFormat returns format of topology export by extension of file name.
+func TopologyFormat(filename string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".dot", ".gv":
+		return FormatDOT, nil
+	case ".json":
+		return FormatJSON, nil
+	}
+	return "", fmt.Errorf("unknown topology format of %s, expected .dot, .gv or .json file", filename)
+}
+
+// Write writes topology in given format.
+func (t *Topology) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatDOT:
+		return t.writeDOT(w)
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(t)
+	}
+	return fmt.Errorf("unknown topology format %q", format)
+}
+
+func (t *Topology) writeDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("graph topology {\n")
+	for _, node := range t.Nodes {
+		label := node.ID
+		if node.Identity != "" {
+			label = node.Identity + "\n" + node.ID
+		}
+		fmt.Fprintf(&b, "  %q [label=%q", node.ID, label)
+		if !node.Visited {
+			b.WriteString(", style=dashed")
+		}
+		b.WriteString("];\n")
+	}
+	for _, link := range t.Links {
+		label := link.Protocol
+		if link.Interface != "" {
+			label = link.Interface + " (" + link.Protocol + ")"
+		}
+		fmt.Fprintf(&b, "  %q -- %q [label=%q];\n", link.From, link.To, label)
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -6,15 +6,18 @@ import (
 	"github.com/migotom/mt-bulk/internal/entities"
 )
 
-// ArgvLoadJobs loads lists of jobs using standard argument list.
+// ArgvLoadJobs loads lists of jobs using standard argument list, CIDRs and ranges of hosts are expanded.
 func ArgvLoadJobs(ctx context.Context, jobTemplate entities.Job, args []string) (jobs []entities.Job, err error) {
 	for _, entry := range args {
-		job := jobTemplate
-		job.Host = entities.Host{IP: entry}
-		if err := job.Host.Parse(); err != nil {
+		hosts, err := entities.Host{IP: entry}.Expand()
+		if err != nil {
 			return nil, err
 		}
-		jobs = append(jobs, job)
+		for _, host := range hosts {
+			job := jobTemplate
+			job.Host = host
+			jobs = append(jobs, job)
+		}
 	}
 	return jobs, nil
 }
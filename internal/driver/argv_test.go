@@ -24,6 +24,16 @@ func TestArgvLoadJobs(t *testing.T) {
 				entities.Job{Host: entities.Host{IP: "192.168.1.2", Port: "44"}},
 			},
 		},
+		{
+			Name: "OK, CIDR and range",
+			Args: []string{"192.168.1.0/30", "192.168.2.1:44-192.168.2.2:44"},
+			ExpectedJobs: []entities.Job{
+				entities.Job{Host: entities.Host{IP: "192.168.1.1"}},
+				entities.Job{Host: entities.Host{IP: "192.168.1.2"}},
+				entities.Job{Host: entities.Host{IP: "192.168.2.1", Port: "44"}},
+				entities.Job{Host: entities.Host{IP: "192.168.2.2", Port: "44"}},
+			},
+		},
 		{
 			Name:          "Wrong hostname",
 			Args:          []string{"fuu", "bar"},
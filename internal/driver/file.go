@@ -17,10 +17,21 @@ import (
 	"github.com/migotom/mt-bulk/internal/entities"
 )
 
-// FileLoadJobs loads list of jobs from file.
+// Columns of CSV file (e.g. exported from The Dude) holding host's address, device's type and comma separated lists of host's tags and groups,
+// other columns are host's variables.
+const (
+	csvColumnAddresses = "Addresses"
+	csvColumnType      = "Type"
+	csvColumnTags      = "Tags"
+	csvColumnGroups    = "Groups"
+)
+
+// FileLoadJobs loads list of jobs from file, CIDRs and ranges of hosts are expanded.
+// Hosts inherit not defined port and credentials from groups defined by YAML and TOML file.
 func FileLoadJobs(ctx context.Context, jobTemplate entities.Job, filename string) (jobs []entities.Job, err error) {
 	var hosts struct {
-		Host []entities.Host
+		Host  []entities.Host
+		Group []entities.Group
 	}
 
 	content, err := ioutil.ReadFile(filename)
@@ -40,42 +51,84 @@ func FileLoadJobs(ctx context.Context, jobTemplate entities.Job, filename string
 			return nil, err
 		}
 	case ".csv":
-		type H struct {
-			IP   string `csv:"Addresses"`
-			Type string `csv:"Type"`
-		}
-		var hs []H
-		err = gocsv.UnmarshalBytes(content, &hs)
+		rows, err := gocsv.CSVToMaps(bytes.NewReader(content))
 		if err != nil {
 			return nil, err
 		}
-		for _, h := range hs {
+		for _, row := range rows {
 			// csv export from The Dude contains all Devices, so filter out everything that is not RouterOS
-			if h.Type == "RouterOS" {
-				hosts.Host = append(hosts.Host, entities.Host{IP: h.IP})
+			if deviceType, ok := row[csvColumnType]; ok && deviceType != "RouterOS" {
+				continue
+			}
+
+			host := entities.Host{IP: row[csvColumnAddresses], Vars: make(map[string]string, len(row))}
+			for column, value := range row {
+				switch column {
+				case csvColumnAddresses, csvColumnType:
+				case csvColumnTags:
+					host.Tags = splitList(value)
+				case csvColumnGroups:
+					host.Groups = splitList(value)
+				default:
+					host.Vars[column] = value
+				}
 			}
+			hosts.Host = append(hosts.Host, host)
 		}
 	default:
 		reader := bytes.NewReader(content)
 		scanner := bufio.NewScanner(reader)
 		for scanner.Scan() {
-			hosts.Host = append(hosts.Host, entities.Host{IP: scanner.Text()})
+			// host's address may be followed by host's tags and groups prefixed by @, e.g. 10.0.0.1 site=waw @core
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 0 {
+				continue
+			}
+
+			host := entities.Host{IP: fields[0]}
+			for _, field := range fields[1:] {
+				if strings.HasPrefix(field, "@") {
+					host.Groups = append(host.Groups, strings.TrimPrefix(field, "@"))
+				} else {
+					host.Tags = append(host.Tags, field)
+				}
+			}
+			hosts.Host = append(hosts.Host, host)
 		}
 		if err := scanner.Err(); err != nil {
 			return nil, err
 		}
 	}
 
+	if err := entities.ValidateGroups(hosts.Group); err != nil {
+		return nil, err
+	}
+
 	for _, host := range hosts.Host {
-		job := jobTemplate
-		job.Host = host
-		if err := job.Host.Parse(); err != nil {
+		host.SetGroupDefaults(hosts.Group)
+
+		expanded, err := host.Expand()
+		if err != nil {
 			log.Printf("Skipping host: %s\n", err)
 			continue
 		}
 
-		jobs = append(jobs, job)
+		for _, host := range expanded {
+			job := jobTemplate
+			job.Host = host
+			jobs = append(jobs, job)
+		}
 	}
 
 	return jobs, nil
 }
+
+// splitList splits comma separated list, empty elements are skipped.
+func splitList(list string) (elements []string) {
+	for _, element := range strings.Split(list, ",") {
+		if element = strings.TrimSpace(element); element != "" {
+			elements = append(elements, element)
+		}
+	}
+	return
+}
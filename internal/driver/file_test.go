@@ -2,6 +2,7 @@ package driver
 
 import (
 	"context"
+	"errors"
 	"io/ioutil"
 	"os"
 	"reflect"
@@ -13,6 +14,7 @@ import (
 func TestFileLoadJobs(t *testing.T) {
 	cases := []struct {
 		Name          string
+		Extension     string
 		FileContent   string
 		ExpectedJobs  []entities.Job
 		ExpectedError error
@@ -32,10 +34,60 @@ func TestFileLoadJobs(t *testing.T) {
 				entities.Job{Host: entities.Host{IP: "192.168.1.2", Port: "22"}},
 			},
 		},
+		{
+			Name:        "OK, yaml with host variables",
+			Extension:   ".yml",
+			FileContent: "host:\n  - ip: 192.168.1.1\n    vars:\n      site: waw\n",
+			ExpectedJobs: []entities.Job{
+				entities.Job{Host: entities.Host{IP: "192.168.1.1", Vars: map[string]string{"site": "waw"}}},
+			},
+		},
+		{
+			Name:        "OK, tags and groups",
+			FileContent: "192.168.1.1 site=waw role=core @backbone\n\n192.168.1.2:22 lab",
+			ExpectedJobs: []entities.Job{
+				entities.Job{Host: entities.Host{IP: "192.168.1.1", Tags: []string{"site=waw", "role=core"}, Groups: []string{"backbone"}}},
+				entities.Job{Host: entities.Host{IP: "192.168.1.2", Port: "22", Tags: []string{"lab"}}},
+			},
+		},
+		{
+			Name:        "OK, yaml with groups' defaults",
+			Extension:   ".yml",
+			FileContent: "group:\n  - name: core\n    port: \"2222\"\n    user: netops\nhost:\n  - ip: 192.168.1.1\n    groups: [core]\n    tags: [site=waw]\n  - ip: 192.168.1.2\n    user: admin\n    groups: [core]\n",
+			ExpectedJobs: []entities.Job{
+				entities.Job{Host: entities.Host{IP: "192.168.1.1", Port: "2222", User: "netops", Tags: []string{"site=waw"}, Groups: []string{"core"}}},
+				entities.Job{Host: entities.Host{IP: "192.168.1.2", Port: "2222", User: "admin", Groups: []string{"core"}}},
+			},
+		},
+		{
+			Name:          "Wrong, yaml with duplicated groups",
+			Extension:     ".yml",
+			FileContent:   "group:\n  - name: core\n  - name: core\n",
+			ExpectedError: errors.New("duplicated group core"),
+		},
+		{
+			Name:        "OK, csv with tags and groups columns",
+			Extension:   ".csv",
+			FileContent: "Addresses,Tags,Groups\n192.168.1.1,\"site=waw, role=core\",backbone\n",
+			ExpectedJobs: []entities.Job{
+				entities.Job{Host: entities.Host{IP: "192.168.1.1", Vars: map[string]string{}, Tags: []string{"site=waw", "role=core"}, Groups: []string{"backbone"}}},
+			},
+		},
+		{
+			Name:        "OK, csv columns as host variables, skip not RouterOS devices",
+			Extension:   ".csv",
+			FileContent: "Addresses,Type,Site\n192.168.1.1,RouterOS,waw\n192.168.1.2,Generic,krk\n",
+			ExpectedJobs: []entities.Job{
+				entities.Job{Host: entities.Host{IP: "192.168.1.1", Vars: map[string]string{"Site": "waw"}}},
+			},
+		},
 	}
 	for _, tc := range cases {
 		t.Run(tc.Name, func(t *testing.T) {
-			tmpfile, err := ioutil.TempFile("", "test.txt")
+			if tc.Extension == "" {
+				tc.Extension = ".txt"
+			}
+			tmpfile, err := ioutil.TempFile("", "test*"+tc.Extension)
 			if err != nil {
 				t.Errorf("Can't create temporary test file %v", err)
 			}
@@ -0,0 +1,153 @@
+package driver
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/migotom/mt-bulk/internal/clients"
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+// DefaultProbeTimeout is default time limit of establishing TCP connection by hosts probe.
+const DefaultProbeTimeout = time.Second
+
+// probeWorkers limits number of parallel TCP connections of hosts probe.
+const probeWorkers = 64
+
+// HostsConfig defines groups, selection, exclusions and reachability probe of loaded hosts.
+type HostsConfig struct {
+	Groups       []entities.Group `toml:"groups" yaml:"groups"`
+	Select       string           `toml:"select" yaml:"select"`
+	Exclude      []string         `toml:"exclude" yaml:"exclude"`
+	Probe        bool             `toml:"probe" yaml:"probe"`
+	ProbePort    string           `toml:"probe_port" yaml:"probe_port"`
+	ProbeTimeout clients.Duration `toml:"probe_timeout" yaml:"probe_timeout"`
+}
+
+// Validate verifies groups, selector and list of excluded hosts.
+func (c HostsConfig) Validate() error {
+	if err := entities.ValidateGroups(c.Groups); err != nil {
+		return err
+	}
+	if _, err := entities.ParseSelector(c.Select); err != nil {
+		return err
+	}
+	_, err := newExclusions(c.Exclude)
+	return err
+}
+
+// FilterJobs sets hosts' defaults inherited from groups, removes jobs of hosts not matching selector, duplicated jobs and jobs of excluded hosts,
+// hosts without port are assumed to use default one.
+// If probe is enabled jobs of hosts not accepting TCP connection on host's port (or probe port) are removed and their hosts returned as unreachable.
+func FilterJobs(ctx context.Context, jobs []entities.Job, config HostsConfig, defaultPort string) (filtered []entities.Job, unreachable []entities.Host, err error) {
+	selector, err := entities.ParseSelector(config.Select)
+	if err != nil {
+		return nil, nil, err
+	}
+	excluded, err := newExclusions(config.Exclude)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seen := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		job.Host.SetGroupDefaults(config.Groups)
+		if !selector.Match(job.Host) {
+			continue
+		}
+
+		port := job.Host.Port
+		if port == "" {
+			port = defaultPort
+		}
+		address := net.JoinHostPort(job.Host.IP, port)
+		if seen[address] || excluded.match(job.Host.IP, port) {
+			continue
+		}
+		seen[address] = true
+		filtered = append(filtered, job)
+	}
+
+	if !config.Probe {
+		return filtered, nil, nil
+	}
+
+	reachable := make([]bool, len(filtered))
+	timeout := config.ProbeTimeout.OrDefault(DefaultProbeTimeout)
+	workers := make(chan struct{}, probeWorkers)
+	wg := new(sync.WaitGroup)
+	for idx, job := range filtered {
+		port := job.Host.Port
+		if port == "" {
+			port = config.ProbePort
+		}
+		if port == "" {
+			port = defaultPort
+		}
+
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(idx int, address string) {
+			defer wg.Done()
+			defer func() { <-workers }()
+
+			dialer := net.Dialer{Timeout: timeout}
+			if conn, err := dialer.DialContext(ctx, "tcp", address); err == nil {
+				conn.Close()
+				reachable[idx] = true
+			}
+		}(idx, net.JoinHostPort(job.Host.IP, port))
+	}
+	wg.Wait()
+
+	probed := filtered[:0]
+	for idx, job := range filtered {
+		if reachable[idx] {
+			probed = append(probed, job)
+		} else {
+			unreachable = append(unreachable, job.Host)
+		}
+	}
+	return probed, unreachable, nil
+}
+
+// exclusions matches excluded hosts, hosts excluded without port are excluded regardless of port.
+type exclusions struct {
+	networks []*net.IPNet
+	hosts    map[string]bool
+}
+
+func newExclusions(list []string) (*exclusions, error) {
+	e := &exclusions{hosts: make(map[string]bool)}
+	for _, entry := range list {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			e.networks = append(e.networks, network)
+			continue
+		}
+
+		hosts, err := entities.Host{IP: entry}.Expand()
+		if err != nil {
+			return nil, err
+		}
+		for _, host := range hosts {
+			e.hosts[net.JoinHostPort(host.IP, host.Port)] = true
+		}
+	}
+	return e, nil
+}
+
+func (e *exclusions) match(IP, port string) bool {
+	if e.hosts[net.JoinHostPort(IP, "")] || e.hosts[net.JoinHostPort(IP, port)] {
+		return true
+	}
+
+	parsed := net.ParseIP(IP)
+	for _, network := range e.networks {
+		if parsed != nil && network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
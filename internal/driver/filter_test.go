@@ -0,0 +1,106 @@
+package driver
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+func TestFilterJobs(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	defer listener.Close()
+	_, openPort, _ := net.SplitHostPort(listener.Addr().String())
+
+	// reserve port and release it to get port not accepting connections
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	_, closedPort, _ := net.SplitHostPort(closed.Addr().String())
+	closed.Close()
+
+	jobs := func(hosts ...entities.Host) (jobs []entities.Job) {
+		for _, host := range hosts {
+			jobs = append(jobs, entities.Job{Host: host})
+		}
+		return
+	}
+
+	cases := []struct {
+		Name                string
+		Jobs                []entities.Job
+		Config              HostsConfig
+		Expected            []entities.Job
+		ExpectedUnreachable []entities.Host
+		ExpectedError       bool
+	}{
+		{
+			Name:     "Dedupe",
+			Jobs:     jobs(entities.Host{IP: "10.0.0.1"}, entities.Host{IP: "10.0.0.1", Port: "22"}, entities.Host{IP: "10.0.0.1", Port: "2222"}),
+			Expected: jobs(entities.Host{IP: "10.0.0.1"}, entities.Host{IP: "10.0.0.1", Port: "2222"}),
+		},
+		{
+			Name:     "Exclude",
+			Jobs:     jobs(entities.Host{IP: "10.0.0.1"}, entities.Host{IP: "10.0.0.2"}, entities.Host{IP: "10.0.1.1", Port: "2222"}, entities.Host{IP: "10.0.2.5"}, entities.Host{IP: "10.0.2.6"}),
+			Config:   HostsConfig{Exclude: []string{"10.0.0.1", "10.0.1.0/24", "10.0.2.1-10.0.2.5"}},
+			Expected: jobs(entities.Host{IP: "10.0.0.2"}, entities.Host{IP: "10.0.2.6"}),
+		},
+		{
+			Name:     "Exclude with port",
+			Jobs:     jobs(entities.Host{IP: "10.0.0.1"}, entities.Host{IP: "10.0.0.1", Port: "2222"}),
+			Config:   HostsConfig{Exclude: []string{"10.0.0.1:2222"}},
+			Expected: jobs(entities.Host{IP: "10.0.0.1"}),
+		},
+		{
+			Name:                "Probe",
+			Jobs:                jobs(entities.Host{IP: "127.0.0.1", Port: openPort}, entities.Host{IP: "127.0.0.1", Port: closedPort}, entities.Host{IP: "127.0.0.1"}),
+			Config:              HostsConfig{Probe: true, ProbePort: openPort},
+			Expected:            jobs(entities.Host{IP: "127.0.0.1", Port: openPort}, entities.Host{IP: "127.0.0.1"}),
+			ExpectedUnreachable: []entities.Host{{IP: "127.0.0.1", Port: closedPort}},
+		},
+		{
+			Name:     "Select with group defaults",
+			Jobs:     jobs(entities.Host{IP: "10.0.0.1", Tags: []string{"site=waw"}, Groups: []string{"core"}}, entities.Host{IP: "10.0.0.2", Tags: []string{"site=waw", "lab"}}, entities.Host{IP: "10.0.0.3", Tags: []string{"site=krk"}}),
+			Config:   HostsConfig{Select: "site=waw,!lab", Groups: []entities.Group{{Name: "core", Port: "2222", User: "netops"}}},
+			Expected: jobs(entities.Host{IP: "10.0.0.1", Port: "2222", User: "netops", Tags: []string{"site=waw"}, Groups: []string{"core"}}),
+		},
+		{
+			Name:     "Dedupe by port inherited from group",
+			Jobs:     jobs(entities.Host{IP: "10.0.0.1", Port: "2222"}, entities.Host{IP: "10.0.0.1", Groups: []string{"core"}}),
+			Config:   HostsConfig{Groups: []entities.Group{{Name: "core", Port: "2222"}}},
+			Expected: jobs(entities.Host{IP: "10.0.0.1", Port: "2222"}),
+		},
+		{
+			Name:          "Wrong, invalid selector",
+			Jobs:          jobs(entities.Host{IP: "10.0.0.1"}),
+			Config:        HostsConfig{Select: "site="},
+			ExpectedError: true,
+		},
+		{
+			Name:          "Wrong, invalid exclusion",
+			Jobs:          jobs(entities.Host{IP: "10.0.0.1"}),
+			Config:        HostsConfig{Exclude: []string{"10.0.0.9-10.0.0.1"}},
+			ExpectedError: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			filtered, unreachable, err := FilterJobs(context.Background(), tc.Jobs, tc.Config, "22")
+			if (err != nil) != tc.ExpectedError {
+				t.Fatalf("got:%v, expected error:%v", err, tc.ExpectedError)
+			}
+			if !reflect.DeepEqual(filtered, tc.Expected) {
+				t.Errorf("got:%v, expected:%v", filtered, tc.Expected)
+			}
+			if !reflect.DeepEqual(unreachable, tc.ExpectedUnreachable) {
+				t.Errorf("got unreachable:%v, expected:%v", unreachable, tc.ExpectedUnreachable)
+			}
+		})
+	}
+}
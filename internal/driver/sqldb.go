@@ -11,6 +11,12 @@ import (
 
 const maxRetries = 3
 
+// Columns of devices query holding comma separated lists of host's tags and groups.
+const (
+	sqlColumnTags   = "tags"
+	sqlColumnGroups = "groups"
+)
+
 type sqlDB struct {
 	conn     *sql.DB
 	dbConfig *DBConfig
@@ -74,7 +80,7 @@ func DBCleaner(dbConfig *DBConfig) {
 	}
 }
 
-// DBSqlLoadJobs loads list of jobs from database.
+// DBSqlLoadJobs loads list of jobs from database, CIDRs and ranges of hosts are expanded.
 func DBSqlLoadJobs(ctx context.Context, jobTemplate entities.Job, dbConfig *DBConfig) ([]entities.Job, error) {
 	db := getDB(dbConfig)
 	if err := db.connect(); err != nil {
@@ -89,20 +95,47 @@ func DBSqlLoadJobs(ctx context.Context, jobTemplate entities.Job, dbConfig *DBCo
 	}
 	defer rows.Close()
 
+	// columns following id and ip are host's tags, groups or variables
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
 	// TODO add ctx.Done check for very long running queries
 	for rows.Next() {
-		job := jobTemplate
-		job.Host = entities.Host{}
-
-		if err = rows.Scan(&job.Host.ID, &job.Host.IP); err != nil {
+		var host entities.Host
+		values := make([]sql.NullString, len(columns))
+		destinations := []interface{}{&host.ID, &host.IP}
+		for idx := 2; idx < len(columns); idx++ {
+			destinations = append(destinations, &values[idx])
+		}
+		if err = rows.Scan(destinations...); err != nil {
 			return nil, err
 		}
+		for idx := 2; idx < len(columns); idx++ {
+			switch columns[idx] {
+			case sqlColumnTags:
+				host.Tags = splitList(values[idx].String)
+			case sqlColumnGroups:
+				host.Groups = splitList(values[idx].String)
+			default:
+				if host.Vars == nil {
+					host.Vars = make(map[string]string, len(columns)-2)
+				}
+				host.Vars[columns[idx]] = values[idx].String
+			}
+		}
 
-		if err := job.Host.Parse(); err != nil {
+		hosts, err := host.Expand()
+		if err != nil {
 			return nil, err
 		}
 
-		jobs = append(jobs, job)
+		for _, host := range hosts {
+			job := jobTemplate
+			job.Host = host
+			jobs = append(jobs, job)
+		}
 	}
 
 	if err = rows.Err(); err != nil {
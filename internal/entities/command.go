@@ -1,8 +1,15 @@
 package entities
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
 
 // Command specifies single command, expected (or not) command's result and optional sleep time that should be performed after command execution.
+// API command may be defined by raw sentence in Body or in structured form by Path, Attributes and Queries.
+// Console output of command may be parsed into records by specifying its format in Parse.
+// Command of Template flag is rendered as Go template against host's variables, job's data and matches before execution.
 type Command struct {
 	Body        string   `toml:"body" yaml:"body" json:"body"`
 	Expect      string   `toml:"expect" yaml:"expect" json:"expect"`
@@ -10,17 +17,49 @@ type Command struct {
 	Match       string   `toml:"match" yaml:"match" json:"match"`
 	Matches     []string `toml:"matches" yaml:"matches" json:"matches"`
 	SleepMs     int      `toml:"sleep_ms" yaml:"sleep_ms" json:"sleep_ms"`
+	TimeoutMs   int      `toml:"timeout_ms" yaml:"timeout_ms" json:"timeout_ms,omitempty"`
+
+	Path       string            `toml:"path" yaml:"path" json:"path,omitempty"`
+	Attributes map[string]string `toml:"attributes" yaml:"attributes" json:"attributes,omitempty"`
+	Queries    []string          `toml:"queries" yaml:"queries" json:"queries,omitempty"`
+
+	Parse    string `toml:"parse" yaml:"parse" json:"parse,omitempty"`
+	Template bool   `toml:"template" yaml:"template" json:"template,omitempty"`
+}
+
+// Sentence returns API sentence of command, structured command is rendered with values quoted where required.
+func (c Command) Sentence() string {
+	if c.Path == "" {
+		return c.Body
+	}
+
+	keys := make([]string, 0, len(c.Attributes))
+	for key := range c.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	words := make([]string, 0, 1+len(keys)+len(c.Queries))
+	words = append(words, c.Path)
+	for _, key := range keys {
+		words = append(words, "="+key+"="+QuoteWord(c.Attributes[key]))
+	}
+	for _, query := range c.Queries {
+		words = append(words, "?"+QuoteWord(query))
+	}
+	return strings.Join(words, " ")
 }
 
 func (c Command) String() string {
-	return c.Body
+	return c.Sentence()
 }
 
 // CommandResult defines result of execution single command/operation.
 type CommandResult struct {
-	Body      string   `json:"body"`
-	Responses []string `json:"responses,omitempty"`
-	Error     error    `json:"error,omitempty"`
+	Body      string              `json:"body"`
+	Responses []string            `json:"responses,omitempty"`
+	Records   []map[string]string `json:"records,omitempty"`
+	Error     error               `json:"error,omitempty"`
 }
 
 // MarshalJSON marshals CommandResult with error support.
@@ -0,0 +1,44 @@
+package entities
+
+import "time"
+
+// Facts is inventory of device's hardware and software collected by CollectFacts job.
+type Facts struct {
+	Host            string           `json:"host"`
+	Collected       time.Time        `json:"collected"`
+	Identity        string           `json:"identity"`
+	BoardName       string           `json:"board_name"`
+	Model           string           `json:"model,omitempty"`
+	SerialNumber    string           `json:"serial_number,omitempty"`
+	Architecture    string           `json:"architecture,omitempty"`
+	Version         string           `json:"version"`
+	Firmware        string           `json:"firmware,omitempty"`
+	UpgradeFirmware string           `json:"upgrade_firmware,omitempty"`
+	LicenseLevel    string           `json:"license_level,omitempty"`
+	SoftwareID      string           `json:"software_id,omitempty"`
+	Uptime          string           `json:"uptime"`
+	CPU             string           `json:"cpu,omitempty"`
+	CPUCount        string           `json:"cpu_count,omitempty"`
+	CPULoad         string           `json:"cpu_load,omitempty"`
+	FreeMemory      string           `json:"free_memory,omitempty"`
+	TotalMemory     string           `json:"total_memory,omitempty"`
+	Interfaces      []InterfaceFacts `json:"interfaces,omitempty"`
+	Packages        []PackageFacts   `json:"packages,omitempty"`
+}
+
+// InterfaceFacts describes device's interface with its addresses.
+type InterfaceFacts struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type,omitempty"`
+	MACAddress string   `json:"mac_address,omitempty"`
+	Addresses  []string `json:"addresses,omitempty"`
+	Running    bool     `json:"running"`
+	Disabled   bool     `json:"disabled"`
+}
+
+// PackageFacts describes installed RouterOS package.
+type PackageFacts struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Disabled bool   `json:"disabled"`
+}
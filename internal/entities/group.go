@@ -0,0 +1,31 @@
+package entities
+
+import "fmt"
+
+// Group defines default port and credentials inherited by group's member hosts.
+type Group struct {
+	Name     string `toml:"name" yaml:"name" json:"name"`
+	Port     string `toml:"port" yaml:"port" json:"port,omitempty"`
+	User     string `toml:"user" yaml:"user" json:"user,omitempty"`
+	Password string `toml:"password" yaml:"password" json:"password,omitempty"`
+}
+
+// ValidateGroups verifies that each group is named and names are unique.
+func ValidateGroups(groups []Group) error {
+	names := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		if group.Name == "" {
+			return fmt.Errorf("group without name")
+		}
+		if names[group.Name] {
+			return fmt.Errorf("duplicated group %s", group.Name)
+		}
+		if group.Port != "" {
+			if err := validPort(group.Port); err != nil {
+				return fmt.Errorf("group %s: %v", group.Name, err)
+			}
+		}
+		names[group.Name] = true
+	}
+	return nil
+}
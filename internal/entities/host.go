@@ -1,12 +1,16 @@
 package entities
 
 import (
+	"encoding/binary"
 	"fmt"
 	"net"
 	"strconv"
 	"strings"
 )
 
+// MaxExpandedHosts limits number of hosts single CIDR or range may be expanded to.
+const MaxExpandedHosts = 65536
+
 // Host represents single host instance with all data and credentials required to connect to.
 type Host struct {
 	ID       string `json:"id,omitempty"`
@@ -14,6 +18,19 @@ type Host struct {
 	Port     string `toml:"port" yaml:"port" json:"port"`
 	User     string `toml:"user" yaml:"user" json:"user"`
 	Password string `toml:"password" yaml:"password" json:"password,omitempty"`
+
+	// Vars are host's variables available to templates of custom commands, e.g. site or VLAN ids.
+	Vars map[string]string `toml:"vars" yaml:"vars" json:"vars,omitempty"`
+
+	// Tags and Groups are used by selectors to target subset of hosts, e.g. `site=waw` or `lab`.
+	// Host inherits not defined port, user and password from its groups.
+	Tags   []string `toml:"tags" yaml:"tags" json:"tags,omitempty"`
+	Groups []string `toml:"groups" yaml:"groups" json:"groups,omitempty"`
+}
+
+// Equal returns true if both hosts address the same device using the same credentials, variables are not compared.
+func (h Host) Equal(other Host) bool {
+	return h.ID == other.ID && h.IP == other.IP && h.Port == other.Port && h.User == other.User && h.Password == other.Password
 }
 
 // GetPasswords returns list of available passwords.
@@ -38,6 +55,17 @@ func (h *Host) SetDefaults(defaultPort, defaultUser, defaultPasswords string) {
 	}
 }
 
+// SetGroupDefaults sets host's not defined values to values of host's groups, first group (in order of host's groups) defining value wins.
+func (h *Host) SetGroupDefaults(groups []Group) {
+	for _, name := range h.Groups {
+		for _, group := range groups {
+			if group.Name == name {
+				h.SetDefaults(group.Port, group.User, group.Password)
+			}
+		}
+	}
+}
+
 // Parse host IP address and split into IP, Port attribute if required.
 func (h *Host) Parse() error {
 	list := strings.Split(h.IP, ":")
@@ -47,9 +75,8 @@ func (h *Host) Parse() error {
 	}
 
 	if len(list) == 2 {
-		port, err := strconv.Atoi(list[1])
-		if err != nil || port < 0 || port > 65535 {
-			return fmt.Errorf("port invalid format: %s", list[1])
+		if err := validPort(list[1]); err != nil {
+			return err
 		}
 		h.Port = list[1]
 		h.IP = list[0]
@@ -68,6 +95,109 @@ func (h *Host) Parse() error {
 	return fmt.Errorf(fmt.Sprintf("can't resolve host: %s", h.IP))
 }
 
+// Expand expands host defined as IPv4 CIDR (e.g. 10.0.0.0/24) or range (e.g. 10.0.0.1-10.0.0.50 or 10.0.0.1:2222-10.0.0.9:2222) into list of parsed hosts.
+// Network and broadcast addresses of CIDR are skipped unless it is /31 or /32 network, single host is returned parsed.
+func (h Host) Expand() ([]Host, error) {
+	entry := h.IP
+	var first, last uint32
+
+	switch {
+	case strings.Contains(h.IP, "/"):
+		address, port := splitPort(h.IP)
+		_, network, err := net.ParseCIDR(address)
+		if err != nil || network.IP.To4() == nil {
+			return nil, fmt.Errorf("host invalid format: %s, allowed IPv4 CIDR", h.IP)
+		}
+		if port != "" {
+			if err := validPort(port); err != nil {
+				return nil, err
+			}
+			h.Port = port
+		}
+		ones, bits := network.Mask.Size()
+		first = binary.BigEndian.Uint32(network.IP.To4())
+		last = first | uint32(1<<uint(bits-ones)-1)
+		if bits-ones > 1 {
+			first, last = first+1, last-1
+		}
+	case isRange(h.IP):
+		ends := strings.Split(h.IP, "-")
+		from, to := Host{IP: ends[0]}, Host{IP: ends[1]}
+		if err := from.Parse(); err != nil {
+			return nil, err
+		}
+		if err := to.Parse(); err != nil {
+			return nil, err
+		}
+		if from.Port != "" && to.Port != "" && from.Port != to.Port {
+			return nil, fmt.Errorf("host invalid format: %s, range has to use the same port", h.IP)
+		}
+		if net.ParseIP(from.IP).To4() == nil || net.ParseIP(to.IP).To4() == nil {
+			return nil, fmt.Errorf("host invalid format: %s, allowed range of IPv4 addresses", h.IP)
+		}
+		first = binary.BigEndian.Uint32(net.ParseIP(from.IP).To4())
+		last = binary.BigEndian.Uint32(net.ParseIP(to.IP).To4())
+		if first > last {
+			return nil, fmt.Errorf("host invalid format: %s, range starts after its end", h.IP)
+		}
+		if from.Port != "" {
+			h.Port = from.Port
+		} else if to.Port != "" {
+			h.Port = to.Port
+		}
+	default:
+		if err := h.Parse(); err != nil {
+			return nil, err
+		}
+		return []Host{h}, nil
+	}
+
+	if uint64(last)-uint64(first)+1 > MaxExpandedHosts {
+		return nil, fmt.Errorf("host invalid format: %s, more than %d addresses", entry, MaxExpandedHosts)
+	}
+
+	hosts := make([]Host, 0, last-first+1)
+	for address := uint64(first); address <= uint64(last); address++ {
+		IP := make(net.IP, 4)
+		binary.BigEndian.PutUint32(IP, uint32(address))
+
+		host := h
+		host.IP = IP.String()
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
+// isRange returns true if address is range of IP addresses, not host name containing dash.
+func isRange(address string) bool {
+	ends := strings.Split(address, "-")
+	if len(ends) != 2 {
+		return false
+	}
+	for _, end := range ends {
+		if IP, _ := splitPort(end); net.ParseIP(IP) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// validPort returns error if port is not valid TCP port number.
+func validPort(port string) error {
+	if number, err := strconv.Atoi(port); err != nil || number < 0 || number > 65535 {
+		return fmt.Errorf("port invalid format: %s", port)
+	}
+	return nil
+}
+
+// splitPort splits address into address and optional port.
+func splitPort(address string) (string, string) {
+	if idx := strings.LastIndex(address, ":"); idx >= 0 {
+		return address[:idx], address[idx+1:]
+	}
+	return address, ""
+}
+
 func (h Host) String() string {
 	return fmt.Sprintf("%s@%s:%s", h.User, h.IP, h.Port)
 }
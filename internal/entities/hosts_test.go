@@ -69,6 +69,65 @@ func TestSetDefaults(t *testing.T) {
 		})
 	}
 }
+func TestSetGroupDefaults(t *testing.T) {
+	groups := []Group{
+		{Name: "core", Port: "2222", User: "netops"},
+		{Name: "waw", User: "waw", Password: "secret"},
+	}
+
+	cases := []struct {
+		Name     string
+		Host     Host
+		Expected Host
+	}{
+		{
+			Name:     "OK, not member of any group",
+			Host:     Host{IP: "10.0.0.1"},
+			Expected: Host{IP: "10.0.0.1"},
+		},
+		{
+			Name:     "OK, first group wins",
+			Host:     Host{IP: "10.0.0.1", Groups: []string{"core", "waw"}},
+			Expected: Host{IP: "10.0.0.1", Port: "2222", User: "netops", Password: "secret", Groups: []string{"core", "waw"}},
+		},
+		{
+			Name:     "OK, host's values not replaced, unknown group skipped",
+			Host:     Host{IP: "10.0.0.1", User: "admin", Groups: []string{"lab", "waw"}},
+			Expected: Host{IP: "10.0.0.1", User: "admin", Password: "secret", Groups: []string{"lab", "waw"}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			tc.Host.SetGroupDefaults(groups)
+			if !reflect.DeepEqual(tc.Host, tc.Expected) {
+				t.Errorf("got:%v, expected:%v", tc.Host, tc.Expected)
+			}
+		})
+	}
+}
+
+func TestValidateGroups(t *testing.T) {
+	cases := []struct {
+		Name          string
+		Groups        []Group
+		ExpectedError bool
+	}{
+		{Name: "OK", Groups: []Group{{Name: "core", Port: "22"}, {Name: "lab"}}},
+		{Name: "Wrong, without name", Groups: []Group{{User: "admin"}}, ExpectedError: true},
+		{Name: "Wrong, duplicated", Groups: []Group{{Name: "core"}, {Name: "core"}}, ExpectedError: true},
+		{Name: "Wrong, invalid port", Groups: []Group{{Name: "core", Port: "ssh"}}, ExpectedError: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if err := ValidateGroups(tc.Groups); (err != nil) != tc.ExpectedError {
+				t.Errorf("got:%v, expected error:%v", err, tc.ExpectedError)
+			}
+		})
+	}
+}
+
 func TestParse(t *testing.T) {
 	cases := []struct {
 		Name          string
@@ -119,3 +178,69 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestExpand(t *testing.T) {
+	cases := []struct {
+		Name          string
+		Host          Host
+		Expected      []Host
+		ExpectedError bool
+	}{
+		{
+			Name:     "OK single host",
+			Host:     Host{IP: "192.168.1.1:22", User: "admin"},
+			Expected: []Host{{IP: "192.168.1.1", Port: "22", User: "admin"}},
+		},
+		{
+			Name:     "OK CIDR",
+			Host:     Host{IP: "10.0.0.0/30", Password: "secret"},
+			Expected: []Host{{IP: "10.0.0.1", Password: "secret"}, {IP: "10.0.0.2", Password: "secret"}},
+		},
+		{
+			Name:     "OK CIDR /31 with port",
+			Host:     Host{IP: "10.0.0.0/31:2222"},
+			Expected: []Host{{IP: "10.0.0.0", Port: "2222"}, {IP: "10.0.0.1", Port: "2222"}},
+		},
+		{
+			Name:     "OK range",
+			Host:     Host{IP: "10.0.0.254-10.0.1.1", Port: "22"},
+			Expected: []Host{{IP: "10.0.0.254", Port: "22"}, {IP: "10.0.0.255", Port: "22"}, {IP: "10.0.1.0", Port: "22"}, {IP: "10.0.1.1", Port: "22"}},
+		},
+		{
+			Name:     "OK range with ports",
+			Host:     Host{IP: "10.0.0.1:2222-10.0.0.2:2222"},
+			Expected: []Host{{IP: "10.0.0.1", Port: "2222"}, {IP: "10.0.0.2", Port: "2222"}},
+		},
+		{
+			Name:          "Wrong, range of different ports",
+			Host:          Host{IP: "10.0.0.1:22-10.0.0.2:2222"},
+			ExpectedError: true,
+		},
+		{
+			Name:          "Wrong, reversed range",
+			Host:          Host{IP: "10.0.0.9-10.0.0.1"},
+			ExpectedError: true,
+		},
+		{
+			Name:          "Wrong, too large CIDR",
+			Host:          Host{IP: "10.0.0.0/8"},
+			ExpectedError: true,
+		},
+		{
+			Name:          "Wrong, invalid CIDR port",
+			Host:          Host{IP: "10.0.0.0/24:XX"},
+			ExpectedError: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			hosts, err := tc.Host.Expand()
+			if (err != nil) != tc.ExpectedError {
+				t.Errorf("got:%v, expected error:%v", err, tc.ExpectedError)
+			}
+			if !reflect.DeepEqual(hosts, tc.Expected) {
+				t.Errorf("got:%v, expected:%v", hosts, tc.Expected)
+			}
+		})
+	}
+}
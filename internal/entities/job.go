@@ -15,7 +15,14 @@ type Job struct {
 	Kind     string            `toml:"kind" yaml:"kind"`
 	Commands []Command         `toml:"commands" yaml:"commands"`
 	Data     map[string]string `toml:"data"  yaml:"data"`
+	Rules    []Rule            `toml:"rules" yaml:"rules" json:"rules,omitempty"`
 	Result   chan Result       `toml:"result" yaml:"result"`
+
+	// TimeoutMs is overall deadline of job processing in milliseconds.
+	TimeoutMs int `toml:"timeout_ms" yaml:"timeout_ms" json:"timeout_ms"`
+
+	// Selector targets job at hosts of inventory matching expression, e.g. `site=waw,role=core,!lab`, instead of single host.
+	Selector string `toml:"selector" yaml:"selector" json:"selector,omitempty"`
 }
 
 func (j Job) String() string {
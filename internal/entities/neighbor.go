@@ -0,0 +1,38 @@
+package entities
+
+import "strings"
+
+// Discovery is device's identity and list of its neighbors found by Discover job.
+type Discovery struct {
+	Identity  string     `json:"identity"`
+	Neighbors []Neighbor `json:"neighbors,omitempty"`
+}
+
+// Neighbor is device found in neighbor table (MNDP, CDP, LLDP) or routing neighbor (OSPF, BGP).
+type Neighbor struct {
+	Address    string `json:"address,omitempty"`
+	MACAddress string `json:"mac_address,omitempty"`
+	Identity   string `json:"identity,omitempty"`
+	Platform   string `json:"platform,omitempty"`
+	Version    string `json:"version,omitempty"`
+	Board      string `json:"board,omitempty"`
+	Interface  string `json:"interface,omitempty"`
+	Protocol   string `json:"protocol"`
+}
+
+// RouterOS returns true if neighbor may be RouterOS device, neighbors of unknown platform (e.g. routing neighbors) are assumed to be.
+func (n Neighbor) RouterOS() bool {
+	return n.Platform == "" || strings.Contains(strings.ToLower(n.Platform), "mikrotik")
+}
+
+func (n Neighbor) String() string {
+	var b strings.Builder
+	b.WriteString(n.Protocol)
+	for _, value := range []string{n.Interface, n.Address, n.MACAddress, n.Identity, n.Platform, n.Version} {
+		if value != "" {
+			b.WriteString(" ")
+			b.WriteString(value)
+		}
+	}
+	return b.String()
+}
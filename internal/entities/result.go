@@ -10,9 +10,27 @@ type Result struct {
 	Results               []CommandResult `toml:"results" yaml:"results" json:"results,omitempty"`
 	DownloadURLs          []string        `json:"download_urls,omitempty"`
 	AdditionalInformation []string        `json:"additional_information,omitempty"`
+	Drift                 bool            `json:"drift,omitempty"`
+	Rules                 []RuleResult    `json:"rules,omitempty"`
+	Facts                 *Facts          `json:"facts,omitempty"`
+	Discovery             *Discovery      `json:"discovery,omitempty"`
 	Errors                []error         `toml:"errors" yaml:"errors" json:"errors,omitempty"`
 }
 
+// Failed returns true if result contains any error failing job, errors reporting own failure state (e.g. triaged vulnerabilities) fail job only if they say so.
+func (r *Result) Failed() bool {
+	for _, err := range r.Errors {
+		if err == nil {
+			continue
+		}
+		if failure, ok := err.(interface{ Failed() bool }); ok && !failure.Failed() {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
 // MarshalJSON marshals Result with error support.
 func (r *Result) MarshalJSON() ([]byte, error) {
 	var err []string
@@ -0,0 +1,34 @@
+package entities
+
+import (
+	"errors"
+	"testing"
+)
+
+type reportedError bool
+
+func (e reportedError) Error() string { return "reported" }
+func (e reportedError) Failed() bool  { return bool(e) }
+
+func TestResultFailed(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Errors   []error
+		Expected bool
+	}{
+		{Name: "No errors", Expected: false},
+		{Name: "Nil error", Errors: []error{nil}, Expected: false},
+		{Name: "Error", Errors: []error{errors.New("failure")}, Expected: true},
+		{Name: "Only reported error", Errors: []error{reportedError(false)}, Expected: false},
+		{Name: "Failing reported error", Errors: []error{reportedError(true)}, Expected: true},
+		{Name: "Reported and other error", Errors: []error{reportedError(false), errors.New("failure")}, Expected: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			result := Result{Errors: tc.Errors}
+			if result.Failed() != tc.Expected {
+				t.Errorf("got:%v, expected:%v", !tc.Expected, tc.Expected)
+			}
+		})
+	}
+}
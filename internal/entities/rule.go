@@ -0,0 +1,41 @@
+package entities
+
+// Severities of rules.
+const (
+	SeverityInfo     = "info"
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// Rule declares desired state of device, verified by matching output of command with regexp or record match expression.
+// Rule is satisfied if expression matches output, or if it does not match in case of Absent rule (e.g. describing insecure setting).
+// Message of failed rule may refer to matched values by `%{evidence}`, Versions limits rule to range of RouterOS versions, e.g. `>=6.41 <7.0`.
+type Rule struct {
+	ID          string    `toml:"id" yaml:"id" json:"id"`
+	Description string    `toml:"description" yaml:"description" json:"description,omitempty"`
+	Severity    string    `toml:"severity" yaml:"severity" json:"severity,omitempty"`
+	Versions    string    `toml:"versions" yaml:"versions" json:"versions,omitempty"`
+	Command     string    `toml:"command" yaml:"command" json:"command"`
+	Parse       string    `toml:"parse" yaml:"parse" json:"parse,omitempty"`
+	Match       string    `toml:"match" yaml:"match" json:"match"`
+	Absent      bool      `toml:"absent" yaml:"absent" json:"absent,omitempty"`
+	Message     string    `toml:"message" yaml:"message" json:"message,omitempty"`
+	Disabled    bool      `toml:"disabled" yaml:"disabled" json:"disabled,omitempty"`
+	Remediation []Command `toml:"remediation" yaml:"remediation" json:"remediation,omitempty"`
+}
+
+// RuleResult is result of verification of single rule on device.
+type RuleResult struct {
+	ID          string   `json:"id"`
+	Description string   `json:"description,omitempty"`
+	Severity    string   `json:"severity"`
+	Passed      bool     `json:"passed"`
+	Fixed       bool     `json:"fixed,omitempty"`
+	Waived      bool     `json:"waived,omitempty"`
+	Waiver      string   `json:"waiver,omitempty"`
+	Message     string   `json:"message,omitempty"`
+	Evidence    []string `json:"evidence,omitempty"`
+	Error       string   `json:"error,omitempty"`
+}
@@ -0,0 +1,85 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Selector selects hosts by their tags, groups and variables, e.g. `site=waw,role=core,!lab`.
+// Selector is list of comma separated terms, host is selected if it matches all of them:
+//   - `name` matches host tagged by `name` or member of group `name`,
+//   - `key=value` matches host tagged by `key=value` or having variable `key` of `value`,
+//   - `!term` or `key!=value` matches host not matching term.
+type Selector []selectorTerm
+
+type selectorTerm struct {
+	key    string
+	value  string
+	negate bool
+}
+
+// ParseSelector parses selector expression, empty expression selects all hosts.
+func ParseSelector(expression string) (Selector, error) {
+	var selector Selector
+	for _, term := range strings.Split(expression, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		var parsed selectorTerm
+		if strings.HasPrefix(term, "!") {
+			parsed.negate = true
+			term = strings.TrimSpace(term[1:])
+		}
+		if idx := strings.Index(term, "="); idx >= 0 {
+			parsed.key, parsed.value = strings.TrimSpace(term[:idx]), strings.TrimSpace(term[idx+1:])
+			if strings.HasSuffix(parsed.key, "!") {
+				if parsed.negate {
+					return nil, fmt.Errorf("selector invalid format: %s, double negation", expression)
+				}
+				parsed.key, parsed.negate = strings.TrimSpace(strings.TrimSuffix(parsed.key, "!")), true
+			}
+		} else {
+			parsed.key = term
+		}
+		if parsed.key == "" || (strings.Contains(term, "=") && parsed.value == "") || strings.ContainsAny(parsed.key, "!=") || strings.Contains(parsed.value, "=") {
+			return nil, fmt.Errorf("selector invalid format: %s, allowed comma separated list of name, key=value, !name or key!=value", expression)
+		}
+		selector = append(selector, parsed)
+	}
+	return selector, nil
+}
+
+// Match returns true if host matches all terms of selector.
+func (s Selector) Match(h Host) bool {
+	for _, term := range s {
+		if term.match(h) == term.negate {
+			return false
+		}
+	}
+	return true
+}
+
+func (t selectorTerm) match(h Host) bool {
+	tag := t.key
+	if t.value != "" {
+		tag = t.key + "=" + t.value
+		if value, ok := h.Vars[t.key]; ok && value == t.value {
+			return true
+		}
+	}
+	for _, hostTag := range h.Tags {
+		if hostTag == tag {
+			return true
+		}
+	}
+	if t.value == "" {
+		for _, group := range h.Groups {
+			if group == t.key {
+				return true
+			}
+		}
+	}
+	return false
+}
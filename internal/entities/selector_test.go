@@ -0,0 +1,50 @@
+package entities
+
+import "testing"
+
+func TestSelectorMatch(t *testing.T) {
+	core := Host{IP: "10.0.0.1", Tags: []string{"site=waw", "role=core"}, Groups: []string{"backbone"}}
+	lab := Host{IP: "10.0.0.2", Tags: []string{"site=waw", "lab"}, Vars: map[string]string{"role": "core"}}
+	edge := Host{IP: "10.0.0.3", Vars: map[string]string{"site": "krk", "role": "edge"}}
+
+	cases := []struct {
+		Name          string
+		Selector      string
+		Expected      []string
+		ExpectedError bool
+	}{
+		{Name: "OK, empty selects all", Selector: "", Expected: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}},
+		{Name: "OK, tags and variables", Selector: "site=waw,role=core", Expected: []string{"10.0.0.1", "10.0.0.2"}},
+		{Name: "OK, negated tag", Selector: "site=waw, role=core, !lab", Expected: []string{"10.0.0.1"}},
+		{Name: "OK, negated value", Selector: "site!=waw", Expected: []string{"10.0.0.3"}},
+		{Name: "OK, group", Selector: "backbone", Expected: []string{"10.0.0.1"}},
+		{Name: "OK, nothing matches", Selector: "role=core,site=krk"},
+		{Name: "Wrong, empty value", Selector: "site=", ExpectedError: true},
+		{Name: "Wrong, double negation", Selector: "!site!=waw", ExpectedError: true},
+		{Name: "Wrong, invalid term", Selector: "site=waw=krk", ExpectedError: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			selector, err := ParseSelector(tc.Selector)
+			if (err != nil) != tc.ExpectedError {
+				t.Fatalf("got:%v, expected error:%v", err, tc.ExpectedError)
+			}
+
+			var selected []string
+			for _, host := range []Host{core, lab, edge} {
+				if err == nil && selector.Match(host) {
+					selected = append(selected, host.IP)
+				}
+			}
+			if len(selected) != len(tc.Expected) {
+				t.Fatalf("got:%v, expected:%v", selected, tc.Expected)
+			}
+			for idx := range selected {
+				if selected[idx] != tc.Expected[idx] {
+					t.Errorf("got:%v, expected:%v", selected, tc.Expected)
+				}
+			}
+		})
+	}
+}
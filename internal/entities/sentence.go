@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// SplitSentence splits API sentence into words.
+// Words are separated by white spaces, double quotes group characters into single word (e.g. `=comment="hello world"`)
+// and backslash escapes following character (e.g. `=comment=say\ \"hi\"`).
+func SplitSentence(sentence string) (words []string, err error) {
+	var word strings.Builder
+	var inWord, quoted, escaped bool
+
+	for _, r := range sentence {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			inWord, escaped = true, true
+		case r == '"':
+			inWord, quoted = true, !quoted
+		case unicode.IsSpace(r) && !quoted:
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if escaped {
+		return nil, errors.New("unfinished escape sequence at the end of sentence")
+	}
+	if quoted {
+		return nil, errors.New("unterminated quoted string in sentence")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// QuoteWord quotes given word if it is required to keep it as single word of API sentence.
+func QuoteWord(word string) string {
+	if word != "" && !strings.ContainsAny(word, `"\`) && strings.IndexFunc(word, unicode.IsSpace) < 0 {
+		return word
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(word) + `"`
+}
@@ -0,0 +1,87 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSentence(t *testing.T) {
+	cases := []struct {
+		Name          string
+		Sentence      string
+		Expected      []string
+		ExpectedError bool
+	}{
+		{Name: "OK", Sentence: "/interface/print  ?type=ether", Expected: []string{"/interface/print", "?type=ether"}},
+		{Name: "OK, quoted value", Sentence: `/interface/set =.id=*1 =comment="hello world"`, Expected: []string{"/interface/set", "=.id=*1", "=comment=hello world"}},
+		{Name: "OK, quoted word", Sentence: `/ip/address/print "?comment=lan uplink"`, Expected: []string{"/ip/address/print", "?comment=lan uplink"}},
+		{Name: "OK, escaped characters", Sentence: `/system/note/set =note=say\ \"hi\"\\`, Expected: []string{"/system/note/set", `=note=say "hi"\`}},
+		{Name: "OK, empty value", Sentence: `/interface/set =comment=""`, Expected: []string{"/interface/set", "=comment="}},
+		{Name: "Wrong, unterminated quote", Sentence: `/interface/set =comment="hello`, ExpectedError: true},
+		{Name: "Wrong, unfinished escape", Sentence: `/interface/set =comment=hello\`, ExpectedError: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			words, err := SplitSentence(tc.Sentence)
+			if (err != nil) != tc.ExpectedError {
+				t.Fatalf("not expected error %v", err)
+			}
+			if !reflect.DeepEqual(words, tc.Expected) {
+				t.Errorf("got:%q, expected:%q", words, tc.Expected)
+			}
+		})
+	}
+}
+
+func TestCommandSentence(t *testing.T) {
+	cases := []struct {
+		Name          string
+		Command       Command
+		Expected      string
+		ExpectedWords []string
+	}{
+		{
+			Name:          "OK, raw body",
+			Command:       Command{Body: "/interface/print"},
+			Expected:      "/interface/print",
+			ExpectedWords: []string{"/interface/print"},
+		},
+		{
+			Name: "OK, structured",
+			Command: Command{
+				Path:       "/interface/set",
+				Attributes: map[string]string{".id": "*1", "comment": `uplink "A" \ B`, "disabled": "no"},
+			},
+			Expected:      `/interface/set =.id=*1 =comment="uplink \"A\" \\ B" =disabled=no`,
+			ExpectedWords: []string{"/interface/set", "=.id=*1", `=comment=uplink "A" \ B`, "=disabled=no"},
+		},
+		{
+			Name: "OK, structured with queries",
+			Command: Command{
+				Path:       "/ip/address/print",
+				Attributes: map[string]string{".proplist": "address"},
+				Queries:    []string{"comment=lan uplink", "#|"},
+			},
+			Expected:      `/ip/address/print =.proplist=address ?"comment=lan uplink" ?#|`,
+			ExpectedWords: []string{"/ip/address/print", "=.proplist=address", "?comment=lan uplink", "?#|"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			sentence := tc.Command.Sentence()
+			if sentence != tc.Expected {
+				t.Errorf("got:%v, expected:%v", sentence, tc.Expected)
+			}
+
+			words, err := SplitSentence(sentence)
+			if err != nil {
+				t.Fatalf("not expected error %v", err)
+			}
+			if !reflect.DeepEqual(words, tc.ExpectedWords) {
+				t.Errorf("got:%q, expected:%q", words, tc.ExpectedWords)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package entities
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Stages of RouterOS release, final releases are newer than release candidates of the same version number.
+const (
+	StageAlpha = iota
+	StageBeta
+	StageRC
+	StageFinal
+)
+
+var (
+	versionRx    = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:(alpha|beta|rc)(\d+)?)?$`)
+	constraintRx = regexp.MustCompile(`^(>=|<=|!=|>|<|=)?\s*(\S+)$`)
+	stages       = map[string]int{"alpha": StageAlpha, "beta": StageBeta, "rc": StageRC, "": StageFinal}
+)
+
+// Version is RouterOS version, e.g. `6.48.6` or `7.1rc4`.
+type Version struct {
+	Numbers     [3]int
+	Stage       int
+	StageNumber int
+}
+
+// ParseVersion parses RouterOS version as printed by device, e.g. `6.48.6 (long-term)` or `7.1rc4 (testing)`.
+func ParseVersion(input string) (Version, error) {
+	fields := strings.Fields(input)
+	if len(fields) == 0 {
+		return Version{}, fmt.Errorf("empty RouterOS version")
+	}
+
+	m := versionRx.FindStringSubmatch(strings.ToLower(fields[0]))
+	if m == nil {
+		return Version{}, fmt.Errorf("invalid RouterOS version %q", input)
+	}
+
+	var version Version
+	for idx, number := range m[1:4] {
+		if number != "" {
+			version.Numbers[idx], _ = strconv.Atoi(number)
+		}
+	}
+	version.Stage = stages[m[4]]
+	if m[5] != "" {
+		version.StageNumber, _ = strconv.Atoi(m[5])
+	}
+	return version, nil
+}
+
+// Compare returns -1, 0 or 1 if version is older, the same or newer than other one.
+func (v Version) Compare(other Version) int {
+	for idx := range v.Numbers {
+		if c := compareInts(v.Numbers[idx], other.Numbers[idx]); c != 0 {
+			return c
+		}
+	}
+	if c := compareInts(v.Stage, other.Stage); c != 0 {
+		return c
+	}
+	return compareInts(v.StageNumber, other.StageNumber)
+}
+
+func (v Version) String() string {
+	version := fmt.Sprintf("%d.%d", v.Numbers[0], v.Numbers[1])
+	if v.Numbers[2] > 0 {
+		version += fmt.Sprintf(".%d", v.Numbers[2])
+	}
+	for name, stage := range stages {
+		if stage == v.Stage && name != "" {
+			version += fmt.Sprintf("%s%d", name, v.StageNumber)
+		}
+	}
+	return version
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// VersionRange is list of constraints version has to satisfy, e.g. `>=6.41 <7.1rc1`.
+type VersionRange []VersionConstraint
+
+// VersionConstraint is single constraint of version range, e.g. `>=6.41`.
+type VersionConstraint struct {
+	Operator string
+	Version  Version
+}
+
+// ParseVersionRange parses version range of constraints separated by white spaces or commas, empty range contains all versions.
+func ParseVersionRange(input string) (VersionRange, error) {
+	var versionRange VersionRange
+	for _, field := range strings.Fields(strings.ReplaceAll(input, ",", " ")) {
+		m := constraintRx.FindStringSubmatch(field)
+		if m == nil {
+			return nil, fmt.Errorf("invalid version constraint %q", field)
+		}
+
+		version, err := ParseVersion(m[2])
+		if err != nil {
+			return nil, err
+		}
+
+		operator := m[1]
+		if operator == "" {
+			operator = "="
+		}
+		versionRange = append(versionRange, VersionConstraint{Operator: operator, Version: version})
+	}
+	return versionRange, nil
+}
+
+// Contains returns true if version satisfies all constraints of range.
+func (r VersionRange) Contains(version Version) bool {
+	for _, constraint := range r {
+		c := version.Compare(constraint.Version)
+
+		var ok bool
+		switch constraint.Operator {
+		case ">=":
+			ok = c >= 0
+		case "<=":
+			ok = c <= 0
+		case ">":
+			ok = c > 0
+		case "<":
+			ok = c < 0
+		case "!=":
+			ok = c != 0
+		default:
+			ok = c == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,66 @@
+package entities
+
+import (
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	cases := []struct {
+		Name          string
+		Input         string
+		Expected      Version
+		ExpectedError bool
+	}{
+		{Name: "OK, stable", Input: "6.48.6 (long-term)", Expected: Version{Numbers: [3]int{6, 48, 6}, Stage: StageFinal}},
+		{Name: "OK, short", Input: "7.1", Expected: Version{Numbers: [3]int{7, 1, 0}, Stage: StageFinal}},
+		{Name: "OK, release candidate", Input: "7.1rc4 (testing)", Expected: Version{Numbers: [3]int{7, 1, 0}, Stage: StageRC, StageNumber: 4}},
+		{Name: "OK, beta", Input: "v7.0beta2", Expected: Version{Numbers: [3]int{7, 0, 0}, Stage: StageBeta, StageNumber: 2}},
+		{Name: "Wrong, empty", Input: " ", ExpectedError: true},
+		{Name: "Wrong, format", Input: "seven", ExpectedError: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			version, err := ParseVersion(tc.Input)
+			if (err != nil) != tc.ExpectedError {
+				t.Fatalf("got:%v, expected error:%v", err, tc.ExpectedError)
+			}
+			if version != tc.Expected {
+				t.Errorf("got:%v, expected:%v", version, tc.Expected)
+			}
+		})
+	}
+}
+
+func TestVersionRangeContains(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Range    string
+		Version  string
+		Expected bool
+	}{
+		{Name: "Empty range", Range: "", Version: "6.40", Expected: true},
+		{Name: "Lower bound", Range: ">=6.41", Version: "6.41", Expected: true},
+		{Name: "Below lower bound", Range: ">=6.41", Version: "6.40.9", Expected: false},
+		{Name: "Release candidate below final", Range: ">=7.1", Version: "7.1rc4", Expected: false},
+		{Name: "Release candidate above former", Range: ">7.0", Version: "7.1rc4", Expected: true},
+		{Name: "Closed range", Range: ">=6.41, <7.0", Version: "6.48.6", Expected: true},
+		{Name: "Pre-release of upper bound", Range: ">=6.41 <7.0", Version: "7.0beta1", Expected: true},
+		{Name: "Exact", Range: "6.48.6", Version: "6.48.6", Expected: true},
+		{Name: "Excluded", Range: "!=6.48.6", Version: "6.48.6", Expected: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			versionRange, err := ParseVersionRange(tc.Range)
+			if err != nil {
+				t.Fatalf("not expected error %v", err)
+			}
+			version, err := ParseVersion(tc.Version)
+			if err != nil {
+				t.Fatalf("not expected error %v", err)
+			}
+			if versionRange.Contains(version) != tc.Expected {
+				t.Errorf("got:%v, expected:%v", !tc.Expected, tc.Expected)
+			}
+		})
+	}
+}
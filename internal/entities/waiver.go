@@ -0,0 +1,63 @@
+package entities
+
+import (
+	"fmt"
+	"path"
+	"time"
+)
+
+// WaiverDateLayout is layout of waiver's expiration date.
+const WaiverDateLayout = "2006-01-02"
+
+// Waiver accepts failure of rule on hosts matching pattern (all hosts if empty) until expiration date (forever if empty).
+type Waiver struct {
+	ID            string `toml:"id" yaml:"id"`
+	Host          string `toml:"host" yaml:"host"`
+	Expires       string `toml:"expires" yaml:"expires"`
+	Justification string `toml:"justification" yaml:"justification"`
+}
+
+// Validate verifies waiver's host pattern and expiration date.
+func (w Waiver) Validate() error {
+	if w.ID == "" {
+		return fmt.Errorf("waiver without id")
+	}
+	if _, err := path.Match(w.Host, ""); err != nil {
+		return fmt.Errorf("waiver %s of invalid host pattern %q: %v", w.ID, w.Host, err)
+	}
+	if w.Expires != "" {
+		if _, err := time.Parse(WaiverDateLayout, w.Expires); err != nil {
+			return fmt.Errorf("waiver %s of invalid expiration date %q: %v", w.ID, w.Expires, err)
+		}
+	}
+	return nil
+}
+
+// Applies returns true if waiver accepts failure of rule with given id on host at given time.
+func (w Waiver) Applies(id, host string, now time.Time) bool {
+	if w.ID != id {
+		return false
+	}
+	if w.Host != "" {
+		if matched, _ := path.Match(w.Host, host); !matched {
+			return false
+		}
+	}
+	if w.Expires != "" {
+		expires, err := time.Parse(WaiverDateLayout, w.Expires)
+		if err != nil || !now.Before(expires.AddDate(0, 0, 1)) {
+			return false
+		}
+	}
+	return true
+}
+
+// FindWaiver returns first waiver of list accepting failure of given id on host at given time.
+func FindWaiver(waivers []Waiver, id, host string, now time.Time) (Waiver, bool) {
+	for _, waiver := range waivers {
+		if waiver.Applies(id, host, now) {
+			return waiver, true
+		}
+	}
+	return Waiver{}, false
+}
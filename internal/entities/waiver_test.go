@@ -0,0 +1,31 @@
+package entities
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWaiverApplies(t *testing.T) {
+	now := time.Date(2020, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		Name     string
+		Waiver   Waiver
+		Host     string
+		Expected bool
+	}{
+		{Name: "All hosts", Waiver: Waiver{ID: "romon"}, Host: "10.0.0.1", Expected: true},
+		{Name: "Matching host", Waiver: Waiver{ID: "romon", Host: "10.0.0.*"}, Host: "10.0.0.1", Expected: true},
+		{Name: "Not matching host", Waiver: Waiver{ID: "romon", Host: "10.0.1.*"}, Host: "10.0.0.1", Expected: false},
+		{Name: "Other rule", Waiver: Waiver{ID: "upnp"}, Host: "10.0.0.1", Expected: false},
+		{Name: "Expires today", Waiver: Waiver{ID: "romon", Expires: "2020-03-15"}, Host: "10.0.0.1", Expected: true},
+		{Name: "Expired", Waiver: Waiver{ID: "romon", Expires: "2020-03-14"}, Host: "10.0.0.1", Expected: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if tc.Waiver.Applies("romon", tc.Host, now) != tc.Expected {
+				t.Errorf("got:%v, expected:%v", !tc.Expected, tc.Expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package inventory
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+// Formats of inventory export.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+var csvHeader = []string{
+	"host", "collected", "identity", "board_name", "model", "serial_number", "architecture",
+	"version", "firmware", "upgrade_firmware", "license_level", "software_id", "uptime",
+	"cpu", "cpu_count", "cpu_load", "free_memory", "total_memory", "interfaces", "addresses", "packages",
+}
+
+// Write writes inventory of devices in given format.
+func Write(w io.Writer, format string, list []entities.Facts) error {
+	switch format {
+	case FormatCSV:
+		return WriteCSV(w, list)
+	case FormatJSON:
+		return WriteJSON(w, list)
+	}
+	return fmt.Errorf("unknown inventory format %q", format)
+}
+
+// WriteJSON writes inventory of devices as JSON array of facts.
+func WriteJSON(w io.Writer, list []entities.Facts) error {
+	if list == nil {
+		list = []entities.Facts{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(list)
+}
+
+// WriteCSV writes inventory of devices as CSV, one row per device.
+// Interfaces, addresses and packages are flattened into `;` separated lists of `name=value` pairs.
+func WriteCSV(w io.Writer, list []entities.Facts) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, facts := range list {
+		var interfaces, addresses, packages []string
+		for _, iface := range facts.Interfaces {
+			interfaces = append(interfaces, iface.Name+"="+iface.MACAddress)
+			for _, address := range iface.Addresses {
+				addresses = append(addresses, iface.Name+"="+address)
+			}
+		}
+		for _, pkg := range facts.Packages {
+			packages = append(packages, pkg.Name+"="+pkg.Version)
+		}
+
+		err := writer.Write([]string{
+			facts.Host, facts.Collected.Format(time.RFC3339), facts.Identity, facts.BoardName, facts.Model, facts.SerialNumber, facts.Architecture,
+			facts.Version, facts.Firmware, facts.UpgradeFirmware, facts.LicenseLevel, facts.SoftwareID, facts.Uptime,
+			facts.CPU, facts.CPUCount, facts.CPULoad, facts.FreeMemory, facts.TotalMemory,
+			strings.Join(interfaces, ";"), strings.Join(addresses, ";"), strings.Join(packages, ";"),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
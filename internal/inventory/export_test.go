@@ -0,0 +1,74 @@
+package inventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+func TestWrite(t *testing.T) {
+	collected := time.Date(2020, 3, 15, 12, 0, 0, 0, time.UTC)
+	list := []entities.Facts{
+		{
+			Host:      "10.0.0.1",
+			Collected: collected,
+			Identity:  "core, router",
+			Version:   "7.1.1",
+			Interfaces: []entities.InterfaceFacts{
+				{Name: "ether1", MACAddress: "48:8F:5A:00:00:01", Addresses: []string{"10.0.0.1/24", "10.0.2.1/24"}},
+				{Name: "ether2", MACAddress: "48:8F:5A:00:00:02"},
+			},
+			Packages: []entities.PackageFacts{{Name: "routeros", Version: "7.1.1"}},
+		},
+	}
+
+	cases := []struct {
+		Name          string
+		Format        string
+		Expected      string
+		ExpectedError bool
+	}{
+		{
+			Name:   "CSV",
+			Format: FormatCSV,
+			Expected: "host,collected,identity,board_name,model,serial_number,architecture,version,firmware,upgrade_firmware,license_level,software_id,uptime,cpu,cpu_count,cpu_load,free_memory,total_memory,interfaces,addresses,packages\n" +
+				`10.0.0.1,2020-03-15T12:00:00Z,"core, router",,,,,7.1.1,,,,,,,,,,,ether1=48:8F:5A:00:00:01;ether2=48:8F:5A:00:00:02,ether1=10.0.0.1/24;ether1=10.0.2.1/24,routeros=7.1.1` + "\n",
+		},
+		{
+			Name:          "Wrong, unknown format",
+			Format:        "xml",
+			ExpectedError: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var output bytes.Buffer
+			err := Write(&output, tc.Format, list)
+			if (err != nil) != tc.ExpectedError {
+				t.Fatalf("got:%v, expected error:%v", err, tc.ExpectedError)
+			}
+			if output.String() != tc.Expected {
+				t.Errorf("got:%v, expected:%v", output.String(), tc.Expected)
+			}
+		})
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		var output bytes.Buffer
+		if err := Write(&output, FormatJSON, list); err != nil {
+			t.Fatalf("not expected error %v", err)
+		}
+
+		var decoded []entities.Facts
+		if err := json.Unmarshal(output.Bytes(), &decoded); err != nil {
+			t.Fatalf("not expected error %v", err)
+		}
+		if !reflect.DeepEqual(decoded, list) {
+			t.Errorf("got:%v, expected:%v", decoded, list)
+		}
+	})
+}
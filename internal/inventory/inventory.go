@@ -0,0 +1,84 @@
+// Package inventory keeps the latest facts collected from devices and exports them.
+package inventory
+
+import (
+	"bytes"
+	"encoding/gob"
+	"sort"
+	"strings"
+
+	"github.com/dgraph-io/badger"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+	"github.com/migotom/mt-bulk/internal/kvdb"
+)
+
+const kvTagFacts = "Facts:"
+
+// Inventory is a store of the latest facts collected from devices.
+type Inventory interface {
+	Get(host string) (entities.Facts, bool, error)
+	Store(entities.Facts) error
+	List() ([]entities.Facts, error)
+}
+
+// New returns inventory kept in MT-bulk database.
+func New(kv kvdb.KV) Inventory {
+	return &inventoryKV{kv: kv}
+}
+
+type inventoryKV struct {
+	kv kvdb.KV
+}
+
+func (i *inventoryKV) Get(host string) (facts entities.Facts, found bool, err error) {
+	err = i.kv.View(func(txn kvdb.Txn) error {
+		return txn.GetCopy(kvTagFacts+host, &facts)
+	})
+	if err == badger.ErrKeyNotFound {
+		return entities.Facts{}, false, nil
+	}
+	return facts, err == nil, err
+}
+
+func (i *inventoryKV) Store(facts entities.Facts) error {
+	txn := i.kv.NewTransaction()
+	defer txn.Discard()
+
+	if err := txn.Store(kvTagFacts+facts.Host, facts); err != nil {
+		return err
+	}
+	return txn.Commit()
+}
+
+// List returns facts of all devices sorted by host.
+func (i *inventoryKV) List() (list []entities.Facts, err error) {
+	err = i.kv.View(func(txn kvdb.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			if !strings.HasPrefix(string(item.KeyCopy(nil)), kvTagFacts) {
+				continue
+			}
+
+			value, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			var facts entities.Facts
+			if err = gob.NewDecoder(bytes.NewReader(value)).Decode(&facts); err != nil {
+				return err
+			}
+			list = append(list, facts)
+		}
+		return nil
+	})
+
+	sort.Slice(list, func(a, b int) bool {
+		return list[a].Host < list[b].Host
+	})
+	return
+}
@@ -16,6 +16,7 @@ type Txn interface {
 	Commit() error
 	GetCopy(key string, decoded interface{}) error
 	Store(key string, value interface{}) error
+	Delete(key string) error
 }
 
 type Iterator interface {
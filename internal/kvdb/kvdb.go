@@ -99,6 +99,11 @@ func (t *KVDBTxn) Store(key string, value interface{}) error {
 	return nil
 }
 
+// Delete removes key from badger database.
+func (t *KVDBTxn) Delete(key string) error {
+	return t.txn.Delete([]byte(key))
+}
+
 type KVDBIterator struct {
 	it *badger.Iterator
 }
@@ -66,6 +66,12 @@ func (txn *TxnMock) Store(key string, value interface{}) error {
 	return arg.Error(0)
 }
 
+// Delete implements Txn's Delete.
+func (txn *TxnMock) Delete(key string) error {
+	arg := txn.Called(key)
+	return arg.Error(0)
+}
+
 // IteratorMock implements Iterator.
 type IteratorMock struct {
 	Items []kvdb.Item
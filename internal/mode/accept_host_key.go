@@ -0,0 +1,40 @@
+package mode
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/migotom/mt-bulk/internal/clients"
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+// AcceptHostKey connects to device and stores its current SSH host key as trusted one.
+func AcceptHostKey(ctx context.Context, sugar *zap.SugaredLogger, client clients.Client, job *entities.Job) entities.Result {
+	results := make([]entities.CommandResult, 0, 2)
+
+	establishResult, err := clients.EstablishConnection(ctx, sugar, client, job)
+	results = append(results, establishResult)
+	if err != nil {
+		return entities.Result{Results: results, Errors: []error{err}}
+	}
+	defer client.Close()
+
+	config := client.GetConfig()
+	if config.KnownHosts == nil {
+		return entities.Result{Results: results, Errors: []error{fmt.Errorf("known hosts store not configured")}}
+	}
+
+	host := clients.KnownHostAddress(job.Host.IP, job.Host.Port)
+	knownHost, found, err := config.KnownHosts.Get(host)
+	if err != nil || !found {
+		return entities.Result{Results: results, Errors: []error{fmt.Errorf("host key of %s not stored: %v", host, err)}}
+	}
+
+	results = append(results, entities.CommandResult{
+		Body:      "/<mt-bulk>accept host key",
+		Responses: []string{fmt.Sprintf("accepted host key %s", knownHost)},
+	})
+	return entities.Result{Results: results}
+}
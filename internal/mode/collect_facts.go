@@ -0,0 +1,144 @@
+package mode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/migotom/mt-bulk/internal/clients"
+	"github.com/migotom/mt-bulk/internal/console"
+	"github.com/migotom/mt-bulk/internal/entities"
+	"github.com/migotom/mt-bulk/internal/inventory"
+)
+
+var factsCommands = []entities.Command{
+	{Body: "/system identity print", Parse: console.FormatPrint},
+	{Body: "/system resource print", Parse: console.FormatPrint},
+	{Body: "/system routerboard print", Parse: console.FormatPrint},
+	{Body: "/system license print", Parse: console.FormatPrint},
+	{Body: "/interface print terse without-paging", Parse: console.FormatTerse},
+	{Body: "/ip address print terse without-paging", Parse: console.FormatTerse},
+	{Body: "/system package print terse without-paging", Parse: console.FormatTerse},
+}
+
+// CollectFacts is an operation collecting inventory of device's hardware and software, e.g. identity, versions, interfaces and packages.
+// Collected facts are returned as job's result and replace latest facts of device kept in inventory.
+func CollectFacts(store inventory.Inventory) OperationModeFunc {
+	return func(ctx context.Context, sugar *zap.SugaredLogger, client clients.Client, job *entities.Job) entities.Result {
+		results := make([]entities.CommandResult, 0, 2+len(factsCommands))
+
+		establishResult, err := clients.EstablishConnection(ctx, sugar, client, job)
+		results = append(results, establishResult)
+		if err != nil {
+			return entities.Result{Results: results, Errors: []error{err}}
+		}
+		defer client.Close()
+
+		commandResults, _, err := clients.ExecuteCommands(ctx, client, factsCommands)
+		results = append(results, commandResults...)
+		if err != nil {
+			return entities.Result{Results: results, Errors: []error{fmt.Errorf("executing CollectFacts commands error %v", err)}}
+		}
+
+		facts := collectFacts(job.Host.IP, commandResults)
+		if facts.Version == "" {
+			return entities.Result{Results: results, Errors: []error{errors.New("Mikrotik version not recognized")}}
+		}
+
+		document, err := json.MarshalIndent(facts, "", "  ")
+		if err != nil {
+			return entities.Result{Results: results, Facts: &facts, Errors: []error{fmt.Errorf("can't encode facts: %v", err)}}
+		}
+		results = append(results, entities.CommandResult{Body: "/<mt-bulk>facts", Responses: []string{string(document)}})
+
+		if err := store.Store(facts); err != nil {
+			return entities.Result{Results: results, Facts: &facts, Errors: []error{fmt.Errorf("can't store facts: %v", err)}}
+		}
+		return entities.Result{Results: results, Facts: &facts}
+	}
+}
+
+// collectFacts builds facts from records parsed out of factsCommands' results.
+func collectFacts(host string, commandResults []entities.CommandResult) entities.Facts {
+	records := make(map[string][]map[string]string, len(commandResults))
+	for _, result := range commandResults {
+		records[result.Body] = result.Records
+	}
+	settings := func(body string) map[string]string {
+		if len(records[body]) == 0 {
+			return map[string]string{}
+		}
+		return records[body][0]
+	}
+
+	identity := settings("/system identity print")
+	resource := settings("/system resource print")
+	routerboard := settings("/system routerboard print")
+	license := settings("/system license print")
+
+	facts := entities.Facts{
+		Host:            host,
+		Collected:       time.Now(),
+		Identity:        identity["name"],
+		BoardName:       resource["board-name"],
+		Model:           routerboard["model"],
+		SerialNumber:    routerboard["serial-number"],
+		Architecture:    resource["architecture-name"],
+		Version:         firstWord(resource["version"]),
+		Firmware:        routerboard["current-firmware"],
+		UpgradeFirmware: routerboard["upgrade-firmware"],
+		LicenseLevel:    license["level"],
+		SoftwareID:      license["software-id"],
+		Uptime:          resource["uptime"],
+		CPU:             resource["cpu"],
+		CPUCount:        resource["cpu-count"],
+		CPULoad:         resource["cpu-load"],
+		FreeMemory:      resource["free-memory"],
+		TotalMemory:     resource["total-memory"],
+	}
+	// RouterOS v6 prints license level of RouterBOARDs as nlevel
+	if facts.LicenseLevel == "" {
+		facts.LicenseLevel = license["nlevel"]
+	}
+
+	addresses := make(map[string][]string)
+	for _, record := range records["/ip address print terse without-paging"] {
+		if strings.Contains(record[console.KeyFlags], "X") {
+			continue
+		}
+		addresses[record["interface"]] = append(addresses[record["interface"]], record["address"])
+	}
+
+	for _, record := range records["/interface print terse without-paging"] {
+		facts.Interfaces = append(facts.Interfaces, entities.InterfaceFacts{
+			Name:       record["name"],
+			Type:       record["type"],
+			MACAddress: record["mac-address"],
+			Addresses:  addresses[record["name"]],
+			Running:    strings.Contains(record[console.KeyFlags], "R"),
+			Disabled:   strings.Contains(record[console.KeyFlags], "X"),
+		})
+	}
+
+	for _, record := range records["/system package print terse without-paging"] {
+		facts.Packages = append(facts.Packages, entities.PackageFacts{
+			Name:     record["name"],
+			Version:  record["version"],
+			Disabled: strings.Contains(record[console.KeyFlags], "X") || record["disabled"] == "yes",
+		})
+	}
+	return facts
+}
+
+// firstWord returns first word of value, e.g. version without release channel.
+func firstWord(value string) string {
+	if fields := strings.Fields(value); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
@@ -0,0 +1,147 @@
+package mode
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/migotom/mt-bulk/internal/clients/mocks"
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+type factsClient struct {
+	mocks.Client
+
+	outputs map[string]string
+}
+
+func (c factsClient) RunCmd(val string, re *regexp.Regexp) (string, error) {
+	return c.outputs[val], nil
+}
+
+type inventoryMap map[string]entities.Facts
+
+func (i inventoryMap) Get(host string) (entities.Facts, bool, error) {
+	facts, found := i[host]
+	return facts, found, nil
+}
+
+func (i inventoryMap) Store(facts entities.Facts) error {
+	i[facts.Host] = facts
+	return nil
+}
+
+func (i inventoryMap) List() (list []entities.Facts, err error) {
+	for _, facts := range i {
+		list = append(list, facts)
+	}
+	return
+}
+
+func TestCollectFacts(t *testing.T) {
+	outputs := map[string]string{
+		"/system identity print": "  name: core-router\r\n",
+		"/system resource print": "                   uptime: 1w2d3h4m5s\r\n" +
+			"                  version: 7.1.1 (stable)\r\n" +
+			"              free-memory: 44.2MiB\r\n" +
+			"             total-memory: 128.0MiB\r\n" +
+			"                      cpu: ARMv7\r\n" +
+			"                cpu-count: 4\r\n" +
+			"                 cpu-load: 3%\r\n" +
+			"        architecture-name: arm\r\n" +
+			"               board-name: hAP ac^2\r\n",
+		"/system routerboard print": "       routerboard: yes\r\n" +
+			"             model: RBD52G-5HacD2HnD\r\n" +
+			"     serial-number: D4F00A1B2C3D\r\n" +
+			"  current-firmware: 7.1.1\r\n" +
+			"  upgrade-firmware: 7.1.5\r\n",
+		"/system license print": "  software-id: ABCD-1234\r\n       nlevel: 4\r\n",
+		"/interface print terse without-paging": " 0  R name=ether1 default-name=ether1 type=ether mtu=1500 mac-address=48:8F:5A:00:00:01\r\n" +
+			" 1 X  name=ether2 default-name=ether2 type=ether mtu=1500 mac-address=48:8F:5A:00:00:02\r\n",
+		"/ip address print terse without-paging": " 0   address=10.0.0.1/24 network=10.0.0.0 interface=ether1\r\n" +
+			" 1 X address=10.0.1.1/24 network=10.0.1.0 interface=ether2\r\n",
+		"/system package print terse without-paging": " 0   name=routeros version=7.1.1\r\n" +
+			" 1 X name=wireless version=7.1.1\r\n",
+	}
+
+	cases := []struct {
+		Name          string
+		Outputs       map[string]string
+		Expected      *entities.Facts
+		ExpectedError bool
+	}{
+		{
+			Name:    "OK",
+			Outputs: outputs,
+			Expected: &entities.Facts{
+				Host:            "10.0.0.1",
+				Identity:        "core-router",
+				BoardName:       "hAP ac^2",
+				Model:           "RBD52G-5HacD2HnD",
+				SerialNumber:    "D4F00A1B2C3D",
+				Architecture:    "arm",
+				Version:         "7.1.1",
+				Firmware:        "7.1.1",
+				UpgradeFirmware: "7.1.5",
+				LicenseLevel:    "4",
+				SoftwareID:      "ABCD-1234",
+				Uptime:          "1w2d3h4m5s",
+				CPU:             "ARMv7",
+				CPUCount:        "4",
+				CPULoad:         "3%",
+				FreeMemory:      "44.2MiB",
+				TotalMemory:     "128.0MiB",
+				Interfaces: []entities.InterfaceFacts{
+					{Name: "ether1", Type: "ether", MACAddress: "48:8F:5A:00:00:01", Addresses: []string{"10.0.0.1/24"}, Running: true},
+					{Name: "ether2", Type: "ether", MACAddress: "48:8F:5A:00:00:02", Disabled: true},
+				},
+				Packages: []entities.PackageFacts{
+					{Name: "routeros", Version: "7.1.1"},
+					{Name: "wireless", Version: "7.1.1", Disabled: true},
+				},
+			},
+		},
+		{
+			Name:          "Wrong, version not recognized",
+			Outputs:       map[string]string{},
+			ExpectedError: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			store := inventoryMap{}
+			job := entities.Job{Host: entities.Host{IP: "10.0.0.1", Password: "secret"}}
+
+			result := CollectFacts(store)(context.Background(), zap.NewExample().Sugar(), factsClient{outputs: tc.Outputs}, &job)
+			if (len(result.Errors) > 0) != tc.ExpectedError {
+				t.Fatalf("got:%v, expected error:%v", result.Errors, tc.ExpectedError)
+			}
+			if tc.ExpectedError {
+				if len(store) > 0 {
+					t.Errorf("facts stored despite error: %v", store)
+				}
+				return
+			}
+
+			if result.Facts == nil {
+				t.Fatalf("got:nil, expected:%v", tc.Expected)
+			}
+			if result.Facts.Collected.IsZero() {
+				t.Errorf("collection time not set")
+			}
+			result.Facts.Collected = tc.Expected.Collected
+			if !reflect.DeepEqual(result.Facts, tc.Expected) {
+				t.Errorf("got:%+v, expected:%+v", result.Facts, tc.Expected)
+			}
+
+			stored := store["10.0.0.1"]
+			stored.Collected = tc.Expected.Collected
+			if !reflect.DeepEqual(&stored, tc.Expected) {
+				t.Errorf("got:%+v, expected stored:%+v", stored, tc.Expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package mode
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/migotom/mt-bulk/internal/clients"
+	"github.com/migotom/mt-bulk/internal/entities"
+	"github.com/migotom/mt-bulk/internal/rules"
+)
+
+// Compliance verifies device against job's rules describing desired state of configuration.
+// Remediation commands of failed rules are executed only if job's `fix` is enabled, afterwards rule is verified again.
+func Compliance(ctx context.Context, sugar *zap.SugaredLogger, client clients.Client, job *entities.Job) entities.Result {
+	if len(job.Rules) == 0 {
+		return entities.Result{Errors: []error{fmt.Errorf("no compliance rules specified")}}
+	}
+	if err := rules.Validate(job.Rules); err != nil {
+		return entities.Result{Errors: []error{err}}
+	}
+	fix := job.Data["fix"] == "true" || job.Data["fix"] == "yes"
+
+	results := make([]entities.CommandResult, 0, 1+2*len(job.Rules))
+
+	establishResult, err := clients.EstablishConnection(ctx, sugar, client, job)
+	results = append(results, establishResult)
+	if err != nil {
+		return entities.Result{Results: results, Errors: []error{err}}
+	}
+	defer client.Close()
+
+	ruleResults := make([]entities.RuleResult, 0, len(job.Rules))
+	var failed []string
+	for _, rule := range job.Rules {
+		ruleResult, commandResults := rules.Evaluate(ctx, client, rule)
+		results = append(results, commandResults...)
+
+		if !ruleResult.Passed && ruleResult.Error == "" && fix && len(rule.Remediation) > 0 {
+			remediationResults, _, err := clients.ExecuteCommands(ctx, client, rules.Remediation(rule, ruleResult.Evidence))
+			results = append(results, remediationResults...)
+			if err != nil {
+				ruleResult.Error = fmt.Sprintf("remediation error %v", err)
+			} else {
+				ruleResult, commandResults = rules.Evaluate(ctx, client, rule)
+				results = append(results, commandResults...)
+				ruleResult.Fixed = ruleResult.Passed
+			}
+		}
+
+		results = append(results, entities.CommandResult{
+			Body:      fmt.Sprintf("/<mt-bulk>rule %s", rule.ID),
+			Responses: []string{ruleResultSummary(ruleResult)},
+		})
+		ruleResults = append(ruleResults, ruleResult)
+		if !ruleResult.Passed {
+			failed = append(failed, rule.ID)
+		}
+
+		if ctx.Err() != nil {
+			return entities.Result{Results: results, Rules: ruleResults, Errors: []error{fmt.Errorf("executing Compliance rules interrupted")}}
+		}
+	}
+
+	if len(failed) > 0 {
+		return entities.Result{Results: results, Rules: ruleResults, Errors: []error{fmt.Errorf("not compliant with rules %s", strings.Join(failed, ", "))}}
+	}
+	return entities.Result{Results: results, Rules: ruleResults}
+}
+
+// ruleResultSummary returns single line summary of rule's result, e.g. `FAIL [high] ntp-servers: NTP servers configured (evidence: ...)`.
+func ruleResultSummary(result entities.RuleResult) string {
+	status := "FAIL"
+	switch {
+	case result.Fixed:
+		status = "FIXED"
+	case result.Passed:
+		status = "PASS"
+	case result.Error != "":
+		status = "ERROR"
+	}
+
+	summary := fmt.Sprintf("%s [%s] %s", status, result.Severity, result.ID)
+	if result.Description != "" {
+		summary += ": " + result.Description
+	}
+	if result.Error != "" {
+		return summary + fmt.Sprintf(" (error: %s)", result.Error)
+	}
+	return summary + fmt.Sprintf(" (evidence: %s)", strings.Join(result.Evidence, "; "))
+}
@@ -0,0 +1,75 @@
+package mode
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/migotom/mt-bulk/internal/clients/mocks"
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+// settingsClient mocks device printing SSH settings changed by remediation commands.
+type settingsClient struct {
+	mocks.Client
+
+	strongCrypto string
+}
+
+func (c *settingsClient) RunCmd(val string, re *regexp.Regexp) (string, error) {
+	switch val {
+	case "/ip ssh print":
+		return "  strong-crypto: " + c.strongCrypto + "\r\n", nil
+	case "/ip ssh set strong-crypto=yes":
+		c.strongCrypto = "yes"
+	}
+	return val, nil
+}
+
+func TestCompliance(t *testing.T) {
+	rules := []entities.Rule{
+		{ID: "ssh-strong-crypto", Severity: "high", Command: "/ip ssh print", Parse: "print", Match: "record:strong-crypto where strong-crypto=yes", Remediation: []entities.Command{{Body: "/ip ssh set strong-crypto=yes"}}},
+	}
+
+	cases := []struct {
+		Name          string
+		StrongCrypto  string
+		Fix           string
+		Expected      []entities.RuleResult
+		ExpectedError bool
+	}{
+		{
+			Name:         "OK, compliant",
+			StrongCrypto: "yes",
+			Expected:     []entities.RuleResult{{ID: "ssh-strong-crypto", Severity: "high", Passed: true, Evidence: []string{"yes"}}},
+		},
+		{
+			Name:          "Wrong, not compliant",
+			StrongCrypto:  "no",
+			Expected:      []entities.RuleResult{{ID: "ssh-strong-crypto", Severity: "high", Message: "ssh-strong-crypto", Evidence: []string{`"record:strong-crypto where strong-crypto=yes" not found in output of /ip ssh print`}}},
+			ExpectedError: true,
+		},
+		{
+			Name:         "OK, fixed",
+			StrongCrypto: "no",
+			Fix:          "true",
+			Expected:     []entities.RuleResult{{ID: "ssh-strong-crypto", Severity: "high", Passed: true, Fixed: true, Evidence: []string{"yes"}}},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			job := entities.Job{Host: entities.Host{Password: "secret"}, Rules: rules, Data: map[string]string{"fix": tc.Fix}}
+
+			result := Compliance(context.Background(), zap.NewExample().Sugar(), &settingsClient{strongCrypto: tc.StrongCrypto}, &job)
+			if !reflect.DeepEqual(result.Rules, tc.Expected) {
+				t.Errorf("got:%v, expected:%v", result.Rules, tc.Expected)
+			}
+			if (len(result.Errors) > 0) != tc.ExpectedError {
+				t.Errorf("got:%v, expected error:%v", result.Errors, tc.ExpectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package mode
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"go.uber.org/zap"
+
+	"github.com/migotom/mt-bulk/internal/backups"
+	"github.com/migotom/mt-bulk/internal/clients"
+	"github.com/migotom/mt-bulk/internal/console"
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+// ConfigDiff exports configuration of device and compares it with baseline, reports unified diff of configuration drift.
+// Baseline is updated by current configuration, unless it is taken from backups store.
+func ConfigDiff(baselines backups.Baselines) OperationModeFunc {
+	return func(ctx context.Context, sugar *zap.SugaredLogger, client clients.Client, job *entities.Job) entities.Result {
+		body := "/export"
+		if hideSensitive := job.Data["hide_sensitive"]; hideSensitive == "true" || hideSensitive == "yes" {
+			body += " hide-sensitive"
+		}
+
+		// echo of export command is not a part of configuration
+		ignore := []*regexp.Regexp{regexp.MustCompile("^" + regexp.QuoteMeta(body) + "$")}
+		if pattern, ok := job.Data["ignore"]; ok && pattern != "" {
+			rx, err := regexp.Compile(pattern)
+			if err != nil {
+				return entities.Result{Errors: []error{fmt.Errorf("invalid ignore expression: %v", err)}}
+			}
+			ignore = append(ignore, rx)
+		}
+
+		results := make([]entities.CommandResult, 0, 3)
+
+		establishResult, err := clients.EstablishConnection(ctx, sugar, client, job)
+		results = append(results, establishResult)
+		if err != nil {
+			return entities.Result{Results: results, Errors: []error{err}}
+		}
+		defer client.Close()
+
+		commandResults, _, err := clients.ExecuteCommands(ctx, client, []entities.Command{{Body: body}})
+		results = append(results, commandResults...)
+		if err != nil {
+			return entities.Result{Results: results, Errors: []error{fmt.Errorf("executing ConfigDiff commands error %v", err)}}
+		}
+		export := strings.Join(commandResults[0].Responses, "\n")
+
+		baseline, found, err := baselines.Get(job.Host.IP)
+		if err != nil {
+			return entities.Result{Results: results, Errors: []error{fmt.Errorf("can't read configuration baseline: %v", err)}}
+		}
+
+		diffResult := entities.CommandResult{Body: "/<mt-bulk>diff"}
+		var drift bool
+		if !found {
+			diffResult.Responses = append(diffResult.Responses, "no configuration baseline, current configuration stored as baseline")
+		} else {
+			diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+				A:        withNewLines(console.NormalizeExport(baseline.Export, ignore...)),
+				B:        withNewLines(console.NormalizeExport(export, ignore...)),
+				FromFile: "baseline",
+				FromDate: baseline.Created.Format(time.RFC3339),
+				ToFile:   "current",
+				ToDate:   time.Now().Format(time.RFC3339),
+				Context:  3,
+			})
+			if err != nil {
+				return entities.Result{Results: results, Errors: []error{fmt.Errorf("can't compare configuration: %v", err)}}
+			}
+
+			drift = diff != ""
+			if drift {
+				diffResult.Responses = append(diffResult.Responses, diff)
+			} else {
+				diffResult.Responses = append(diffResult.Responses, fmt.Sprintf("no configuration drift since %s", baseline.Created.Format(time.RFC3339)))
+			}
+		}
+		results = append(results, diffResult)
+
+		if err := baselines.Store(backups.Baseline{Host: job.Host.IP, Export: export, Created: time.Now()}); err != nil {
+			return entities.Result{Results: results, Drift: drift, Errors: []error{fmt.Errorf("can't store configuration baseline: %v", err)}}
+		}
+
+		if drift {
+			return entities.Result{Results: results, Drift: drift, Errors: []error{fmt.Errorf("configuration drift detected since %s", baseline.Created.Format(time.RFC3339))}}
+		}
+		return entities.Result{Results: results}
+	}
+}
+
+func withNewLines(lines []string) []string {
+	for idx := range lines {
+		lines[idx] += "\n"
+	}
+	return lines
+}
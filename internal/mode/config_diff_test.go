@@ -0,0 +1,100 @@
+package mode
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/migotom/mt-bulk/internal/backups"
+	"github.com/migotom/mt-bulk/internal/clients/mocks"
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+type exportClient struct {
+	mocks.Client
+
+	export string
+}
+
+func (c exportClient) RunCmd(val string, re *regexp.Regexp) (string, error) {
+	return c.export, nil
+}
+
+type baselinesMap map[string]backups.Baseline
+
+func (b baselinesMap) Get(host string) (backups.Baseline, bool, error) {
+	baseline, found := b[host]
+	return baseline, found, nil
+}
+
+func (b baselinesMap) Store(baseline backups.Baseline) error {
+	b[baseline.Host] = baseline
+	return nil
+}
+
+func TestConfigDiff(t *testing.T) {
+	baseline := "# mar/15/2020 12:00:00 by RouterOS 6.48.1\n/system identity\nset name=router\n/system clock\nset time-zone-name=UTC\n"
+
+	cases := []struct {
+		Name          string
+		Baseline      string
+		Export        string
+		Ignore        string
+		ExpectedDiff  string
+		ExpectedDrift bool
+	}{
+		{
+			Name:         "No baseline",
+			Export:       baseline,
+			ExpectedDiff: "no configuration baseline, current configuration stored as baseline",
+		},
+		{
+			Name:         "No drift",
+			Baseline:     baseline,
+			Export:       strings.Replace(baseline, "mar/15/2020 12:00:00", "mar/16/2020 13:00:00", 1),
+			ExpectedDiff: "no configuration drift",
+		},
+		{
+			Name:          "Drift",
+			Baseline:      baseline,
+			Export:        strings.Replace(baseline, "name=router", "name=core", 1),
+			ExpectedDiff:  "-set name=router\n+set name=core\n",
+			ExpectedDrift: true,
+		},
+		{
+			Name:         "Ignored drift",
+			Baseline:     baseline,
+			Export:       strings.Replace(baseline, "UTC", "Europe/Warsaw", 1),
+			Ignore:       "^set time-zone-name=",
+			ExpectedDiff: "no configuration drift",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			baselines := baselinesMap{}
+			if tc.Baseline != "" {
+				baselines["10.0.0.1"] = backups.Baseline{Host: "10.0.0.1", Export: tc.Baseline, Created: time.Now()}
+			}
+			job := entities.Job{Host: entities.Host{IP: "10.0.0.1", Password: "secret"}, Data: map[string]string{"ignore": tc.Ignore}}
+
+			result := ConfigDiff(baselines)(context.Background(), zap.NewExample().Sugar(), exportClient{export: tc.Export}, &job)
+
+			if result.Drift != tc.ExpectedDrift || (len(result.Errors) > 0) != tc.ExpectedDrift {
+				t.Errorf("got:%v %v, expected:%v", result.Drift, result.Errors, tc.ExpectedDrift)
+			}
+
+			diff := result.Results[len(result.Results)-1]
+			if diff.Body != "/<mt-bulk>diff" || !strings.Contains(strings.Join(diff.Responses, "\n"), tc.ExpectedDiff) {
+				t.Errorf("got:%v, expected:%v", diff.Responses, tc.ExpectedDiff)
+			}
+
+			if baselines["10.0.0.1"].Export != tc.Export {
+				t.Errorf("baseline not updated, got:%v, expected:%v", baselines["10.0.0.1"].Export, tc.Export)
+			}
+		})
+	}
+}
@@ -9,7 +9,7 @@ import (
 	"go.uber.org/zap"
 )
 
-// Custom executes by client custom job.
+// Custom executes by client custom job, commands of template flag are rendered as templates against host's variables, job's data and matches of executed commands.
 func Custom(ctx context.Context, sugar *zap.SugaredLogger, client clients.Client, job *entities.Job) entities.Result {
 	results := make([]entities.CommandResult, 0, 8)
 
@@ -20,7 +20,7 @@ func Custom(ctx context.Context, sugar *zap.SugaredLogger, client clients.Client
 	}
 	defer client.Close()
 
-	commandResults, _, err := clients.ExecuteCommands(ctx, client, job.Commands)
+	commandResults, _, err := clients.ExecuteCommandTemplates(ctx, client, job.Commands, clients.NewTemplateData(job))
 	results = append(results, commandResults...)
 	if err != nil {
 		return entities.Result{Results: results, Errors: []error{fmt.Errorf("executing custom commands error %v", err)}}
@@ -10,6 +10,30 @@ import (
 	"go.uber.org/zap"
 )
 
+func TestCustomRecords(t *testing.T) {
+	records := []map[string]string{
+		{".id": "*1", "name": "ether1"},
+		{".id": "*2", "name": "ether2"},
+	}
+	job := entities.Job{Host: entities.Host{Password: "old"}, Commands: []entities.Command{
+		{Body: "/interface/print", MatchPrefix: "i", Match: "record:.id where name=ether2"},
+		{Body: "/interface/set =.id=%{i1} =disabled=yes"},
+	}}
+	expected := entities.Result{Results: []entities.CommandResult{
+		{Body: "/<mt-bulk>establish connection", Responses: []string{"/<mt-bulk>establish connection", " --> attempt #0, password #0, job #"}},
+		{Body: "/interface/print", Records: records, Responses: []string{
+			"/interface/print",
+			`/<mt-bulk:record> "record:.id where name=ether2" set key "%{i1}" with value *2`,
+		}},
+		{Body: "/interface/set =.id=%{i1} =disabled=yes", Records: records, Responses: []string{"/interface/set =.id=*2 =disabled=yes"}},
+	}}
+
+	result := Custom(context.Background(), zap.NewExample().Sugar(), mocks.RecordsClient{Records: records}, &job)
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("got:%v, expected:%v", result, expected)
+	}
+}
+
 func TestCustom(t *testing.T) {
 	cases := []struct {
 		Name     string
@@ -0,0 +1,119 @@
+package mode
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/migotom/mt-bulk/internal/clients"
+	"github.com/migotom/mt-bulk/internal/console"
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+// Routing neighbors are printed by different menus on RouterOS v6 and v7, commands not supported by device return no records.
+var discoverCommands = []entities.Command{
+	{Body: "/system identity print", Parse: console.FormatPrint},
+	{Body: "/ip neighbor print detail without-paging", Parse: console.FormatDetail},
+	{Body: "/routing ospf neighbor print detail without-paging", Parse: console.FormatDetail},
+	{Body: "/routing bgp peer print detail without-paging", Parse: console.FormatDetail},
+	{Body: "/routing bgp session print detail without-paging", Parse: console.FormatDetail},
+}
+
+// Discover is an operation reading device's neighbor table (MNDP, CDP, LLDP) and routing neighbors (OSPF, BGP).
+// Found neighbors are returned as job's result to let crawler visit next devices.
+func Discover(ctx context.Context, sugar *zap.SugaredLogger, client clients.Client, job *entities.Job) entities.Result {
+	results := make([]entities.CommandResult, 0, 2+len(discoverCommands))
+
+	establishResult, err := clients.EstablishConnection(ctx, sugar, client, job)
+	results = append(results, establishResult)
+	if err != nil {
+		return entities.Result{Results: results, Errors: []error{err}}
+	}
+	defer client.Close()
+
+	commandResults, _, err := clients.ExecuteCommands(ctx, client, discoverCommands)
+	results = append(results, commandResults...)
+	if err != nil {
+		return entities.Result{Results: results, Errors: []error{fmt.Errorf("executing Discover commands error %v", err)}}
+	}
+
+	discovery := discoverNeighbors(commandResults)
+
+	neighborsResult := entities.CommandResult{Body: "/<mt-bulk>neighbors"}
+	for _, neighbor := range discovery.Neighbors {
+		neighborsResult.Responses = append(neighborsResult.Responses, neighbor.String())
+	}
+	if len(discovery.Neighbors) == 0 {
+		neighborsResult.Responses = append(neighborsResult.Responses, "no neighbors found")
+	}
+	results = append(results, neighborsResult)
+
+	return entities.Result{Results: results, Discovery: &discovery}
+}
+
+// discoverNeighbors builds list of neighbors from records parsed out of discoverCommands' results.
+func discoverNeighbors(commandResults []entities.CommandResult) (discovery entities.Discovery) {
+	records := make(map[string][]map[string]string, len(commandResults))
+	for _, result := range commandResults {
+		records[result.Body] = result.Records
+	}
+
+	if identity := records["/system identity print"]; len(identity) > 0 {
+		discovery.Identity = identity[0]["name"]
+	}
+
+	for _, record := range records["/ip neighbor print detail without-paging"] {
+		address := record["address"]
+		if address == "" {
+			address = record["address4"]
+		}
+		if net.ParseIP(address) == nil && record["mac-address"] == "" {
+			continue
+		}
+
+		// RouterOS v6 doesn't report protocol the neighbor was discovered by
+		protocol := "neighbor"
+		if discoveredBy := record["discovered-by"]; discoveredBy != "" {
+			protocol = discoveredBy
+		}
+
+		discovery.Neighbors = append(discovery.Neighbors, entities.Neighbor{
+			Address:    validIP(address),
+			MACAddress: record["mac-address"],
+			Identity:   record["identity"],
+			Platform:   record["platform"],
+			Version:    firstWord(record["version"]),
+			Board:      record["board"],
+			Interface:  strings.Split(record["interface"], ",")[0],
+			Protocol:   protocol,
+		})
+	}
+
+	for _, record := range records["/routing ospf neighbor print detail without-paging"] {
+		if address := validIP(record["address"]); address != "" {
+			discovery.Neighbors = append(discovery.Neighbors, entities.Neighbor{Address: address, Interface: record["interface"], Protocol: "ospf"})
+		}
+	}
+
+	for _, record := range append(records["/routing bgp peer print detail without-paging"], records["/routing bgp session print detail without-paging"]...) {
+		address := record["remote-address"]
+		if address == "" {
+			address = record["remote.address"]
+		}
+		if address = validIP(address); address != "" {
+			discovery.Neighbors = append(discovery.Neighbors, entities.Neighbor{Address: address, Protocol: "bgp"})
+		}
+	}
+	return
+}
+
+// validIP returns address if it is valid IP address, otherwise empty string.
+func validIP(address string) string {
+	if net.ParseIP(address) == nil {
+		return ""
+	}
+	return address
+}
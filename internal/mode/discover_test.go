@@ -0,0 +1,66 @@
+package mode
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+func TestDiscover(t *testing.T) {
+	badCommand := "bad command name peer (line 1 column 15)\r\n"
+
+	cases := []struct {
+		Name     string
+		Outputs  map[string]string
+		Expected *entities.Discovery
+	}{
+		{
+			Name: "RouterOS v7",
+			Outputs: map[string]string{
+				"/system identity print": "  name: core-router\r\n",
+				"/ip neighbor print detail without-paging": " 0 interface=ether1 address=10.0.0.2 mac-address=48:8F:5A:00:00:02 identity=\"edge router\" platform=MikroTik \r\n" +
+					"   version=\"7.1.1 (stable)\" board=RB4011iGS+ discovered-by=mndp\r\n\r\n" +
+					" 1 interface=ether2,bridge address=10.0.1.5 mac-address=00:1B:54:00:00:05 identity=switch platform=\"cisco WS-C2960\" discovered-by=cdp,lldp\r\n\r\n" +
+					" 2 interface=ether3 mac-address=00:1B:54:00:00:06 identity=ap discovered-by=lldp\r\n",
+				"/routing ospf neighbor print detail without-paging": " 0 instance=default-v2 address=10.0.2.1 interface=ether4 router-id=10.255.0.3 state=\"Full\"\r\n",
+				"/routing bgp peer print detail without-paging":      badCommand,
+				"/routing bgp session print detail without-paging":   " 0 E name=\"upstream-1\" remote.address=192.0.2.1 .as=65001 local.role=ebgp\r\n",
+			},
+			Expected: &entities.Discovery{
+				Identity: "core-router",
+				Neighbors: []entities.Neighbor{
+					{Address: "10.0.0.2", MACAddress: "48:8F:5A:00:00:02", Identity: "edge router", Platform: "MikroTik", Version: "7.1.1", Board: "RB4011iGS+", Interface: "ether1", Protocol: "mndp"},
+					{Address: "10.0.1.5", MACAddress: "00:1B:54:00:00:05", Identity: "switch", Platform: "cisco WS-C2960", Interface: "ether2", Protocol: "cdp,lldp"},
+					{MACAddress: "00:1B:54:00:00:06", Identity: "ap", Interface: "ether3", Protocol: "lldp"},
+					{Address: "10.0.2.1", Interface: "ether4", Protocol: "ospf"},
+					{Address: "192.0.2.1", Protocol: "bgp"},
+				},
+			},
+		},
+		{
+			Name: "RouterOS v6 without neighbors",
+			Outputs: map[string]string{
+				"/system identity print":                           "  name: lab\r\n",
+				"/routing bgp session print detail without-paging": badCommand,
+			},
+			Expected: &entities.Discovery{Identity: "lab"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			job := entities.Job{Host: entities.Host{IP: "10.0.0.1", Password: "secret"}}
+
+			result := Discover(context.Background(), zap.NewExample().Sugar(), factsClient{outputs: tc.Outputs}, &job)
+			if len(result.Errors) > 0 {
+				t.Fatalf("not expected error %v", result.Errors)
+			}
+			if !reflect.DeepEqual(result.Discovery, tc.Expected) {
+				t.Errorf("got:%+v, expected:%+v", result.Discovery, tc.Expected)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package mode
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 
 	"github.com/migotom/mt-bulk/internal/clients"
@@ -11,65 +12,92 @@ import (
 )
 
 // InitSecureAPI initializes Mikrotik secure API using SSH client.
-func InitSecureAPI(ctx context.Context, sugar *zap.SugaredLogger, client clients.Client, job *entities.Job) entities.Result {
-	certificatesDirectory, ok := job.Data["keys_directory"]
-	if !ok || certificatesDirectory == "" {
-		return entities.Result{Errors: []error{fmt.Errorf("keys_directory not specified")}}
-	}
+// Each device gets its own certificate signed by MT-bulk CA and recorded in given registry.
+func InitSecureAPI(deviceCertificates clients.DeviceCertificates) OperationModeFunc {
+	return func(ctx context.Context, sugar *zap.SugaredLogger, client clients.Client, job *entities.Job) entities.Result {
+		certificatesDirectory, ok := job.Data["keys_directory"]
+		if !ok || certificatesDirectory == "" {
+			return entities.Result{Errors: []error{fmt.Errorf("keys_directory not specified")}}
+		}
+
+		rootDirectory, ok := job.Data["root_directory"]
+		if ok && rootDirectory != "" {
+			var err error
+			certificatesDirectory, err = clients.SecurePathJoin(rootDirectory, certificatesDirectory)
+			if err != nil {
+				return entities.Result{Errors: []error{err}}
+			}
+		}
 
-	rootDirectory, ok := job.Data["root_directory"]
-	if ok && rootDirectory != "" {
-		var err error
-		certificatesDirectory, err = clients.SecurePathJoin(rootDirectory, certificatesDirectory)
+		results := make([]entities.CommandResult, 0, 10)
+
+		establishResult, err := clients.EstablishConnection(ctx, sugar, client, job)
+		results = append(results, establishResult)
 		if err != nil {
 			return entities.Result{Errors: []error{err}}
 		}
-	}
+		defer client.Close()
 
-	results := make([]entities.CommandResult, 0, 9)
+		copier, ok := client.(clients.Copier)
+		if !ok {
+			return entities.Result{Errors: []error{fmt.Errorf("copy file operation not implemented for protocol %v", client)}}
+		}
 
-	establishResult, err := clients.EstablishConnection(ctx, sugar, client, job)
-	results = append(results, establishResult)
-	if err != nil {
-		return entities.Result{Errors: []error{err}}
-	}
-	defer client.Close()
+		config := client.GetConfig()
 
-	copier, ok := client.(clients.Copier)
-	if !ok {
-		return entities.Result{Errors: []error{fmt.Errorf("copy file operation not implemented for protocol %v", client)}}
-	}
+		commandResults, matches, err := clients.ExecuteCommands(ctx, client, []entities.Command{
+			{Body: `/system identity print`, MatchPrefix: "identity", Match: `(?m)name:\s+(.+)$`},
+		})
+		results = append(results, commandResults...)
+		if err != nil {
+			return entities.Result{Results: results, Errors: []error{fmt.Errorf("executing InitSecureAPI commands error %v", err)}}
+		}
 
-	config := client.GetConfig()
+		// mint certificate unique for device, private key is kept locally only until it is uploaded
+		deviceDirectory := filepath.FromSlash(filepath.Join(certificatesDirectory, "devices", job.Host.IP))
+		deviceCertificate, err := clients.GenerateDeviceCerts(certificatesDirectory, deviceDirectory, job.Host.IP, matches["(%{identity1})"])
+		if err != nil {
+			return entities.Result{Results: results, Errors: []error{err}}
+		}
+		defer os.Remove(filepath.Join(deviceDirectory, "device.key"))
 
-	var sftpCopyResult entities.CommandResult
-	sftpCopyResult, err = copier.CopyFile(ctx, filepath.FromSlash(filepath.Join(certificatesDirectory, "device.crt")), "sftp://mtbulkdevice.crt")
-	results = append(results, sftpCopyResult)
-	if err != nil {
-		return entities.Result{Results: results, Errors: []error{err}}
-	}
+		var sftpCopyResult entities.CommandResult
+		sftpCopyResult, err = copier.CopyFile(ctx, filepath.Join(deviceDirectory, "device.crt"), "sftp://mtbulkdevice.crt")
+		results = append(results, sftpCopyResult)
+		if err != nil {
+			return entities.Result{Results: results, Errors: []error{err}}
+		}
 
-	sftpCopyResult, err = copier.CopyFile(ctx, filepath.FromSlash(filepath.Join(certificatesDirectory, "device.key")), "sftp://mtbulkdevice.key")
-	results = append(results, sftpCopyResult)
-	if err != nil {
-		return entities.Result{Results: results, Errors: []error{err}}
-	}
+		sftpCopyResult, err = copier.CopyFile(ctx, filepath.Join(deviceDirectory, "device.key"), "sftp://mtbulkdevice.key")
+		results = append(results, sftpCopyResult)
+		if err != nil {
+			return entities.Result{Results: results, Errors: []error{err}}
+		}
 
-	// prepare sequence of commands to run on device
-	commands := []entities.Command{
-		{Body: `/ip service set api-ssl certificate=none`},
-		{Body: `/certificate print detail`, MatchPrefix: "c", Match: `(?m)^\s+(\d+).*mtbulkdevice`},
-		{Body: `/certificate remove %{c1}`},
-		{Body: `/certificate import file-name=mtbulkdevice.crt passphrase=""`, Expect: "certificates-imported: 1"},
-		{Body: `/certificate import file-name=mtbulkdevice.key passphrase=""`, Expect: "private-keys-imported: 1", SleepMs: config.VerifySleepMs},
-		{Body: `/ip service set api-ssl disabled=no certificate=mtbulkdevice.crt`},
-	}
+		// prepare sequence of commands to run on device
+		commands := []entities.Command{
+			{Body: `/ip service set api-ssl certificate=none`},
+			{Body: `/certificate print detail`, MatchPrefix: "c", Match: `(?m)^\s+(\d+).*mtbulkdevice`},
+			{Body: `/certificate remove %{c1}`},
+			{Body: `/certificate import file-name=mtbulkdevice.crt passphrase=""`, Expect: "certificates-imported: 1"},
+			{Body: `/certificate import file-name=mtbulkdevice.key passphrase=""`, Expect: "private-keys-imported: 1", SleepMs: config.VerifySleepMs},
+			{Body: `/ip service set api-ssl disabled=no certificate=mtbulkdevice.crt`},
+		}
 
-	commandResults, _, err := clients.ExecuteCommands(ctx, client, commands)
-	results = append(results, commandResults...)
-	if err != nil {
-		return entities.Result{Results: results, Errors: []error{fmt.Errorf("executing InitSecureAPI commands error %v", err)}}
-	}
+		commandResults, _, err = clients.ExecuteCommands(ctx, client, commands)
+		results = append(results, commandResults...)
+		if err != nil {
+			return entities.Result{Results: results, Errors: []error{fmt.Errorf("executing InitSecureAPI commands error %v", err)}}
+		}
 
-	return entities.Result{Results: results}
+		if err := deviceCertificates.Store(deviceCertificate); err != nil {
+			return entities.Result{Results: results, Errors: []error{fmt.Errorf("can't record certificate issued for %s: %v", job.Host.IP, err)}}
+		}
+		results = append(results, entities.CommandResult{
+			Body:      "/<mt-bulk>issue certificate",
+			Responses: []string{fmt.Sprintf("issued certificate %s", deviceCertificate)},
+		})
+
+		return entities.Result{Results: results}
+	}
 }
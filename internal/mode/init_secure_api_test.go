@@ -3,42 +3,67 @@ package mode
 import (
 	"context"
 	"errors"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
 
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"github.com/migotom/mt-bulk/internal/clients"
 	"github.com/migotom/mt-bulk/internal/clients/mocks"
 	"github.com/migotom/mt-bulk/internal/entities"
-	"go.uber.org/zap"
+	kvmocks "github.com/migotom/mt-bulk/internal/kvdb/mocks"
 )
 
 func TestInitSecureAPI(t *testing.T) {
+	certificatesDirectory, err := ioutil.TempDir("", "certs")
+	if err != nil {
+		t.Fatalf("can't create temporary directory %v", err)
+	}
+	defer os.RemoveAll(certificatesDirectory)
+
+	if err := clients.GenerateCA(certificatesDirectory); err != nil {
+		t.Fatalf("can't generate CA %v", err)
+	}
+	deviceDirectory := filepath.Join(certificatesDirectory, "devices", "10.0.0.1")
+
 	cases := []struct {
 		Name          string
 		Job           entities.Job
 		Expected      entities.Result
-		ExpectedError error
+		ExpectedMocks func(kv *kvmocks.KVMock)
 	}{
 		{
 			Name: "OK",
-			Job:  entities.Job{Host: entities.Host{Password: "old"}, Data: map[string]string{"keys_directory": "certs/"}},
+			Job:  entities.Job{Host: entities.Host{IP: "10.0.0.1", Password: "old"}, Data: map[string]string{"keys_directory": certificatesDirectory}},
 			Expected: entities.Result{Results: []entities.CommandResult{
 				entities.CommandResult{Body: "/<mt-bulk>establish connection", Responses: []string{"/<mt-bulk>establish connection", " --> attempt #0, password #0, job #"}},
-				entities.CommandResult{Body: `/<mt-bulk>copy sftp://` + filepath.FromSlash("certs/device.crt") + ` sftp://mtbulkdevice.crt`},
-				entities.CommandResult{Body: `/<mt-bulk>copy sftp://` + filepath.FromSlash("certs/device.key") + ` sftp://mtbulkdevice.key`},
+				entities.CommandResult{Body: `/system identity print`, Responses: []string{`/system identity print`}},
+				entities.CommandResult{Body: `/<mt-bulk>copy sftp://` + filepath.Join(deviceDirectory, "device.crt") + ` sftp://mtbulkdevice.crt`},
+				entities.CommandResult{Body: `/<mt-bulk>copy sftp://` + filepath.Join(deviceDirectory, "device.key") + ` sftp://mtbulkdevice.key`},
 				entities.CommandResult{Body: `/ip service set api-ssl certificate=none`, Responses: []string{`/ip service set api-ssl certificate=none`}},
 				entities.CommandResult{Body: `/certificate print detail`, Responses: []string{`/certificate print detail`}},
 				entities.CommandResult{Body: `/certificate remove %{c1}`, Responses: []string{`/certificate remove %{c1}`}},
 				entities.CommandResult{Body: `/certificate import file-name=mtbulkdevice.crt passphrase=""`, Responses: []string{`/certificate import file-name=mtbulkdevice.crt passphrase=""`}},
 				entities.CommandResult{Body: `/certificate import file-name=mtbulkdevice.key passphrase=""`, Responses: []string{`/certificate import file-name=mtbulkdevice.key passphrase=""`}},
 				entities.CommandResult{Body: `/ip service set api-ssl disabled=no certificate=mtbulkdevice.crt`, Responses: []string{`/ip service set api-ssl disabled=no certificate=mtbulkdevice.crt`}},
+				entities.CommandResult{Body: "/<mt-bulk>issue certificate"},
+			},
 			},
+			ExpectedMocks: func(kv *kvmocks.KVMock) {
+				kv.Txn.On("Store", "DeviceCertificate:10.0.0.1", mock.AnythingOfType("clients.DeviceCertificate")).Return(nil)
+				kv.Txn.On("Commit").Return(nil)
+				kv.Txn.On("Discard").Return()
 			},
 		},
 		{
-			Name:     "Wrong, missing certificates directory",
-			Job:      entities.Job{Host: entities.Host{Password: "old"}},
-			Expected: entities.Result{Errors: []error{errors.New("keys_directory not specified")}},
+			Name:          "Wrong, missing certificates directory",
+			Job:           entities.Job{Host: entities.Host{Password: "old"}},
+			Expected:      entities.Result{Errors: []error{errors.New("keys_directory not specified")}},
+			ExpectedMocks: func(kv *kvmocks.KVMock) {},
 		},
 	}
 	for _, tc := range cases {
@@ -46,10 +71,19 @@ func TestInitSecureAPI(t *testing.T) {
 			sugar := zap.NewExample().Sugar()
 			client := mocks.Client{}
 
-			result := InitSecureAPI(context.Background(), sugar, client, &tc.Job)
+			kvMock := kvmocks.KVMock{Txn: kvmocks.TxnMock{}}
+			tc.ExpectedMocks(&kvMock)
+
+			result := InitSecureAPI(clients.NewDeviceCertificates(&kvMock))(context.Background(), sugar, client, &tc.Job)
+
+			// issued certificate details are unique for each run
+			if len(result.Results) > 0 {
+				result.Results[len(result.Results)-1].Responses = nil
+			}
 			if !reflect.DeepEqual(result, tc.Expected) {
 				t.Errorf("got:%v, expected:%v", result, tc.Expected)
 			}
+			kvMock.Txn.AssertExpectations(t)
 		})
 	}
 }
@@ -19,6 +19,12 @@ const (
 	CustomSSHMode = "CustomSSH"
 	// CustomAPIMode is custom Mikrotik secure API job operation name.
 	CustomAPIMode = "CustomAPI"
+	// CustomAPIPlainMode is custom Mikrotik plain (non-TLS) API job operation name.
+	CustomAPIPlainMode = "CustomAPIPlain"
+	// ChangePasswordAPIPlainMode is change password using Mikrotik plain (non-TLS) API operation name.
+	ChangePasswordAPIPlainMode = "ChangePasswordAPIPlain"
+	// CustomRESTMode is custom RouterOS REST API job operation name.
+	CustomRESTMode = "CustomREST"
 	// InitSecureAPIMode is initialize secure API job operation name.
 	InitSecureAPIMode = "InitSecureAPI"
 	// InitPublicKeySSHMode is initialize public key SSH authentication job operation name.
@@ -29,6 +35,20 @@ const (
 	SFTPMode = "SFTP"
 	// SystemBackupMode is system backup job operation name.
 	SystemBackupMode = "SystemBackup"
+	// SystemRestoreMode is system restore (backup or configuration import) job operation name.
+	SystemRestoreMode = "SystemRestore"
+	// SystemUpgradeMode is system (RouterOS packages and RouterBOARD firmware) upgrade job operation name.
+	SystemUpgradeMode = "SystemUpgrade"
+	// ConfigDiffMode is configuration drift detection job operation name.
+	ConfigDiffMode = "ConfigDiff"
+	// ComplianceMode is name of a job verifying device against compliance rules.
+	ComplianceMode = "Compliance"
 	// SecurityAuditMode is name of a job performing security audit of device.
 	SecurityAuditMode = "SecurityAudit"
+	// CollectFactsMode is name of a job collecting inventory of device's hardware and software.
+	CollectFactsMode = "CollectFacts"
+	// DiscoverMode is name of a job reading device's neighbors to discover network.
+	DiscoverMode = "Discover"
+	// AcceptHostKeyMode is accept device's SSH host key job operation name.
+	AcceptHostKeyMode = "AcceptHostKey"
 )
@@ -0,0 +1,114 @@
+package mode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/migotom/mt-bulk/internal/clients"
+	"github.com/migotom/mt-bulk/internal/console"
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+// DefaultRebootWaitTimeout is default time limit of waiting for device to answer again after reboot.
+const DefaultRebootWaitTimeout = 10 * time.Minute
+
+var (
+	// rebootGracePeriod is time given to device to shut down before first attempt to reconnect.
+	rebootGracePeriod = 30 * time.Second
+	// rebootPollInterval is time between attempts to reconnect to rebooting device.
+	rebootPollInterval = 10 * time.Second
+)
+
+// rebootWaitTimeout returns time limit of waiting for device after reboot set by job's `wait_timeout`, or default one.
+func rebootWaitTimeout(job *entities.Job) (time.Duration, error) {
+	timeout, ok := job.Data["wait_timeout"]
+	if !ok || timeout == "" {
+		return DefaultRebootWaitTimeout, nil
+	}
+	waitTimeout, err := time.ParseDuration(timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid wait_timeout: %v", err)
+	}
+	return waitTimeout, nil
+}
+
+// rebootAndWait reboots device, waits until it answers again and reestablishes connection.
+func rebootAndWait(ctx context.Context, sugar *zap.SugaredLogger, client clients.Client, job *entities.Job, waitTimeout time.Duration) ([]entities.CommandResult, error) {
+	rebootResult, err := runConfirmed(client, "/system reboot")
+	if err != nil {
+		return []entities.CommandResult{rebootResult}, fmt.Errorf("reboot error %v", err)
+	}
+
+	waitResult, err := waitForDevice(ctx, sugar, client, job, waitTimeout)
+	return []entities.CommandResult{rebootResult, waitResult}, err
+}
+
+// runConfirmed runs command requiring confirmation which makes device to reboot, e.g. `/system reboot`.
+func runConfirmed(client clients.Client, body string) (entities.CommandResult, error) {
+	result := entities.CommandResult{Body: body}
+	response, err := client.RunCmd(body, regexp.MustCompile(`\[y/N\]`))
+	result.Responses = append(result.Responses, response)
+	if err != nil {
+		result.Error = err
+		return result, err
+	}
+	// device closes connection while rebooting, so confirmation has no meaningful response
+	response, _ = client.RunCmd("y", nil)
+	result.Responses = append(result.Responses, response)
+	return result, nil
+}
+
+// waitForDevice closes connection to rebooting device, waits until it answers again and reestablishes connection.
+func waitForDevice(ctx context.Context, sugar *zap.SugaredLogger, client clients.Client, job *entities.Job, waitTimeout time.Duration) (entities.CommandResult, error) {
+	client.Close()
+
+	waitResult := entities.CommandResult{Body: "/<mt-bulk>wait for device", Responses: []string{"/<mt-bulk>wait for device"}}
+	started := time.Now()
+	wait := rebootGracePeriod
+	for {
+		select {
+		case <-ctx.Done():
+			waitResult.Error = errors.New("interrupted")
+			return waitResult, waitResult.Error
+		case <-time.After(wait):
+		}
+		wait = rebootPollInterval
+
+		if _, err := clients.EstablishConnection(ctx, sugar, client, job); err == nil {
+			waitResult.Responses = append(waitResult.Responses, fmt.Sprintf(" --> device answers after %s", time.Since(started).Round(time.Second)))
+			return waitResult, nil
+		}
+		waitResult.Responses = append(waitResult.Responses, fmt.Sprintf(" --> device not answering after %s", time.Since(started).Round(time.Second)))
+
+		if time.Since(started) > waitTimeout {
+			waitResult.Error = fmt.Errorf("device did not answer within %s after reboot", waitTimeout)
+			return waitResult, waitResult.Error
+		}
+	}
+}
+
+// readSettings reads settings printed by given command, e.g. `/system resource print`.
+func readSettings(ctx context.Context, client clients.Client, body string) (map[string]string, []entities.CommandResult, error) {
+	commandResults, _, err := clients.ExecuteCommands(ctx, client, []entities.Command{{Body: body, Parse: console.FormatPrint}})
+	if err != nil {
+		return nil, commandResults, err
+	}
+	if len(commandResults) == 0 || len(commandResults[0].Records) == 0 {
+		return nil, commandResults, fmt.Errorf("can't read settings printed by %s", body)
+	}
+	return commandResults[0].Records[0], commandResults, nil
+}
+
+// routerOSVersion returns version number of RouterOS version printed by device, e.g. 6.48.1 of `6.48.1 (stable)`.
+func routerOSVersion(version string) string {
+	if fields := strings.Fields(version); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
@@ -4,20 +4,40 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"regexp"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/migotom/mt-bulk/internal/clients"
 	"github.com/migotom/mt-bulk/internal/entities"
+	"github.com/migotom/mt-bulk/internal/rules"
 	"github.com/migotom/mt-bulk/internal/vulnerabilities"
 )
 
-// SecurityAudit is an operation performing security audit of device.
-func SecurityAudit(vulnerabilitiesManager *vulnerabilities.Manager) OperationModeFunc {
+// Remediation modes of security audit findings.
+const (
+	RemediationPreview = "preview"
+	RemediationApply   = "apply"
+)
+
+// SecurityAudit is an operation performing security audit of device by enabled audit rules applicable to device's version and scan for known CVEs.
+// Failures of waived rules are reported by rules results only, waived CVEs and CVEs below audit's CVSS threshold are reported without failing audit.
+// If job's `fix` is enabled remediation commands of failed rules are executed and device is audited again, `fix: preview` only reports commands to execute.
+func SecurityAudit(vulnerabilitiesManager *vulnerabilities.Manager, audit rules.Audit) OperationModeFunc {
 	return func(ctx context.Context, sugar *zap.SugaredLogger, client clients.Client, job *entities.Job) entities.Result {
-		results := make([]entities.CommandResult, 0, 9)
+		var remediation string
+		switch job.Data["fix"] {
+		case "", "false", "no":
+		case RemediationPreview:
+			remediation = RemediationPreview
+		case "true", "yes", RemediationApply:
+			remediation = RemediationApply
+		default:
+			return entities.Result{Errors: []error{fmt.Errorf("unknown remediation mode %s", job.Data["fix"])}}
+		}
+
+		results := make([]entities.CommandResult, 0, 2+len(audit.Rules))
 
 		establishResult, err := clients.EstablishConnection(ctx, sugar, client, job)
 		results = append(results, establishResult)
@@ -26,104 +46,184 @@ func SecurityAudit(vulnerabilitiesManager *vulnerabilities.Manager) OperationMod
 		}
 		defer client.Close()
 
-		// prepare sequence of commands to run on device
-		commands := []entities.Command{
-			{Body: `/system resource print`, MatchPrefix: "v", Match: `(?m)\s+version:\s+([\d\.]+)+`},
-			{Body: `/ip service print`, MatchPrefix: "service",
-				Matches: []string{
-					`(?m)\d+\s+(telnet)\s+\d+\s+^`,
-					`(?m)\d+\s+(ftp)\s+\d+\s+^`,
-					`(?m)\d+\s+(www)\s+\d+\s+^`,
-					`(?m)\d+\s+(ftp)\s+\d+\s+^`,
-					`(?m)\d+\s+(www-ssl)\s+\d+\s+^`,
-					`(?m)\d+\s+(api)\s+\d+\s+^`,
-					`(?m)\d+\s+(api-ssl)\s+\d+\s+^`,
-				}},
-			{Body: `/tool mac-server print`, MatchPrefix: "mac-server", Match: `(?m)\s+(allowed-interface-list:\s+[^n][^o][^n][^e])`},
-			{Body: `/tool mac-server mac-winbox print`, MatchPrefix: "mac-winbox", Match: `(?m)\s+(allowed-interface-list:\s+[^n][^o][^n][^e])`},
-			{Body: `/tool mac-server ping print`, MatchPrefix: "mac-ping", Match: `(?m)\s+(enabled:\s+yes)`},
-			{Body: `/ip neighbor discovery-settings print`, MatchPrefix: "neighbor", Match: `(?m)\s+(discover-interface-list:\s+[^n][^o][^n][^e])`},
-			{Body: `/tool bandwidth-server print`, MatchPrefix: "bandwidth-server", Match: `(?m)\s+(enabled:\s+yes)`},
-			{Body: `/ip dns print`, MatchPrefix: "dns", Match: `(?m)\s+(allow-remote-requests:\s+yes)`},
-			{Body: `/ip proxy print`, MatchPrefix: "proxy", Match: `(?m)\s+(enabled:\s+yes)`},
-			{Body: `/ip socks print`, MatchPrefix: "socks", Match: `(?m)\s+(enabled:\s+yes)`},
-			{Body: `/ip upnp print`, MatchPrefix: "upnp", Match: `(?m)\s+(enabled:\s+yes)`},
-			{Body: `/tool romon print`, MatchPrefix: "romon", Match: `(?m)\s+(enabled:\s+yes)`},
-			{Body: `/user print`, MatchPrefix: "admin-full", Match: `(?m)\s+(\d+\s+(?:;;; system default user)?\s+admin\s+full)`},
-			{Body: `/snmp community print where name=public`, MatchPrefix: "snmp", Match: `(?m)\s+(public\s+::\/0)|(public\s+([\d\.\/:]+)\s+none)`},
-			{Body: `/ip ssh print`, MatchPrefix: "ssh", Match: `(?m)\s+(strong-crypto:\s+no)`},
-			{Body: `/ip settings print`, MatchPrefix: "rp-filter", Match: `(?m)\s+(rp-filter:\s+no)`},
-		}
-
-		commandResults, matches, err := clients.ExecuteCommands(ctx, client, commands)
+		commandResults, matches, err := clients.ExecuteCommands(ctx, client, []entities.Command{
+			{Body: `/system resource print`, MatchPrefix: "v", Match: `(?m)\s+version:\s+([\d\.]+(?:(?:alpha|beta|rc)\d+)?)`},
+		})
 		results = append(results, commandResults...)
 		if err != nil {
 			return entities.Result{Results: results, Errors: []error{fmt.Errorf("executing SecurityAudit commands error %v", err)}}
 		}
 
-		var optionsErr optionsError
+		version, ok := matches["(%{v1})"]
+		if !ok {
+			return entities.Result{Results: results, Errors: []error{errors.New("Mikrotik version not recognized")}}
+		}
+		routerOSVersion, err := entities.ParseVersion(version)
+		if err != nil {
+			return entities.Result{Results: results, Errors: []error{err}}
+		}
 
-		unsecureServices := extractUnsecureOptions(regexp.MustCompile(`%{service\d+}`), matches)
-		if len(unsecureServices) > 0 {
-			optionsErr.err = append(optionsErr.err, fmt.Errorf("enabled services %v", unsecureServices))
-		}
-
-		singleTests := []struct {
-			re  string
-			err string
-		}{
-			{`%{mac-server\d+}`, "enabled mac-server"},
-			{`%{mac-ping\d+}`, "enabled ping by mac"},
-			{`%{neighbor\d+}`, "enabled neighbor discovery"},
-			{`%{bandwidth-server\d+}`, "enabled bandwidth server"},
-			{`%{dns\d+}`, "DNS server allows remote requests"},
-			{`%{proxy\d+}`, "enabled proxy server"},
-			{`%{socks\d+}`, "enabled socks server"},
-			{`%{upnp\d+}`, "enabled upnp server"},
-			{`%{romon\d+}`, "enabled RoMON agent"},
-			{`%{rp-filter\d+}`, "Reverse Path Filtering not enabled"},
-			{`%{snmp\d+}`, "SNMP publicly available"},
-			{`%{ssh\d+}`, "not enabled SSH strong-crypto"},
-			{`%{admin-full\d+}`, "enabled admin user without allowed IP restriction with full grants"},
-		}
-
-		for _, test := range singleTests {
-			if extractUnsecureOption(regexp.MustCompile(test.re), matches) != "" {
-				optionsErr.err = append(optionsErr.err, errors.New(test.err))
+		applicable := make([]entities.Rule, 0, len(audit.Rules))
+		for _, rule := range audit.Rules {
+			if rules.Applicable(rule, routerOSVersion) {
+				applicable = append(applicable, rule)
 			}
 		}
 
-		version, ok := matches["(%{v1})"]
-		if !ok {
-			return entities.Result{Results: results, Errors: []error{errors.New("Mikrotik version not recognized"), optionsErr}}
+		ruleResults, commandResults, err := auditRules(ctx, client, audit, applicable, job.Host.IP)
+		results = append(results, commandResults...)
+		if err != nil {
+			return entities.Result{Results: results, Rules: ruleResults, Errors: []error{err}}
+		}
+
+		var auditErrors []error
+		switch remediation {
+		case RemediationPreview:
+			results = append(results, remediationPreview(applicable, ruleResults))
+		case RemediationApply:
+			commandResults, remediated, remediationErrors := remediate(ctx, client, applicable, ruleResults)
+			results = append(results, commandResults...)
+			auditErrors = append(auditErrors, remediationErrors...)
+			if len(remediated) == 0 {
+				break
+			}
+
+			before := ruleResults
+			ruleResults, commandResults, err = auditRules(ctx, client, audit, applicable, job.Host.IP)
+			results = append(results, commandResults...)
+			if err != nil {
+				return entities.Result{Results: results, Rules: ruleResults, Errors: append(auditErrors, err)}
+			}
+
+			report := entities.CommandResult{Body: "/<mt-bulk>remediation report"}
+			for idx := range ruleResults {
+				if !remediated[ruleResults[idx].ID] {
+					continue
+				}
+				ruleResults[idx].Fixed = ruleResults[idx].Passed
+				report.Responses = append(report.Responses, fmt.Sprintf("%s: %s -> %s", ruleResults[idx].ID, ruleStatus(before[idx]), ruleStatus(ruleResults[idx])))
+			}
+			results = append(results, report)
+		}
+
+		var optionsErr optionsError
+		for _, ruleResult := range ruleResults {
+			switch {
+			case ruleResult.Passed || ruleResult.Waived:
+			case ruleResult.Error != "":
+				optionsErr.err = append(optionsErr.err, fmt.Errorf("rule %s error %s", ruleResult.ID, ruleResult.Error))
+			default:
+				optionsErr.err = append(optionsErr.err, errors.New(ruleResult.Message))
+			}
+		}
+		if len(optionsErr.err) > 0 {
+			auditErrors = append(auditErrors, optionsErr)
 		}
-		vulnerabilitiesErrors := vulnerabilitiesManager.Check(version)
 
 		var additionalInformation []string
-		if vul, ok := vulnerabilitiesErrors.(vulnerabilities.VulnerabilityError); ok {
-			additionalInformation = vul.Details()
+		if vulnerabilitiesErrors := vulnerabilitiesManager.Check(version); vulnerabilitiesErrors != nil {
+			if vul, ok := vulnerabilitiesErrors.(vulnerabilities.VulnerabilityError); ok {
+				waivers, err := vulnerabilitiesManager.Waivers()
+				if err != nil {
+					auditErrors = append(auditErrors, fmt.Errorf("can't load CVE waivers: %v", err))
+				}
+				waivers = append(waivers, audit.CVEWaivers...)
+
+				now := time.Now()
+				vul = vul.Triage(audit.CVSSThreshold, func(id string) (entities.Waiver, bool) {
+					return entities.FindWaiver(waivers, id, job.Host.IP, now)
+				})
+				vulnerabilitiesErrors = vul
+				additionalInformation = vul.Details()
+			}
+			auditErrors = append(auditErrors, vulnerabilitiesErrors)
+		}
+
+		return entities.Result{Results: results, Rules: ruleResults, Errors: auditErrors, AdditionalInformation: additionalInformation}
+	}
+}
+
+// auditRules evaluates list of rules on device, failures of rules are marked as waived if accepted by audit's waivers.
+func auditRules(ctx context.Context, client clients.Client, audit rules.Audit, list []entities.Rule, host string) ([]entities.RuleResult, []entities.CommandResult, error) {
+	ruleResults := make([]entities.RuleResult, 0, len(list))
+	var results []entities.CommandResult
+
+	for _, rule := range list {
+		ruleResult, commandResults := rules.Evaluate(ctx, client, rule)
+		results = append(results, commandResults...)
+		if ctx.Err() != nil {
+			return ruleResults, results, fmt.Errorf("executing SecurityAudit commands interrupted")
 		}
 
-		return entities.Result{Results: results, Errors: []error{optionsErr, vulnerabilitiesErrors}, AdditionalInformation: additionalInformation}
+		if !ruleResult.Passed {
+			if waiver, ok := audit.Waiver(rule.ID, host, time.Now()); ok {
+				ruleResult.Waived, ruleResult.Waiver = true, waiver.Justification
+			}
+		}
+		ruleResults = append(ruleResults, ruleResult)
 	}
+	return ruleResults, results, nil
+}
+
+// remediable returns true if failure of rule should be remediated.
+func remediable(rule entities.Rule, result entities.RuleResult) bool {
+	return !result.Passed && !result.Waived && result.Error == "" && len(rule.Remediation) > 0
 }
 
-func extractUnsecureOptions(re *regexp.Regexp, matches map[string]string) (options []string) {
-	for match, value := range matches {
-		if re.MatchString(match) {
-			options = append(options, value)
+// remediationPreview returns list of remediation commands which would be executed for failed rules.
+func remediationPreview(list []entities.Rule, ruleResults []entities.RuleResult) entities.CommandResult {
+	preview := entities.CommandResult{Body: "/<mt-bulk>remediation preview"}
+	for idx, rule := range list {
+		result := ruleResults[idx]
+		if result.Passed || result.Waived || result.Error != "" {
+			continue
+		}
+		if !remediable(rule, result) {
+			preview.Responses = append(preview.Responses, fmt.Sprintf("%s: %s, no remediation defined", rule.ID, result.Message))
+			continue
+		}
+		for _, command := range rules.Remediation(rule, result.Evidence) {
+			preview.Responses = append(preview.Responses, fmt.Sprintf("%s: %s, would execute %s", rule.ID, result.Message, command.Sentence()))
 		}
 	}
-	return
+	if len(preview.Responses) == 0 {
+		preview.Responses = append(preview.Responses, "nothing to remediate")
+	}
+	return preview
 }
 
-func extractUnsecureOption(re *regexp.Regexp, matches map[string]string) (option string) {
-	for match, value := range matches {
-		if re.MatchString(match) {
-			return value
+// remediate executes remediation commands of failed rules, returns set of ids of remediated rules.
+func remediate(ctx context.Context, client clients.Client, list []entities.Rule, ruleResults []entities.RuleResult) ([]entities.CommandResult, map[string]bool, []error) {
+	var results []entities.CommandResult
+	var errs []error
+	remediated := make(map[string]bool)
+
+	for idx, rule := range list {
+		if !remediable(rule, ruleResults[idx]) {
+			continue
+		}
+
+		commandResults, _, err := clients.ExecuteCommands(ctx, client, rules.Remediation(rule, ruleResults[idx].Evidence))
+		results = append(results, commandResults...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("remediation of rule %s error %v", rule.ID, err))
 		}
+		remediated[rule.ID] = true
+	}
+	return results, remediated, errs
+}
+
+// ruleStatus returns status of rule's result, e.g. `PASS` or `FAIL`.
+func ruleStatus(result entities.RuleResult) string {
+	switch {
+	case result.Error != "":
+		return "ERROR"
+	case result.Passed:
+		return "PASS"
+	case result.Waived:
+		return "WAIVED"
 	}
-	return
+	return "FAIL"
 }
 
 type optionsError struct {
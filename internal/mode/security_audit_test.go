@@ -0,0 +1,58 @@
+package mode
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+	"github.com/migotom/mt-bulk/internal/rules"
+)
+
+func TestSecurityAuditRemediation(t *testing.T) {
+	list := []entities.Rule{
+		{ID: "ssh-strong-crypto", Command: "/ip ssh print", Match: `(?m)\s+(strong-crypto:\s+no)`, Absent: true, Message: "not enabled SSH strong-crypto", Remediation: []entities.Command{{Body: "/ip ssh set strong-crypto=yes"}}},
+		{ID: "weak-crypto", Command: "/ip ssh print", Match: `(?m)\s+strong-crypto:\s+(no)`, Absent: true, Message: "weak crypto [%{evidence}]", Remediation: []entities.Command{{Body: "/ip ssh set strong-crypto=%{evidence}"}}},
+		{ID: "ssh-no-remediation", Command: "/ip ssh print", Match: `(?m)\s+(strong-crypto:\s+no)`, Absent: true, Message: "weak SSH"},
+	}
+	audit := rules.Audit{Waivers: []entities.Waiver{{ID: "weak-crypto", Justification: "accepted"}}}
+
+	client := &settingsClient{strongCrypto: "no"}
+	before, _, err := auditRules(context.Background(), client, audit, list, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	if before[0].Passed || !before[1].Waived || before[1].Waiver != "accepted" || before[2].Passed {
+		t.Fatalf("got:%v, expected failed and waived rules", before)
+	}
+
+	preview := remediationPreview(list, before)
+	expectedPreview := []string{
+		"ssh-strong-crypto: not enabled SSH strong-crypto, would execute /ip ssh set strong-crypto=yes",
+		"ssh-no-remediation: weak SSH, no remediation defined",
+	}
+	if !reflect.DeepEqual(preview.Responses, expectedPreview) {
+		t.Errorf("got:%v, expected:%v", preview.Responses, expectedPreview)
+	}
+	if client.strongCrypto != "no" {
+		t.Errorf("preview changed device settings")
+	}
+
+	_, remediated, errs := remediate(context.Background(), client, list, before)
+	if len(errs) > 0 {
+		t.Fatalf("not expected error %v", errs)
+	}
+	if !reflect.DeepEqual(remediated, map[string]bool{"ssh-strong-crypto": true}) {
+		t.Errorf("got:%v, expected only not waived rule with remediation", remediated)
+	}
+
+	after, _, err := auditRules(context.Background(), client, audit, list, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	for _, result := range after {
+		if !result.Passed || ruleStatus(result) != "PASS" {
+			t.Errorf("got:%v, expected passed rule", result)
+		}
+	}
+}
@@ -5,82 +5,136 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/migotom/mt-bulk/internal/backups"
 	"github.com/migotom/mt-bulk/internal/clients"
 	"github.com/migotom/mt-bulk/internal/entities"
 	"go.uber.org/zap"
 )
 
-// SystemBackup backups system.
-func SystemBackup(ctx context.Context, sugar *zap.SugaredLogger, client clients.Client, job *entities.Job) entities.Result {
-	name, ok := job.Data["name"]
-	if !ok || name == "" {
-		name = "backup"
-	}
-	name = fmt.Sprintf("%s-%s", name, job.Host.IP)
+var (
+	// backupSaveSleep is time given to device to save backup file.
+	backupSaveSleep = time.Second
+	// exportSaveSleep is time given to device to save exported configuration.
+	exportSaveSleep = 5 * time.Second
+)
 
-	backupsStore, ok := job.Data["backups_store"]
-	if !ok || backupsStore == "" {
-		return entities.Result{Errors: []error{fmt.Errorf("backups_store not specified")}}
-	}
+// SystemBackup backups system into timestamped directory of host within backups store, indexes backup and prunes
+// host's backups expired by retention policy.
+func SystemBackup(index backups.Index, retention backups.Retention) OperationModeFunc {
+	return func(ctx context.Context, sugar *zap.SugaredLogger, client clients.Client, job *entities.Job) entities.Result {
+		name, ok := job.Data["name"]
+		if !ok || name == "" {
+			name = "backup"
+		}
+		name = fmt.Sprintf("%s-%s", name, job.Host.IP)
+
+		backupsStore, ok := job.Data["backups_store"]
+		if !ok || backupsStore == "" {
+			return entities.Result{Errors: []error{fmt.Errorf("backups_store not specified")}}
+		}
+
+		rootDirectory, ok := job.Data["root_directory"]
+		if ok && rootDirectory != "" {
+			var err error
+			backupsStore, err = clients.SecurePathJoin(rootDirectory, backupsStore)
+			if err != nil {
+				return entities.Result{Errors: []error{err}}
+			}
+		}
 
-	rootDirectory, ok := job.Data["root_directory"]
-	if ok && rootDirectory != "" {
-		var err error
-		backupsStore, err = clients.SecurePathJoin(rootDirectory, backupsStore)
+		results := make([]entities.CommandResult, 0, 6)
+
+		establishResult, err := clients.EstablishConnection(ctx, sugar, client, job)
+		results = append(results, establishResult)
 		if err != nil {
-			return entities.Result{Errors: []error{err}}
+			return entities.Result{Results: results, Errors: []error{err}}
 		}
-	}
+		defer client.Close()
 
-	if _, err := os.Stat(backupsStore); os.IsNotExist(err) {
-		if err := os.MkdirAll(backupsStore, os.ModePerm); err != nil {
-			return entities.Result{Errors: []error{err}}
+		// prepare sequence of commands to run on device
+		commands := []entities.Command{
+			{Body: fmt.Sprintf("/system backup save dont-encrypt=yes name=%s", name), SleepMs: int(backupSaveSleep / time.Millisecond)},
+			{Body: fmt.Sprintf("/export file=%s", name), SleepMs: int(exportSaveSleep / time.Millisecond)},
 		}
-	}
 
-	results := make([]entities.CommandResult, 0, 3)
+		commandResults, _, err := clients.ExecuteCommands(ctx, client, commands)
+		results = append(results, commandResults...)
+		if err != nil {
+			return entities.Result{Results: results, Errors: []error{fmt.Errorf("executing SystemBackup commands error %v", err)}}
+		}
 
-	establishResult, err := clients.EstablishConnection(ctx, sugar, client, job)
-	results = append(results, establishResult)
-	if err != nil {
-		return entities.Result{Errors: []error{err}}
-	}
-	defer client.Close()
+		copier, ok := client.(clients.Copier)
+		if !ok {
+			return entities.Result{Results: results, Errors: []error{fmt.Errorf("copy file operation not implemented for protocol %v", client)}}
+		}
 
-	// prepare sequence of commands to run on device
-	commands := []entities.Command{
-		{Body: fmt.Sprintf("/system backup save dont-encrypt=yes name=%s", name), SleepMs: 1000},
-		{Body: fmt.Sprintf("/export file=%s", name), SleepMs: 5000},
-	}
+		// directory is created only for backup saved by device and removed unless backup is indexed, so store keeps indexed backups only
+		created := time.Now()
+		backupDirectory := backups.Directory(backupsStore, job.Host.IP, created)
+		if err := os.MkdirAll(backupDirectory, os.ModePerm); err != nil {
+			return entities.Result{Results: results, Errors: []error{err}}
+		}
+		var indexed bool
+		defer func() {
+			if !indexed {
+				os.RemoveAll(backupDirectory)
+			}
+		}()
 
-	commandResults, _, err := clients.ExecuteCommands(ctx, client, commands)
-	results = append(results, commandResults...)
-	if err != nil {
-		return entities.Result{Results: results, Errors: []error{fmt.Errorf("executing SystemBackup commands error %v", err)}}
-	}
+		downloadURLs := make([]string, 0, 2)
+		for _, extension := range []string{"backup", "rsc"} {
+			var sftpCopyResult entities.CommandResult
 
-	copier, ok := client.(clients.Copier)
-	if !ok {
-		return entities.Result{Results: results, Errors: []error{fmt.Errorf("copy file operation not implemented for protocol %v", client)}}
-	}
+			target := filepath.FromSlash(filepath.Join(backupDirectory, fmt.Sprintf("%s.%s", name, extension)))
+			sftpCopyResult, err = copier.CopyFile(ctx,
+				fmt.Sprintf("sftp://%s.%s", name, extension),
+				target,
+			)
 
-	downloadURLs := make([]string, 0, 2)
-	for _, extension := range []string{"backup", "rsc"} {
-		var sftpCopyResult entities.CommandResult
+			results = append(results, sftpCopyResult)
+			downloadURLs = append(downloadURLs, target)
+			if err != nil {
+				return entities.Result{Results: results, Errors: []error{err}}
+			}
+		}
 
-		target := filepath.FromSlash(filepath.Join(backupsStore, fmt.Sprintf("%s.%s", name, extension)))
-		sftpCopyResult, err = copier.CopyFile(ctx,
-			fmt.Sprintf("sftp://%s.%s", name, extension),
-			target,
-		)
+		backup := backups.Backup{Host: job.Host.IP, Name: name, Created: created}
+		for _, downloadURL := range downloadURLs {
+			file, err := backups.NewFile(downloadURL)
+			if err != nil {
+				return entities.Result{Results: results, Errors: []error{err}}
+			}
+			backup.Files = append(backup.Files, file)
+		}
 
-		results = append(results, sftpCopyResult)
-		downloadURLs = append(downloadURLs, target)
-	}
-	if err != nil {
-		return entities.Result{Results: results, Errors: []error{err}}
-	}
+		indexResult := entities.CommandResult{Body: "/<mt-bulk>index backup", Responses: []string{backup.String()}}
+		if err := index.Add(backup); err != nil {
+			indexResult.Error = err
+			results = append(results, indexResult)
+			return entities.Result{Results: results, Errors: []error{fmt.Errorf("can't index backup: %v", err)}}
+		}
+		indexed = true
+		results = append(results, indexResult)
 
-	return entities.Result{Results: results, DownloadURLs: downloadURLs, Errors: []error{err}}
+		if !retention.Enabled() {
+			return entities.Result{Results: results, DownloadURLs: downloadURLs}
+		}
+
+		pruneResult := entities.CommandResult{Body: "/<mt-bulk>prune backups"}
+		pruned, err := backups.Prune(index, retention, job.Host.IP, time.Now())
+		for _, backup := range pruned {
+			pruneResult.Responses = append(pruneResult.Responses, fmt.Sprintf(" --> pruned %s", backup))
+		}
+		if err != nil {
+			pruneResult.Error = err
+			results = append(results, pruneResult)
+			return entities.Result{Results: results, DownloadURLs: downloadURLs, Errors: []error{fmt.Errorf("can't prune backups: %v", err)}}
+		}
+
+		results = append(results, pruneResult)
+
+		return entities.Result{Results: results, DownloadURLs: downloadURLs}
+	}
 }
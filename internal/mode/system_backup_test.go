@@ -0,0 +1,96 @@
+package mode
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/migotom/mt-bulk/internal/backups"
+	"github.com/migotom/mt-bulk/internal/clients/mocks"
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+// backupClient mocks device saving backups, downloaded files are written with their remote names.
+type backupClient struct {
+	mocks.Client
+
+	connectErr error
+	copyErr    error
+}
+
+func (c backupClient) Connect(ctx context.Context, IP, Port, User, Password string) error {
+	return c.connectErr
+}
+
+func (c backupClient) CopyFile(ctx context.Context, source, target string) (entities.CommandResult, error) {
+	if c.copyErr != nil {
+		return entities.CommandResult{Body: "/<mt-bulk>copy " + source, Error: c.copyErr}, c.copyErr
+	}
+	return entities.CommandResult{Body: "/<mt-bulk>copy " + source}, ioutil.WriteFile(target, []byte(source), 0600)
+}
+
+type backupsIndexList []backups.Backup
+
+func (i *backupsIndexList) Add(backup backups.Backup) error {
+	*i = append(*i, backup)
+	return nil
+}
+
+func (i *backupsIndexList) Remove(backup backups.Backup) error { return nil }
+
+func (i *backupsIndexList) List(host string) ([]backups.Backup, error) { return *i, nil }
+
+func TestSystemBackup(t *testing.T) {
+	defer func(backupSleep, exportSleep time.Duration) {
+		backupSaveSleep, exportSaveSleep = backupSleep, exportSleep
+	}(backupSaveSleep, exportSaveSleep)
+	backupSaveSleep, exportSaveSleep = time.Millisecond, time.Millisecond
+
+	cases := []struct {
+		Name            string
+		Client          backupClient
+		ExpectedResults int
+		ExpectedIndexed bool
+		ExpectedError   bool
+	}{
+		{Name: "OK", Client: backupClient{}, ExpectedResults: 6, ExpectedIndexed: true},
+		{Name: "Wrong, connection failed", Client: backupClient{connectErr: errors.New("connection refused")}, ExpectedResults: 1, ExpectedError: true},
+		{Name: "Wrong, download failed", Client: backupClient{copyErr: errors.New("no such file")}, ExpectedResults: 4, ExpectedError: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			store, err := ioutil.TempDir("", "backups")
+			if err != nil {
+				t.Fatalf("can't create temporary directory %v", err)
+			}
+			defer os.RemoveAll(store)
+
+			index := &backupsIndexList{}
+			job := entities.Job{Host: entities.Host{IP: "10.0.0.1", Password: "secret"}, Data: map[string]string{"backups_store": store}}
+			result := SystemBackup(index, backups.Retention{})(context.Background(), zap.NewExample().Sugar(), tc.Client, &job)
+
+			if (len(result.Errors) > 0) != tc.ExpectedError {
+				t.Errorf("got:%v, expected error:%v", result.Errors, tc.ExpectedError)
+			}
+			if len(result.Results) != tc.ExpectedResults {
+				t.Errorf("got:%v, expected:%v results", len(result.Results), tc.ExpectedResults)
+			}
+			if (len(*index) > 0) != tc.ExpectedIndexed {
+				t.Errorf("got:%v, expected indexed:%v", *index, tc.ExpectedIndexed)
+			}
+
+			// only indexed backups are kept in store
+			directories, _ := filepath.Glob(filepath.Join(store, "10.0.0.1", "*"))
+			if (len(directories) > 0) != tc.ExpectedIndexed {
+				t.Errorf("got:%v, expected backup directory:%v", directories, tc.ExpectedIndexed)
+			}
+		})
+	}
+}
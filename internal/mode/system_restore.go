@@ -0,0 +1,118 @@
+package mode
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/migotom/mt-bulk/internal/clients"
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+// importErrorRx matches errors reported by `/import` command, e.g. `failure: already have interface with such name`.
+var importErrorRx = regexp.MustCompile(`(?im)^.*(failure:|error).*$`)
+
+// SystemRestore uploads and restores system backup (.backup) or imports configuration script (.rsc).
+// Restoring backup reboots device, import may drop connection, in both cases device is expected to answer again.
+func SystemRestore(ctx context.Context, sugar *zap.SugaredLogger, client clients.Client, job *entities.Job) entities.Result {
+	file, ok := job.Data["file"]
+	if !ok || file == "" {
+		return entities.Result{Errors: []error{fmt.Errorf("file not specified")}}
+	}
+
+	extension := strings.ToLower(filepath.Ext(file))
+	if extension != ".backup" && extension != ".rsc" {
+		return entities.Result{Errors: []error{fmt.Errorf("file %s is neither system backup (.backup) nor configuration script (.rsc)", file)}}
+	}
+
+	waitTimeout, err := rebootWaitTimeout(job)
+	if err != nil {
+		return entities.Result{Errors: []error{err}}
+	}
+
+	rootDirectory, ok := job.Data["root_directory"]
+	if ok && rootDirectory != "" {
+		file, err = clients.SecurePathJoin(rootDirectory, file)
+		if err != nil {
+			return entities.Result{Errors: []error{err}}
+		}
+	}
+
+	results := make([]entities.CommandResult, 0, 6)
+
+	establishResult, err := clients.EstablishConnection(ctx, sugar, client, job)
+	results = append(results, establishResult)
+	if err != nil {
+		return entities.Result{Results: results, Errors: []error{err}}
+	}
+	defer client.Close()
+
+	copier, ok := client.(clients.Copier)
+	if !ok {
+		return entities.Result{Results: results, Errors: []error{fmt.Errorf("copy file operation not implemented for protocol %v", client)}}
+	}
+
+	name := filepath.Base(file)
+	sftpCopyResult, err := copier.CopyFile(ctx, file, "sftp://"+name)
+	results = append(results, sftpCopyResult)
+	if err != nil {
+		return entities.Result{Results: results, Errors: []error{err}}
+	}
+
+	switch extension {
+	case ".backup":
+		body := fmt.Sprintf("/system backup load name=%s", entities.QuoteWord(name))
+		if password := job.Data["password"]; password != "" {
+			body += fmt.Sprintf(" password=%s", entities.QuoteWord(password))
+		}
+
+		loadResult, err := runConfirmed(client, body)
+		results = append(results, loadResult)
+		if err != nil {
+			return entities.Result{Results: results, Errors: []error{fmt.Errorf("restore error %v", err)}}
+		}
+
+		waitResult, err := waitForDevice(ctx, sugar, client, job, waitTimeout)
+		results = append(results, waitResult)
+		if err != nil {
+			return entities.Result{Results: results, Errors: []error{err}}
+		}
+
+	case ".rsc":
+		commandResults, _, err := clients.ExecuteCommands(ctx, client, []entities.Command{
+			{Body: fmt.Sprintf("/import file-name=%s", entities.QuoteWord(name)), SleepMs: client.GetConfig().VerifySleepMs},
+		})
+		results = append(results, commandResults...)
+
+		// imported configuration may change addressing or services, so lost connection has to be established again
+		if err != nil {
+			if !clients.IsConnectionLost(err) {
+				return entities.Result{Results: results, Errors: []error{fmt.Errorf("import error %v", err)}}
+			}
+
+			waitResult, waitErr := waitForDevice(ctx, sugar, client, job, waitTimeout)
+			results = append(results, waitResult)
+			if waitErr != nil {
+				return entities.Result{Results: results, Errors: []error{fmt.Errorf("import error %v", err), waitErr}}
+			}
+		} else if len(commandResults) > 0 {
+			for _, response := range commandResults[0].Responses {
+				if failure := importErrorRx.FindString(response); failure != "" {
+					return entities.Result{Results: results, Errors: []error{fmt.Errorf("import error %s", strings.TrimSpace(failure))}}
+				}
+			}
+		}
+	}
+
+	// device answers, verify it is operational
+	_, commandResults, err := readSettings(ctx, client, "/system resource print")
+	results = append(results, commandResults...)
+	if err != nil {
+		return entities.Result{Results: results, Errors: []error{fmt.Errorf("executing SystemRestore commands error %v", err)}}
+	}
+	return entities.Result{Results: results}
+}
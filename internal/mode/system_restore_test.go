@@ -0,0 +1,109 @@
+package mode
+
+import (
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/migotom/mt-bulk/internal/clients/mocks"
+	"github.com/migotom/mt-bulk/internal/entities"
+	"go.uber.org/zap"
+)
+
+// restoringClient mocks device restoring backups and importing scripts.
+type restoringClient struct {
+	mocks.Client
+
+	importResponse string
+	importErr      error
+	commands       []string
+}
+
+func (c *restoringClient) RunCmd(val string, re *regexp.Regexp) (string, error) {
+	c.commands = append(c.commands, val)
+
+	switch {
+	case val == "/system resource print":
+		return "uptime: 1m\nversion: 6.48.1 (stable)\n", nil
+	case strings.HasPrefix(val, "/system backup load"):
+		return "Restore and reboot? [y/N]:", nil
+	case strings.HasPrefix(val, "/import"):
+		return c.importResponse, c.importErr
+	}
+	return val, nil
+}
+
+func TestSystemRestore(t *testing.T) {
+	defer func(gracePeriod, pollInterval time.Duration) {
+		rebootGracePeriod, rebootPollInterval = gracePeriod, pollInterval
+	}(rebootGracePeriod, rebootPollInterval)
+	rebootGracePeriod, rebootPollInterval = time.Millisecond, time.Millisecond
+
+	cases := []struct {
+		Name     string
+		Data     map[string]string
+		Client   restoringClient
+		Commands []string
+		Error    string
+	}{
+		{
+			Name:     "Backup",
+			Data:     map[string]string{"file": "backups/backup-10.0.0.1.backup", "password": "top secret"},
+			Commands: []string{`/system backup load name=backup-10.0.0.1.backup password="top secret"`, "y", "/system resource print"},
+		},
+		{
+			Name:     "Import",
+			Data:     map[string]string{"file": "firewall.rsc"},
+			Client:   restoringClient{importResponse: "Script file loaded and executed successfully"},
+			Commands: []string{"/import file-name=firewall.rsc", "/system resource print"},
+		},
+		{
+			Name:     "Import failure",
+			Data:     map[string]string{"file": "firewall.rsc"},
+			Client:   restoringClient{importResponse: "failure: already have such address\r\n"},
+			Commands: []string{"/import file-name=firewall.rsc"},
+			Error:    "import error failure: already have such address",
+		},
+		{
+			Name:     "Import, connection lost",
+			Data:     map[string]string{"file": "addressing.rsc"},
+			Client:   restoringClient{importErr: io.EOF},
+			Commands: []string{"/import file-name=addressing.rsc", "/system resource print"},
+		},
+		{
+			Name:     "Import, command error",
+			Data:     map[string]string{"file": "firewall.rsc"},
+			Client:   restoringClient{importErr: errors.New("timeout on waiting to expected result")},
+			Commands: []string{"/import file-name=firewall.rsc"},
+			Error:    "import error command processing error: timeout on waiting to expected result (/import file-name=firewall.rsc)",
+		},
+		{
+			Name:  "Unknown file",
+			Data:  map[string]string{"file": "firewall.txt"},
+			Error: "file firewall.txt is neither system backup (.backup) nor configuration script (.rsc)",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			job := entities.Job{Host: entities.Host{Password: "secret"}, Data: tc.Data}
+
+			result := SystemRestore(context.Background(), zap.NewExample().Sugar(), &tc.Client, &job)
+
+			if strings.Join(tc.Client.commands, "\n") != strings.Join(tc.Commands, "\n") {
+				t.Errorf("got:%v, expected:%v", tc.Client.commands, tc.Commands)
+			}
+
+			var err string
+			if len(result.Errors) > 0 {
+				err = result.Errors[0].Error()
+			}
+			if err != tc.Error {
+				t.Errorf("got:%v, expected:%v", err, tc.Error)
+			}
+		})
+	}
+}
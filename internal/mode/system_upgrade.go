@@ -0,0 +1,224 @@
+package mode
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/migotom/mt-bulk/internal/clients"
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+// upgradeDownloadTimeout is time limit of downloading RouterOS packages by device.
+const upgradeDownloadTimeout = 15 * time.Minute
+
+// packageVersionRx matches RouterOS version in name of package file, e.g. routeros-mipsbe-6.48.1.npk or routeros-7.1rc4-arm64.npk.
+var packageVersionRx = regexp.MustCompile(`-(\d+\.\d+(?:\.\d+)?(?:(?:alpha|beta|rc)\d+)?)(?:-[^/\\]*)?\.npk$`)
+
+// SystemUpgrade upgrades RouterOS packages from given channel or uploaded package, reboots device and verifies new version.
+// Uploaded package may reinstall running version, downgrades are rejected. Optionally upgrades RouterBOARD firmware and reboots device once again.
+func SystemUpgrade(ctx context.Context, sugar *zap.SugaredLogger, client clients.Client, job *entities.Job) entities.Result {
+	channel := job.Data["channel"]
+	packageFile := job.Data["package"]
+	firmware := job.Data["firmware"] == "true" || job.Data["firmware"] == "yes"
+
+	waitTimeout, err := rebootWaitTimeout(job)
+	if err != nil {
+		return entities.Result{Errors: []error{err}}
+	}
+
+	rootDirectory, ok := job.Data["root_directory"]
+	if ok && rootDirectory != "" && packageFile != "" {
+		packageFile, err = clients.SecurePathJoin(rootDirectory, packageFile)
+		if err != nil {
+			return entities.Result{Errors: []error{err}}
+		}
+	}
+
+	results := make([]entities.CommandResult, 0, 16)
+
+	establishResult, err := clients.EstablishConnection(ctx, sugar, client, job)
+	results = append(results, establishResult)
+	if err != nil {
+		return entities.Result{Results: results, Errors: []error{err}}
+	}
+	defer client.Close()
+
+	config := client.GetConfig()
+
+	resource, commandResults, err := readSettings(ctx, client, "/system resource print")
+	results = append(results, commandResults...)
+	if err != nil {
+		return entities.Result{Results: results, Errors: []error{fmt.Errorf("executing SystemUpgrade commands error %v", err)}}
+	}
+	installedVersion := routerOSVersion(resource["version"])
+
+	// prepare packages, downloaded by device from update channel or uploaded to device
+	var expectedVersion string
+	upgrade := true
+	if packageFile != "" {
+		if expectedVersion, err = packageVersion(packageFile, installedVersion); err != nil {
+			return entities.Result{Results: results, Errors: []error{err}}
+		}
+
+		copier, ok := client.(clients.Copier)
+		if !ok {
+			return entities.Result{Results: results, Errors: []error{fmt.Errorf("copy file operation not implemented for protocol %v", client)}}
+		}
+
+		sftpCopyResult, err := copier.CopyFile(ctx, packageFile, "sftp://"+filepath.Base(packageFile))
+		results = append(results, sftpCopyResult)
+		if err != nil {
+			return entities.Result{Results: results, Errors: []error{err}}
+		}
+	} else {
+		commands := make([]entities.Command, 0, 2)
+		if channel != "" {
+			commands = append(commands, entities.Command{Body: fmt.Sprintf("/system package update set channel=%s", channel)})
+		}
+		commands = append(commands, entities.Command{Body: "/system package update check-for-updates", SleepMs: config.VerifySleepMs})
+
+		commandResults, _, err := clients.ExecuteCommands(ctx, client, commands)
+		results = append(results, commandResults...)
+		if err != nil {
+			return entities.Result{Results: results, Errors: []error{fmt.Errorf("executing SystemUpgrade commands error %v", err)}}
+		}
+
+		update, commandResults, err := readSettings(ctx, client, "/system package update print")
+		results = append(results, commandResults...)
+		if err != nil {
+			return entities.Result{Results: results, Errors: []error{fmt.Errorf("executing SystemUpgrade commands error %v", err)}}
+		}
+
+		expectedVersion = routerOSVersion(update["latest-version"])
+		switch expectedVersion {
+		case "":
+			return entities.Result{Results: results, Errors: []error{fmt.Errorf("can't check for updates, status: %s", update["status"])}}
+		case installedVersion:
+			upgrade = false
+			results = append(results, entities.CommandResult{
+				Body:      "/<mt-bulk>upgrade",
+				Responses: []string{fmt.Sprintf("already running latest version %s", installedVersion)},
+			})
+		default:
+			commandResults, _, err = clients.ExecuteCommands(ctx, client, []entities.Command{
+				{Body: "/system package update download", TimeoutMs: int(upgradeDownloadTimeout / time.Millisecond), SleepMs: config.VerifySleepMs},
+			})
+			results = append(results, commandResults...)
+			if err != nil {
+				return entities.Result{Results: results, Errors: []error{fmt.Errorf("executing SystemUpgrade commands error %v", err)}}
+			}
+		}
+	}
+
+	// packages are installed during reboot
+	if upgrade {
+		rebootResults, err := rebootAndWait(ctx, sugar, client, job, waitTimeout)
+		results = append(results, rebootResults...)
+		if err != nil {
+			return entities.Result{Results: results, Errors: []error{err}}
+		}
+
+		resource, commandResults, err := readSettings(ctx, client, "/system resource print")
+		results = append(results, commandResults...)
+		if err != nil {
+			return entities.Result{Results: results, Errors: []error{fmt.Errorf("executing SystemUpgrade commands error %v", err)}}
+		}
+
+		version := routerOSVersion(resource["version"])
+		if version != expectedVersion {
+			return entities.Result{Results: results, Errors: []error{fmt.Errorf("upgrade from %s failed, running version %s", installedVersion, version)}}
+		}
+		verified := fmt.Sprintf("upgraded from %s to %s", installedVersion, version)
+		if version == installedVersion {
+			verified = fmt.Sprintf("reinstalled version %s", version)
+		}
+		results = append(results, entities.CommandResult{
+			Body:      "/<mt-bulk>verify version",
+			Responses: []string{verified},
+		})
+	}
+
+	if !firmware {
+		return entities.Result{Results: results}
+	}
+
+	firmwareResults, err := upgradeFirmware(ctx, sugar, client, job, waitTimeout)
+	results = append(results, firmwareResults...)
+	if err != nil {
+		return entities.Result{Results: results, Errors: []error{err}}
+	}
+	return entities.Result{Results: results}
+}
+
+// packageVersion returns RouterOS version of package file, package older than installed version is rejected.
+func packageVersion(packageFile, installedVersion string) (string, error) {
+	m := packageVersionRx.FindStringSubmatch(filepath.Base(packageFile))
+	if m == nil {
+		return "", fmt.Errorf("can't determine RouterOS version of package %s, expected file name like routeros-mipsbe-6.48.1.npk", filepath.Base(packageFile))
+	}
+
+	version, err := entities.ParseVersion(m[1])
+	if err != nil {
+		return "", err
+	}
+	installed, err := entities.ParseVersion(installedVersion)
+	if err != nil {
+		return "", err
+	}
+	if version.Compare(installed) < 0 {
+		return "", fmt.Errorf("downgrade from %s to %s not supported", installedVersion, m[1])
+	}
+	return m[1], nil
+}
+
+// upgradeFirmware upgrades RouterBOARD firmware to version provided by installed RouterOS and reboots device.
+func upgradeFirmware(ctx context.Context, sugar *zap.SugaredLogger, client clients.Client, job *entities.Job, waitTimeout time.Duration) ([]entities.CommandResult, error) {
+	results := make([]entities.CommandResult, 0, 6)
+
+	routerboard, commandResults, err := readSettings(ctx, client, "/system routerboard print")
+	results = append(results, commandResults...)
+	if err != nil {
+		return results, fmt.Errorf("executing SystemUpgrade commands error %v", err)
+	}
+
+	currentFirmware, upgradeFirmware := routerboard["current-firmware"], routerboard["upgrade-firmware"]
+	switch {
+	case routerboard["routerboard"] != "yes":
+		return append(results, entities.CommandResult{Body: "/<mt-bulk>upgrade firmware", Responses: []string{"not a RouterBOARD device, firmware upgrade skipped"}}), nil
+	case currentFirmware == upgradeFirmware:
+		return append(results, entities.CommandResult{Body: "/<mt-bulk>upgrade firmware", Responses: []string{fmt.Sprintf("already running firmware %s", currentFirmware)}}), nil
+	}
+
+	commandResults, _, err = clients.ExecuteCommands(ctx, client, []entities.Command{
+		{Body: "/system routerboard upgrade", Expect: `\[y/n\]`},
+		{Body: "y", SleepMs: client.GetConfig().VerifySleepMs},
+	})
+	results = append(results, commandResults...)
+	if err != nil {
+		return results, fmt.Errorf("executing SystemUpgrade commands error %v", err)
+	}
+
+	rebootResults, err := rebootAndWait(ctx, sugar, client, job, waitTimeout)
+	results = append(results, rebootResults...)
+	if err != nil {
+		return results, err
+	}
+
+	routerboard, commandResults, err = readSettings(ctx, client, "/system routerboard print")
+	results = append(results, commandResults...)
+	if err != nil {
+		return results, fmt.Errorf("executing SystemUpgrade commands error %v", err)
+	}
+	if routerboard["current-firmware"] != upgradeFirmware {
+		return results, fmt.Errorf("firmware upgrade from %s failed, running firmware %s", currentFirmware, routerboard["current-firmware"])
+	}
+	return append(results, entities.CommandResult{
+		Body:      "/<mt-bulk>verify firmware",
+		Responses: []string{fmt.Sprintf("firmware upgraded from %s to %s", currentFirmware, upgradeFirmware)},
+	}), nil
+}
@@ -0,0 +1,208 @@
+package mode
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/migotom/mt-bulk/internal/clients/mocks"
+	"github.com/migotom/mt-bulk/internal/entities"
+	"go.uber.org/zap"
+)
+
+// upgradingClient mocks device installing latest version and flashed firmware during reboot.
+type upgradingClient struct {
+	mocks.Client
+
+	version, latest   string
+	firmware, upgrade string
+	installs          bool
+	rebooting         bool
+	flashing          bool
+}
+
+func (c *upgradingClient) RunCmd(val string, re *regexp.Regexp) (string, error) {
+	switch val {
+	case "/system resource print":
+		return fmt.Sprintf("uptime: 1m\nversion: %s (stable)\n", c.version), nil
+	case "/system package update print":
+		return fmt.Sprintf("channel: stable\ninstalled-version: %s\nlatest-version: %s\n", c.version, c.latest), nil
+	case "/system routerboard print":
+		return fmt.Sprintf("routerboard: yes\ncurrent-firmware: %s\nupgrade-firmware: %s\n", c.firmware, c.upgrade), nil
+	case "/system routerboard upgrade":
+		c.flashing = true
+		return "Do you really want to upgrade firmware? [y/n]", nil
+	case "/system reboot":
+		c.rebooting = true
+		return "Reboot, yes? [y/N]:", nil
+	case "y":
+		if c.rebooting && c.installs {
+			c.version = c.latest
+		}
+		if c.rebooting && c.flashing {
+			c.firmware = c.upgrade
+		}
+		c.rebooting = false
+	}
+	return val, nil
+}
+
+func TestSystemUpgrade(t *testing.T) {
+	defer func(gracePeriod, pollInterval time.Duration) {
+		rebootGracePeriod, rebootPollInterval = gracePeriod, pollInterval
+	}(rebootGracePeriod, rebootPollInterval)
+	rebootGracePeriod, rebootPollInterval = time.Millisecond, time.Millisecond
+
+	cases := []struct {
+		Name     string
+		Client   upgradingClient
+		Package  string
+		Firmware bool
+		Steps    []string
+		Error    string
+	}{
+		{
+			Name:     "OK",
+			Client:   upgradingClient{version: "6.47", latest: "6.48.1", firmware: "6.47", upgrade: "6.48.1", installs: true},
+			Firmware: true,
+			Steps: []string{
+				"/<mt-bulk>establish connection",
+				"/system resource print",
+				"/system package update check-for-updates",
+				"/system package update print",
+				"/system package update download",
+				"/system reboot",
+				"/<mt-bulk>wait for device",
+				"/system resource print",
+				"/<mt-bulk>verify version",
+				"/system routerboard print",
+				"/system routerboard upgrade",
+				"y",
+				"/system reboot",
+				"/<mt-bulk>wait for device",
+				"/system routerboard print",
+				"/<mt-bulk>verify firmware",
+			},
+		},
+		{
+			Name:   "Already latest",
+			Client: upgradingClient{version: "6.48.1", latest: "6.48.1"},
+			Steps: []string{
+				"/<mt-bulk>establish connection",
+				"/system resource print",
+				"/system package update check-for-updates",
+				"/system package update print",
+				"/<mt-bulk>upgrade",
+			},
+		},
+		{
+			Name:    "OK, reinstall uploaded package",
+			Client:  upgradingClient{version: "6.48.1", latest: "6.48.1", installs: true},
+			Package: "routeros-mipsbe-6.48.1.npk",
+			Steps: []string{
+				"/<mt-bulk>establish connection",
+				"/system resource print",
+				"/<mt-bulk>copy sftp://routeros-mipsbe-6.48.1.npk sftp://routeros-mipsbe-6.48.1.npk",
+				"/system reboot",
+				"/<mt-bulk>wait for device",
+				"/system resource print",
+				"/<mt-bulk>verify version",
+			},
+		},
+		{
+			Name:    "OK, upgrade by uploaded package",
+			Client:  upgradingClient{version: "7.1rc4", latest: "7.1", installs: true},
+			Package: "routeros-7.1-arm64.npk",
+			Steps: []string{
+				"/<mt-bulk>establish connection",
+				"/system resource print",
+				"/<mt-bulk>copy sftp://routeros-7.1-arm64.npk sftp://routeros-7.1-arm64.npk",
+				"/system reboot",
+				"/<mt-bulk>wait for device",
+				"/system resource print",
+				"/<mt-bulk>verify version",
+			},
+		},
+		{
+			Name:    "Wrong, downgrade by uploaded package",
+			Client:  upgradingClient{version: "6.48.1"},
+			Package: "routeros-mipsbe-6.47.npk",
+			Steps: []string{
+				"/<mt-bulk>establish connection",
+				"/system resource print",
+			},
+			Error: "downgrade from 6.48.1 to 6.47 not supported",
+		},
+		{
+			Name:    "Wrong, package without version",
+			Client:  upgradingClient{version: "6.48.1"},
+			Package: "routeros.npk",
+			Steps: []string{
+				"/<mt-bulk>establish connection",
+				"/system resource print",
+			},
+			Error: "can't determine RouterOS version of package routeros.npk, expected file name like routeros-mipsbe-6.48.1.npk",
+		},
+		{
+			Name:    "Failed, uploaded package not installed",
+			Client:  upgradingClient{version: "6.47", latest: "6.48.1"},
+			Package: "routeros-mipsbe-6.48.1.npk",
+			Steps: []string{
+				"/<mt-bulk>establish connection",
+				"/system resource print",
+				"/<mt-bulk>copy sftp://routeros-mipsbe-6.48.1.npk sftp://routeros-mipsbe-6.48.1.npk",
+				"/system reboot",
+				"/<mt-bulk>wait for device",
+				"/system resource print",
+			},
+			Error: "upgrade from 6.47 failed, running version 6.47",
+		},
+		{
+			Name:   "Failed",
+			Client: upgradingClient{version: "6.47", latest: "6.48.1"},
+			Steps: []string{
+				"/<mt-bulk>establish connection",
+				"/system resource print",
+				"/system package update check-for-updates",
+				"/system package update print",
+				"/system package update download",
+				"/system reboot",
+				"/<mt-bulk>wait for device",
+				"/system resource print",
+			},
+			Error: "upgrade from 6.47 failed, running version 6.47",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			job := entities.Job{Host: entities.Host{Password: "secret"}, Data: map[string]string{}}
+			if tc.Firmware {
+				job.Data["firmware"] = "true"
+			}
+			if tc.Package != "" {
+				job.Data["package"] = tc.Package
+			}
+
+			result := SystemUpgrade(context.Background(), zap.NewExample().Sugar(), &tc.Client, &job)
+
+			steps := make([]string, 0, len(result.Results))
+			for _, commandResult := range result.Results {
+				steps = append(steps, commandResult.Body)
+			}
+			if strings.Join(steps, "\n") != strings.Join(tc.Steps, "\n") {
+				t.Errorf("got:%v, expected:%v", steps, tc.Steps)
+			}
+
+			var err string
+			if len(result.Errors) > 0 {
+				err = result.Errors[0].Error()
+			}
+			if err != tc.Error {
+				t.Errorf("got:%v, expected:%v", err, tc.Error)
+			}
+		})
+	}
+}
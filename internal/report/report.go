@@ -0,0 +1,98 @@
+// Package report builds machine readable reports of security audits and CVE scans.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+	"github.com/migotom/mt-bulk/internal/vulnerabilities"
+)
+
+// Formats of reports.
+const (
+	FormatJSON  = "json"
+	FormatSARIF = "sarif"
+)
+
+// ToolName is name of tool generating reports.
+const ToolName = "mt-bulk"
+
+// Report is a report of processed jobs grouped by hosts.
+type Report struct {
+	Tool      string    `json:"tool"`
+	Version   string    `json:"version,omitempty"`
+	Generated time.Time `json:"generated"`
+	Hosts     []Host    `json:"hosts"`
+}
+
+// Host is a report of single job processed for host, findings are failed, waived or fixed rules.
+type Host struct {
+	Host                          string                      `json:"host"`
+	Kind                          string                      `json:"kind"`
+	Findings                      []entities.RuleResult       `json:"findings,omitempty"`
+	Vulnerabilities               []vulnerabilities.CVE       `json:"vulnerabilities,omitempty"`
+	WaivedVulnerabilities         []vulnerabilities.WaivedCVE `json:"waived_vulnerabilities,omitempty"`
+	BelowThresholdVulnerabilities []vulnerabilities.CVE       `json:"below_threshold_vulnerabilities,omitempty"`
+	Errors                        []string                    `json:"errors,omitempty"`
+}
+
+// New returns new empty report.
+func New(version string) *Report {
+	return &Report{Tool: ToolName, Version: version, Generated: time.Now(), Hosts: []Host{}}
+}
+
+// ValidFormat returns error if format of report is not supported.
+func ValidFormat(format string) error {
+	switch format {
+	case FormatJSON, FormatSARIF:
+		return nil
+	}
+	return fmt.Errorf("unknown report format %s, expected %s or %s", format, FormatJSON, FormatSARIF)
+}
+
+// Add adds result of processed job to report, results not related to any host are skipped.
+func (r *Report) Add(result entities.Result) {
+	if result.Job.Host.Equal(entities.Host{}) {
+		return
+	}
+
+	host := Host{Host: fmt.Sprintf("%s:%s", result.Job.Host.IP, result.Job.Host.Port), Kind: result.Job.Kind}
+	for _, rule := range result.Rules {
+		if !rule.Passed || rule.Fixed {
+			host.Findings = append(host.Findings, rule)
+		}
+	}
+	for _, err := range result.Errors {
+		if err == nil {
+			continue
+		}
+		if vul, ok := err.(vulnerabilities.VulnerabilityError); ok && len(vul.Vulnerabilities)+len(vul.Waived)+len(vul.BelowThreshold) > 0 {
+			host.Vulnerabilities = append(host.Vulnerabilities, vul.Vulnerabilities...)
+			host.WaivedVulnerabilities = append(host.WaivedVulnerabilities, vul.Waived...)
+			host.BelowThresholdVulnerabilities = append(host.BelowThresholdVulnerabilities, vul.BelowThreshold...)
+			continue
+		}
+		host.Errors = append(host.Errors, err.Error())
+	}
+
+	r.Hosts = append(r.Hosts, host)
+	sort.SliceStable(r.Hosts, func(i, j int) bool { return r.Hosts[i].Host < r.Hosts[j].Host })
+}
+
+// Write writes out report in given format.
+func (r Report) Write(w io.Writer, format string) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	switch format {
+	case FormatJSON:
+		return encoder.Encode(r)
+	case FormatSARIF:
+		return encoder.Encode(r.SARIF())
+	}
+	return ValidFormat(format)
+}
@@ -0,0 +1,142 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+	"github.com/migotom/mt-bulk/internal/vulnerabilities"
+)
+
+func auditResult() entities.Result {
+	return entities.Result{
+		Job: entities.Job{Host: entities.Host{IP: "10.0.0.1", Port: "22"}, Kind: "SecurityAudit"},
+		Rules: []entities.RuleResult{
+			{ID: "ssh-strong-crypto", Severity: "medium", Passed: true},
+			{ID: "romon", Description: "RoMON agent disabled", Severity: "medium", Message: "enabled RoMON agent"},
+			{ID: "snmp-public", Severity: "high", Message: "SNMP publicly available", Waived: true, Waiver: "monitoring"},
+			{ID: "upnp", Severity: "medium", Passed: true, Fixed: true},
+		},
+		Errors: []error{
+			errors.New("unsecure options found: enabled RoMON agent"),
+			vulnerabilities.VulnerabilityError{
+				Vulnerabilities: []vulnerabilities.CVE{
+					{ID: "CVE-2019-3976", CVSS: 6.5, Summary: "Relative path traversal", References: []string{"https://mikrotik.com/supportsec"}},
+				},
+				Waived: []vulnerabilities.WaivedCVE{
+					{CVE: vulnerabilities.CVE{ID: "CVE-2018-14847", CVSS: 9.1}, Waiver: entities.Waiver{ID: "CVE-2018-14847", Justification: "Winbox firewalled"}},
+				},
+				BelowThreshold: []vulnerabilities.CVE{{ID: "CVE-2017-7285", CVSS: 5.0}},
+			},
+		},
+	}
+}
+
+func TestReportAdd(t *testing.T) {
+	report := New("2.0")
+	report.Add(entities.Result{Errors: []error{errors.New("new version available")}})
+	report.Add(auditResult())
+
+	if len(report.Hosts) != 1 {
+		t.Fatalf("got:%v, expected single host", report.Hosts)
+	}
+	host := report.Hosts[0]
+
+	var findings []string
+	for _, finding := range host.Findings {
+		findings = append(findings, finding.ID)
+	}
+	if expected := []string{"romon", "snmp-public", "upnp"}; !reflect.DeepEqual(findings, expected) {
+		t.Errorf("got:%v, expected:%v", findings, expected)
+	}
+	if len(host.Vulnerabilities) != 1 || host.Vulnerabilities[0].ID != "CVE-2019-3976" {
+		t.Errorf("got:%v, expected CVE-2019-3976", host.Vulnerabilities)
+	}
+	if len(host.WaivedVulnerabilities) != 1 || len(host.BelowThresholdVulnerabilities) != 1 {
+		t.Errorf("got:%v %v, expected waived and below threshold CVE", host.WaivedVulnerabilities, host.BelowThresholdVulnerabilities)
+	}
+	if expected := []string{"unsecure options found: enabled RoMON agent"}; !reflect.DeepEqual(host.Errors, expected) {
+		t.Errorf("got:%v, expected:%v", host.Errors, expected)
+	}
+}
+
+func TestReportWrite(t *testing.T) {
+	report := New("2.0")
+	report.Add(auditResult())
+
+	t.Run("JSON", func(t *testing.T) {
+		var output bytes.Buffer
+		if err := report.Write(&output, FormatJSON); err != nil {
+			t.Fatalf("not expected error %v", err)
+		}
+
+		var decoded Report
+		if err := json.Unmarshal(output.Bytes(), &decoded); err != nil {
+			t.Fatalf("not expected error %v", err)
+		}
+		if decoded.Tool != ToolName || len(decoded.Hosts) != 1 || decoded.Hosts[0].Host != "10.0.0.1:22" {
+			t.Errorf("got:%v, expected report of 10.0.0.1:22", decoded)
+		}
+	})
+
+	t.Run("SARIF", func(t *testing.T) {
+		var output bytes.Buffer
+		if err := report.Write(&output, FormatSARIF); err != nil {
+			t.Fatalf("not expected error %v", err)
+		}
+
+		var decoded struct {
+			Version string `json:"version"`
+			Runs    []struct {
+				Tool struct {
+					Driver struct {
+						Rules []struct {
+							ID         string                 `json:"id"`
+							Properties map[string]interface{} `json:"properties"`
+						} `json:"rules"`
+					} `json:"driver"`
+				} `json:"tool"`
+				Results []struct {
+					RuleID       string        `json:"ruleId"`
+					Level        string        `json:"level"`
+					Suppressions []interface{} `json:"suppressions"`
+				} `json:"results"`
+			} `json:"runs"`
+		}
+		if err := json.Unmarshal(output.Bytes(), &decoded); err != nil {
+			t.Fatalf("not expected error %v", err)
+		}
+		if decoded.Version != "2.1.0" || len(decoded.Runs) != 1 {
+			t.Fatalf("got:%v, expected single SARIF 2.1.0 run", decoded)
+		}
+
+		results := decoded.Runs[0].Results
+		if len(results) != 5 {
+			t.Fatalf("got:%v, expected 5 results", results)
+		}
+		expected := []struct{ id, level string }{{"romon", "warning"}, {"snmp-public", "error"}, {"CVE-2019-3976", "warning"}, {"CVE-2018-14847", "error"}, {"CVE-2017-7285", "note"}}
+		for idx, e := range expected {
+			if results[idx].RuleID != e.id || results[idx].Level != e.level {
+				t.Errorf("got:%v, expected:%v", results[idx], e)
+			}
+		}
+		if len(results[1].Suppressions) != 1 {
+			t.Errorf("got:%v, expected suppressed waived rule", results[1])
+		}
+		if len(results[3].Suppressions) != 1 {
+			t.Errorf("got:%v, expected suppressed waived CVE", results[3])
+		}
+		if severity := decoded.Runs[0].Tool.Driver.Rules[2].Properties["security-severity"]; severity != "6.5" {
+			t.Errorf("got:%v, expected:6.5", severity)
+		}
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		if err := report.Write(&bytes.Buffer{}, "xml"); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+}
@@ -0,0 +1,205 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+	"github.com/migotom/mt-bulk/internal/rules"
+	"github.com/migotom/mt-bulk/internal/vulnerabilities"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolURI      = "https://github.com/migotom/mt-bulk"
+)
+
+// SARIF levels of results.
+const (
+	levelError   = "error"
+	levelWarning = "warning"
+	levelNote    = "note"
+)
+
+// SARIFLog is SARIF 2.1.0 log with single run of mt-bulk.
+type SARIFLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string                 `json:"id"`
+	ShortDescription     sarifMessage           `json:"shortDescription"`
+	FullDescription      *sarifMessage          `json:"fullDescription,omitempty"`
+	HelpURI              string                 `json:"helpUri,omitempty"`
+	DefaultConfiguration sarifConfiguration     `json:"defaultConfiguration"`
+	Properties           map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID       string             `json:"ruleId"`
+	RuleIndex    int                `json:"ruleIndex"`
+	Level        string             `json:"level"`
+	Message      sarifMessage       `json:"message"`
+	Locations    []sarifLocation    `json:"locations"`
+	Suppressions []sarifSuppression `json:"suppressions,omitempty"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+// SARIF returns report as SARIF 2.1.0 log, each failed rule and found CVE of host is reported as result located at host.
+// Results of waived rules and CVEs are suppressed, CVEs below CVSS threshold are notes, fixed rules and rules which couldn't be verified are not reported.
+func (r Report) SARIF() SARIFLog {
+	driver := sarifDriver{Name: r.Tool, Version: r.Version, InformationURI: toolURI, Rules: []sarifRule{}}
+	results := []sarifResult{}
+
+	indexes := make(map[string]int)
+	ruleIndex := func(rule sarifRule) int {
+		if idx, ok := indexes[rule.ID]; ok {
+			return idx
+		}
+		indexes[rule.ID] = len(driver.Rules)
+		driver.Rules = append(driver.Rules, rule)
+		return indexes[rule.ID]
+	}
+
+	for _, host := range r.Hosts {
+		locations := []sarifLocation{{LogicalLocations: []sarifLogicalLocation{{Name: host.Host, FullyQualifiedName: host.Host, Kind: "resource"}}}}
+
+		for _, finding := range host.Findings {
+			if finding.Fixed || finding.Error != "" {
+				continue
+			}
+
+			level := severityLevel(finding.Severity)
+			rule := sarifRule{
+				ID:                   finding.ID,
+				ShortDescription:     sarifMessage{Text: finding.ID},
+				DefaultConfiguration: sarifConfiguration{Level: level},
+				Properties:           map[string]interface{}{"tags": []string{"security", "audit"}, "severity": finding.Severity},
+			}
+			if finding.Description != "" {
+				rule.ShortDescription.Text = finding.Description
+			}
+
+			result := sarifResult{
+				RuleID:    finding.ID,
+				RuleIndex: ruleIndex(rule),
+				Level:     level,
+				Message:   sarifMessage{Text: finding.Message},
+				Locations: locations,
+			}
+			if result.Message.Text == "" {
+				result.Message.Text = rule.ShortDescription.Text
+			}
+			if finding.Waived {
+				result.Suppressions = []sarifSuppression{{Kind: "external", Justification: finding.Waiver}}
+			}
+			results = append(results, result)
+		}
+
+		for _, cve := range host.Vulnerabilities {
+			results = append(results, cveResult(cve, cvssLevel(cve.CVSS), locations, ruleIndex))
+		}
+		for _, cve := range host.WaivedVulnerabilities {
+			result := cveResult(cve.CVE, cvssLevel(cve.CVSS), locations, ruleIndex)
+			result.Suppressions = []sarifSuppression{{Kind: "external", Justification: cve.Waiver.Justification}}
+			results = append(results, result)
+		}
+		for _, cve := range host.BelowThresholdVulnerabilities {
+			results = append(results, cveResult(cve, levelNote, locations, ruleIndex))
+		}
+	}
+
+	return SARIFLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+}
+
+// cveResult returns result of CVE found at location of given level.
+func cveResult(cve vulnerabilities.CVE, level string, locations []sarifLocation, ruleIndex func(sarifRule) int) sarifResult {
+	rule := sarifRule{
+		ID:                   cve.ID,
+		ShortDescription:     sarifMessage{Text: cve.ID},
+		FullDescription:      &sarifMessage{Text: cve.Summary},
+		DefaultConfiguration: sarifConfiguration{Level: cvssLevel(cve.CVSS)},
+		Properties: map[string]interface{}{
+			"tags":              []string{"security", "cve"},
+			"security-severity": fmt.Sprintf("%.1f", cve.CVSS),
+			"references":        cve.References,
+		},
+	}
+	if len(cve.References) > 0 {
+		rule.HelpURI = cve.References[0]
+	}
+
+	return sarifResult{
+		RuleID:    cve.ID,
+		RuleIndex: ruleIndex(rule),
+		Level:     level,
+		Message:   sarifMessage{Text: fmt.Sprintf("%s (CVSS %.1f): %s", cve.ID, cve.CVSS, cve.Summary)},
+		Locations: locations,
+	}
+}
+
+// severityLevel returns SARIF level of rule's severity.
+func severityLevel(severity string) string {
+	switch level := rules.SeverityLevel(severity); {
+	case level >= rules.SeverityLevel(entities.SeverityHigh):
+		return levelError
+	case level >= rules.SeverityLevel(entities.SeverityMedium):
+		return levelWarning
+	}
+	return levelNote
+}
+
+// cvssLevel returns SARIF level of CVE's CVSS score.
+func cvssLevel(cvss float32) string {
+	switch {
+	case cvss >= 7:
+		return levelError
+	case cvss >= 4:
+		return levelWarning
+	}
+	return levelNote
+}
@@ -0,0 +1,121 @@
+package rules
+
+import (
+	_ "embed" // embedded built-in rule packs
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+//go:embed packs/security-audit.yml
+var securityAuditPack []byte
+
+// SecurityAuditPack returns built-in pack of security audit rules.
+func SecurityAuditPack() ([]entities.Rule, error) {
+	var pack struct {
+		Rules []entities.Rule `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(securityAuditPack, &pack); err != nil {
+		return nil, fmt.Errorf("can't load built-in security audit rules: %v", err)
+	}
+	return pack.Rules, nil
+}
+
+// Audit is configuration of security audit rules, built-in pack extended by user packs, rules of the same id override former ones.
+type Audit struct {
+	SkipBuiltin bool              `toml:"skip_builtin" yaml:"skip_builtin"`
+	Packs       []string          `toml:"packs" yaml:"packs"`
+	Enabled     []string          `toml:"enabled" yaml:"enabled"`
+	Disabled    []string          `toml:"disabled" yaml:"disabled"`
+	Waivers     []entities.Waiver `toml:"waivers" yaml:"waivers"`
+
+	// CVSSThreshold is minimal CVSS score of CVE failing audit, CVEs of lower score are only reported.
+	CVSSThreshold float32 `toml:"cvss_threshold" yaml:"cvss_threshold"`
+	// CVEWaivers accept CVEs found on hosts, waivers' ids are CVE ids.
+	CVEWaivers []entities.Waiver `toml:"cve_waivers" yaml:"cve_waivers"`
+
+	// Rules are enabled rules of loaded packs.
+	Rules []entities.Rule `toml:"-" yaml:"-"`
+}
+
+// Load loads rule packs and resolves list of enabled rules.
+func (a *Audit) Load() error {
+	var list []entities.Rule
+	if !a.SkipBuiltin {
+		builtin, err := SecurityAuditPack()
+		if err != nil {
+			return err
+		}
+		list = builtin
+	}
+	for _, file := range a.Packs {
+		pack, err := LoadFile(file)
+		if err != nil {
+			return err
+		}
+		list = merge(list, pack)
+	}
+	if err := Validate(list); err != nil {
+		return fmt.Errorf("invalid security audit rules: %v", err)
+	}
+
+	ids := make(map[string]*entities.Rule, len(list))
+	for idx := range list {
+		ids[list[idx].ID] = &list[idx]
+	}
+	for _, id := range a.Enabled {
+		rule, ok := ids[id]
+		if !ok {
+			return fmt.Errorf("enabled unknown security audit rule %s", id)
+		}
+		rule.Disabled = false
+	}
+	for _, id := range a.Disabled {
+		rule, ok := ids[id]
+		if !ok {
+			return fmt.Errorf("disabled unknown security audit rule %s", id)
+		}
+		rule.Disabled = true
+	}
+	for _, waiver := range append(a.Waivers, a.CVEWaivers...) {
+		if err := waiver.Validate(); err != nil {
+			return err
+		}
+	}
+	if a.CVSSThreshold < 0 || a.CVSSThreshold > 10 {
+		return fmt.Errorf("invalid CVSS threshold %.1f, expected score between 0 and 10", a.CVSSThreshold)
+	}
+
+	a.Rules = make([]entities.Rule, 0, len(list))
+	for _, rule := range list {
+		if !rule.Disabled {
+			a.Rules = append(a.Rules, rule)
+		}
+	}
+	return nil
+}
+
+// Waiver returns waiver accepting failure of rule with given id on host at given time.
+func (a Audit) Waiver(id, host string, now time.Time) (entities.Waiver, bool) {
+	return entities.FindWaiver(a.Waivers, id, host, now)
+}
+
+// merge returns list of rules extended by pack, rules of pack override rules of the same id.
+func merge(list, pack []entities.Rule) []entities.Rule {
+	ids := make(map[string]int, len(list))
+	for idx, rule := range list {
+		ids[rule.ID] = idx
+	}
+	for _, rule := range pack {
+		if idx, ok := ids[rule.ID]; ok {
+			list[idx] = rule
+			continue
+		}
+		ids[rule.ID] = len(list)
+		list = append(list, rule)
+	}
+	return list
+}
@@ -0,0 +1,106 @@
+package rules
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+func TestSecurityAuditPack(t *testing.T) {
+	list, err := SecurityAuditPack()
+	if err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	if err := Validate(list); err != nil {
+		t.Errorf("not expected error %v", err)
+	}
+	for _, rule := range list {
+		if !rule.Absent || rule.Message == "" {
+			t.Errorf("got:%v, expected absent rule with message", rule)
+		}
+	}
+}
+
+func TestAuditLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mt-bulk-audit")
+	if err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	pack := filepath.Join(dir, "site.yml")
+	content := `rules:
+  - id: ssh-strong-crypto
+    command: /ip ssh print
+    match: 'strong-crypto:\s+(no)'
+    absent: true
+    message: weak SSH crypto
+  - id: ntp-client
+    command: /system ntp client print
+    match: 'enabled:\s+(no)'
+    absent: true
+    disabled: true
+`
+	if err := ioutil.WriteFile(pack, []byte(content), 0600); err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+
+	cases := []struct {
+		Name          string
+		Audit         Audit
+		ExpectedRules []string
+		ExpectedError bool
+	}{
+		{Name: "OK, user pack only", Audit: Audit{SkipBuiltin: true, Packs: []string{pack}}, ExpectedRules: []string{"ssh-strong-crypto"}},
+		{Name: "OK, enabled rule", Audit: Audit{SkipBuiltin: true, Packs: []string{pack}, Enabled: []string{"ntp-client"}}, ExpectedRules: []string{"ssh-strong-crypto", "ntp-client"}},
+		{Name: "OK, disabled rule", Audit: Audit{SkipBuiltin: true, Packs: []string{pack}, Disabled: []string{"ssh-strong-crypto"}}, ExpectedRules: []string{}},
+		{Name: "Wrong, unknown rule", Audit: Audit{SkipBuiltin: true, Packs: []string{pack}, Disabled: []string{"telnet"}}, ExpectedError: true},
+		{Name: "Wrong, waiver", Audit: Audit{SkipBuiltin: true, Waivers: []entities.Waiver{{ID: "snmp-public", Expires: "tomorrow"}}}, ExpectedError: true},
+		{Name: "Wrong, CVE waiver", Audit: Audit{SkipBuiltin: true, CVEWaivers: []entities.Waiver{{ID: "CVE-2018-14847", Host: "[10.0.0.1"}}}, ExpectedError: true},
+		{Name: "Wrong, CVSS threshold", Audit: Audit{SkipBuiltin: true, CVSSThreshold: 11}, ExpectedError: true},
+		{Name: "Wrong, missing pack", Audit: Audit{Packs: []string{filepath.Join(dir, "missing.yml")}}, ExpectedError: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Audit.Load()
+			if (err != nil) != tc.ExpectedError {
+				t.Fatalf("got:%v, expected error:%v", err, tc.ExpectedError)
+			}
+			if tc.ExpectedError {
+				return
+			}
+
+			var ids []string
+			for _, rule := range tc.Audit.Rules {
+				ids = append(ids, rule.ID)
+			}
+			if len(ids) != len(tc.ExpectedRules) {
+				t.Fatalf("got:%v, expected:%v", ids, tc.ExpectedRules)
+			}
+			for idx := range ids {
+				if ids[idx] != tc.ExpectedRules[idx] {
+					t.Errorf("got:%v, expected:%v", ids, tc.ExpectedRules)
+				}
+			}
+		})
+	}
+
+	t.Run("Override built-in rule", func(t *testing.T) {
+		audit := Audit{Packs: []string{pack}}
+		if err := audit.Load(); err != nil {
+			t.Fatalf("not expected error %v", err)
+		}
+		builtin, _ := SecurityAuditPack()
+		if len(audit.Rules) != len(builtin) {
+			t.Errorf("got:%v, expected:%v rules", len(audit.Rules), len(builtin))
+		}
+		for _, rule := range audit.Rules {
+			if rule.ID == "ssh-strong-crypto" && rule.Message != "weak SSH crypto" {
+				t.Errorf("got:%v, expected overridden rule", rule)
+			}
+		}
+	})
+}
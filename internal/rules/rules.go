@@ -0,0 +1,192 @@
+// Package rules verifies declarative rules describing desired state of devices.
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/migotom/mt-bulk/internal/clients"
+	"github.com/migotom/mt-bulk/internal/config"
+	"github.com/migotom/mt-bulk/internal/console"
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+// matchPrefix is prefix of keys of values matched by rule's expression.
+const matchPrefix = "rule"
+
+var severities = map[string]int{
+	entities.SeverityInfo:     0,
+	entities.SeverityLow:      1,
+	entities.SeverityMedium:   2,
+	entities.SeverityHigh:     3,
+	entities.SeverityCritical: 4,
+}
+
+// SeverityLevel returns level of severity, higher is more severe, rule without severity is of medium one.
+func SeverityLevel(severity string) int {
+	if severity == "" {
+		return severities[entities.SeverityMedium]
+	}
+	if level, ok := severities[severity]; ok {
+		return level
+	}
+	return -1
+}
+
+// LoadFile loads list of rules from YAML or TOML file.
+func LoadFile(file string) ([]entities.Rule, error) {
+	if file == "" {
+		return nil, errors.New("rules file not specified")
+	}
+
+	var pack struct {
+		Rules []entities.Rule `toml:"rules" yaml:"rules"`
+	}
+	if err := config.LoadConfigFile(&pack, file); err != nil {
+		return nil, fmt.Errorf("can't load rules %s: %v", file, err)
+	}
+	if err := Validate(pack.Rules); err != nil {
+		return nil, fmt.Errorf("invalid rules %s: %v", file, err)
+	}
+	return pack.Rules, nil
+}
+
+// Validate verifies that rules are complete, have unique IDs, known severities and valid match expressions.
+func Validate(rules []entities.Rule) error {
+	ids := make(map[string]struct{}, len(rules))
+	for idx, rule := range rules {
+		if rule.ID == "" {
+			return fmt.Errorf("rule #%d without id", idx)
+		}
+		if _, ok := ids[rule.ID]; ok {
+			return fmt.Errorf("duplicated rule %s", rule.ID)
+		}
+		ids[rule.ID] = struct{}{}
+
+		if rule.Command == "" || rule.Match == "" {
+			return fmt.Errorf("rule %s without command or match expression", rule.ID)
+		}
+		if SeverityLevel(rule.Severity) < 0 {
+			return fmt.Errorf("rule %s of unknown severity %s", rule.ID, rule.Severity)
+		}
+		if _, err := entities.ParseVersionRange(rule.Versions); err != nil {
+			return fmt.Errorf("rule %s: %v", rule.ID, err)
+		}
+		if rule.Parse != "" {
+			if _, err := console.Parse(rule.Parse, ""); err != nil {
+				return fmt.Errorf("rule %s: %v", rule.ID, err)
+			}
+		}
+		if _, err := match(rule); err != nil {
+			return fmt.Errorf("rule %s: %v", rule.ID, err)
+		}
+	}
+	return nil
+}
+
+// Evaluate verifies rule on connected device, returns rule's result and results of executed commands.
+func Evaluate(ctx context.Context, client clients.Client, rule entities.Rule) (entities.RuleResult, []entities.CommandResult) {
+	result := entities.RuleResult{ID: rule.ID, Description: rule.Description, Severity: rule.Severity}
+	if result.Severity == "" {
+		result.Severity = entities.SeverityMedium
+	}
+
+	expression, err := match(rule)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	commandResults, matches, err := clients.ExecuteCommands(ctx, client, []entities.Command{
+		{Body: rule.Command, Parse: rule.Parse, MatchPrefix: matchPrefix, Match: expression},
+	})
+	if err != nil {
+		result.Error = err.Error()
+		return result, commandResults
+	}
+
+	result.Evidence = evidence(matches)
+	result.Passed = (len(result.Evidence) > 0) != rule.Absent
+	if !result.Passed {
+		result.Message = message(rule, result.Evidence)
+	}
+	if len(result.Evidence) == 0 {
+		result.Evidence = []string{fmt.Sprintf("%q not found in output of %s", rule.Match, rule.Command)}
+	}
+	return result, commandResults
+}
+
+// Applicable returns true if rule applies to given RouterOS version, rules with invalid versions range are not applicable.
+func Applicable(rule entities.Rule, version entities.Version) bool {
+	versions, err := entities.ParseVersionRange(rule.Versions)
+	return err == nil && versions.Contains(version)
+}
+
+// Remediation returns remediation commands of rule with `%{evidence}` substituted by comma separated values matched by rule.
+func Remediation(rule entities.Rule, evidence []string) []entities.Command {
+	commands := make([]entities.Command, 0, len(rule.Remediation))
+	for _, command := range rule.Remediation {
+		command.Body = strings.ReplaceAll(command.Body, "%{evidence}", strings.Join(evidence, ","))
+		commands = append(commands, command)
+	}
+	return commands
+}
+
+// message returns message of failed rule with substituted evidence, by default rule's description.
+func message(rule entities.Rule, evidence []string) string {
+	switch {
+	case rule.Message != "":
+		return strings.ReplaceAll(rule.Message, "%{evidence}", strings.Join(evidence, " "))
+	case rule.Description != "":
+		return rule.Description
+	}
+	return rule.ID
+}
+
+// match returns match expression of rule as used by clients.ExecuteCommands, regexp without groups matches as a whole.
+func match(rule entities.Rule) (string, error) {
+	if strings.HasPrefix(rule.Match, clients.RecordMatchPrefix) {
+		_, err := clients.ParseRecordMatch(rule.Match)
+		return rule.Match, err
+	}
+
+	rx, err := regexp.Compile(rule.Match)
+	if err != nil {
+		return "", err
+	}
+	if rx.NumSubexp() == 0 {
+		return "(" + rule.Match + ")", nil
+	}
+	return rule.Match, nil
+}
+
+// evidence returns values matched by rule's expression in order of matching.
+func evidence(matches map[string]string) []string {
+	keyRx := regexp.MustCompile(`^\(%\{` + matchPrefix + `(\d+)\}\)$`)
+
+	type value struct {
+		order int
+		value string
+	}
+	values := make([]value, 0, len(matches))
+	for key, matched := range matches {
+		m := keyRx.FindStringSubmatch(key)
+		if m == nil {
+			continue
+		}
+		order, _ := strconv.Atoi(m[1])
+		values = append(values, value{order: order, value: strings.TrimSpace(matched)})
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].order < values[j].order })
+
+	list := make([]string, 0, len(values))
+	for _, v := range values {
+		list = append(list, v.value)
+	}
+	return list
+}
@@ -0,0 +1,118 @@
+package rules
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/migotom/mt-bulk/internal/clients/mocks"
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+type outputClient struct {
+	mocks.Client
+
+	output string
+}
+
+func (c outputClient) RunCmd(val string, re *regexp.Regexp) (string, error) {
+	return c.output, nil
+}
+
+func TestLoadFile(t *testing.T) {
+	list, err := LoadFile("../../examples/rules/compliance.example.yml")
+	if err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	if len(list) != 4 || list[0].ID != "ntp-client" || len(list[0].Remediation) != 1 {
+		t.Errorf("got:%v, expected 4 rules", list)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		Name          string
+		Rules         []entities.Rule
+		ExpectedError bool
+	}{
+		{Name: "OK", Rules: []entities.Rule{{ID: "a", Command: "/ip ssh print", Match: `strong-crypto:\s+yes`}, {ID: "b", Command: "/ip dns print", Parse: "print", Match: "record:servers", Severity: "high"}}},
+		{Name: "Wrong, missing id", Rules: []entities.Rule{{Command: "/ip ssh print", Match: "yes"}}, ExpectedError: true},
+		{Name: "Wrong, duplicated id", Rules: []entities.Rule{{ID: "a", Command: "/ip ssh print", Match: "yes"}, {ID: "a", Command: "/ip ssh print", Match: "no"}}, ExpectedError: true},
+		{Name: "Wrong, missing match", Rules: []entities.Rule{{ID: "a", Command: "/ip ssh print"}}, ExpectedError: true},
+		{Name: "Wrong, severity", Rules: []entities.Rule{{ID: "a", Command: "/ip ssh print", Match: "yes", Severity: "urgent"}}, ExpectedError: true},
+		{Name: "Wrong, parse format", Rules: []entities.Rule{{ID: "a", Command: "/ip ssh print", Match: "yes", Parse: "json"}}, ExpectedError: true},
+		{Name: "Wrong, versions", Rules: []entities.Rule{{ID: "a", Command: "/ip ssh print", Match: "yes", Versions: ">=6.x"}}, ExpectedError: true},
+		{Name: "Wrong, regexp", Rules: []entities.Rule{{ID: "a", Command: "/ip ssh print", Match: "(yes"}}, ExpectedError: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := Validate(tc.Rules)
+			if (err != nil) != tc.ExpectedError {
+				t.Errorf("got:%v, expected error:%v", err, tc.ExpectedError)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	output := "  strong-crypto: yes\r\n  forwarding-enabled: no\r\n"
+
+	cases := []struct {
+		Name     string
+		Rule     entities.Rule
+		Expected entities.RuleResult
+	}{
+		{
+			Name:     "OK, regexp without group",
+			Rule:     entities.Rule{ID: "ssh", Command: "/ip ssh print", Match: `strong-crypto:\s+yes`},
+			Expected: entities.RuleResult{ID: "ssh", Severity: "medium", Passed: true, Evidence: []string{"strong-crypto: yes"}},
+		},
+		{
+			Name:     "OK, record match",
+			Rule:     entities.Rule{ID: "ssh", Severity: "high", Command: "/ip ssh print", Parse: "print", Match: "record:strong-crypto"},
+			Expected: entities.RuleResult{ID: "ssh", Severity: "high", Passed: true, Evidence: []string{"yes"}},
+		},
+		{
+			Name:     "OK, absent",
+			Rule:     entities.Rule{ID: "fwd", Command: "/ip ssh print", Match: `forwarding-enabled:\s+(both|local|remote)`, Absent: true},
+			Expected: entities.RuleResult{ID: "fwd", Severity: "medium", Passed: true, Evidence: []string{`"forwarding-enabled:\\s+(both|local|remote)" not found in output of /ip ssh print`}},
+		},
+		{
+			Name:     "Wrong, not matching",
+			Rule:     entities.Rule{ID: "fwd", Command: "/ip ssh print", Parse: "print", Match: "record:forwarding-enabled where forwarding-enabled=both"},
+			Expected: entities.RuleResult{ID: "fwd", Severity: "medium", Message: "fwd", Evidence: []string{`"record:forwarding-enabled where forwarding-enabled=both" not found in output of /ip ssh print`}},
+		},
+		{
+			Name:     "Wrong, absent",
+			Rule:     entities.Rule{ID: "ssh", Command: "/ip ssh print", Match: `strong-crypto:\s+(yes)`, Absent: true},
+			Expected: entities.RuleResult{ID: "ssh", Severity: "medium", Message: "ssh", Evidence: []string{"yes"}},
+		},
+		{
+			Name:     "Wrong, absent with message",
+			Rule:     entities.Rule{ID: "ssh", Command: "/ip ssh print", Match: `strong-crypto:\s+(yes)`, Absent: true, Message: "strong-crypto [%{evidence}]"},
+			Expected: entities.RuleResult{ID: "ssh", Severity: "medium", Message: "strong-crypto [yes]", Evidence: []string{"yes"}},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			result, _ := Evaluate(context.Background(), outputClient{output: output}, tc.Rule)
+			if !reflect.DeepEqual(result, tc.Expected) {
+				t.Errorf("got:%v, expected:%v", result, tc.Expected)
+			}
+		})
+	}
+}
+
+func TestRemediation(t *testing.T) {
+	rule := entities.Rule{ID: "services", Remediation: []entities.Command{{Body: "/ip service disable %{evidence}"}, {Body: "/ip service print"}}}
+
+	commands := Remediation(rule, []string{"telnet", "ftp", "www"})
+	expected := []entities.Command{{Body: "/ip service disable telnet,ftp,www"}, {Body: "/ip service print"}}
+	if !reflect.DeepEqual(commands, expected) {
+		t.Errorf("got:%v, expected:%v", commands, expected)
+	}
+	if rule.Remediation[0].Body != "/ip service disable %{evidence}" {
+		t.Errorf("rule's remediation modified: %v", rule.Remediation)
+	}
+}
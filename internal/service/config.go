@@ -1,7 +1,9 @@
 package service
 
 import (
+	"github.com/migotom/mt-bulk/internal/backups"
 	"github.com/migotom/mt-bulk/internal/clients"
+	"github.com/migotom/mt-bulk/internal/rules"
 	"github.com/migotom/mt-bulk/internal/vulnerabilities"
 )
 
@@ -22,4 +24,7 @@ type Config struct {
 
 	CVEURLs vulnerabilities.CVEURLs `toml:"cve_urls" yaml:"cve_urls"`
 	Clients clients.Clients         `toml:"clients" yaml:"clients"`
+	Backups backups.Retention       `toml:"backups" yaml:"backups"`
+
+	SecurityAudit rules.Audit `toml:"security_audit" yaml:"security_audit"`
 }
@@ -0,0 +1,41 @@
+package mtbulkrestapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/migotom/mt-bulk/internal/backups"
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+// BackupsHandler lists history of backups of device.
+func (mtbulk *MTbulkRESTGateway) BackupsHandler(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		host := entities.Host{IP: mux.Vars(r)["host"]}
+		if err := host.Parse(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := mtbulk.AuthorizeRequest(r, &entities.Job{Host: host}); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		list, err := backups.NewIndex(mtbulk.kv).List(host.IP)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if list == nil {
+			list = []backups.Backup{}
+		}
+
+		if err := json.NewEncoder(w).Encode(&list); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
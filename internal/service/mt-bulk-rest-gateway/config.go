@@ -1,6 +1,7 @@
 package mtbulkrestapi
 
 import (
+	"github.com/migotom/mt-bulk/internal/driver"
 	"github.com/migotom/mt-bulk/internal/service"
 )
 
@@ -12,5 +13,10 @@ type Config struct {
 	KeyStore      string         `toml:"keys_store" yaml:"keys_store"`
 	TokenSecret   string         `toml:"token_secret" yaml:"token_secret"`
 	Authenticate  []Authenticate `toml:"authenticate" yaml:"authenticate"`
-	Service       service.Config `toml:"service" yaml:"service"`
+
+	// HostsFile is inventory of hosts targeted by jobs' selectors.
+	HostsFile string             `toml:"hosts_file" yaml:"hosts_file"`
+	Hosts     driver.HostsConfig `toml:"hosts" yaml:"hosts"`
+
+	Service service.Config `toml:"service" yaml:"service"`
 }
@@ -1,14 +1,22 @@
 package mtbulkrestapi
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"sort"
 
 	"go.uber.org/zap"
 
+	"github.com/migotom/mt-bulk/internal/driver"
 	"github.com/migotom/mt-bulk/internal/entities"
 	"github.com/migotom/mt-bulk/internal/kvdb"
+	"github.com/migotom/mt-bulk/internal/mode"
+	"github.com/migotom/mt-bulk/internal/report"
 	"github.com/migotom/mt-bulk/internal/service"
 )
 
@@ -50,54 +58,158 @@ func (mtbulk *MTbulkRESTGateway) RunWorkers(ctx context.Context, cancel context.
 }
 
 // JobHandler parses job request and process it with pool of workers.
+// Job with selector is processed for each matching host of hosts inventory and list of results is returned.
 func (mtbulk *MTbulkRESTGateway) JobHandler(ctx context.Context) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var job entities.Job
 
+		format := r.URL.Query().Get("report")
+		if format != "" {
+			if err := report.ValidFormat(format); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
 		decoder := json.NewDecoder(r.Body)
 		if err := decoder.Decode(&job); err != nil {
 			http.Error(w, "Bad request", http.StatusBadRequest)
 			return
 		}
-		job.Host.Parse()
-
-		if err := mtbulk.AuthorizeRequest(r, &job); err != nil {
-			http.Error(w, err.Error(), http.StatusUnauthorized)
+		// accepting changed host keys bypasses verification of SSH host keys, so it is allowed only to mt-bulk
+		if job.Kind == mode.AcceptHostKeyMode {
+			http.Error(w, fmt.Sprintf("job kind %s not allowed by REST API", job.Kind), http.StatusBadRequest)
 			return
 		}
 
 		id := r.Context().Value("id").(string)
-		mtbulk.sugar.Infow("processing job", "commands", job.Commands, "id", id)
-
-		resultChan := make(chan entities.Result)
 		if job.Data == nil {
 			job.Data = make(map[string]string)
 		}
 		job.Data["root_directory"] = mtbulk.RootDirectory
-		job.Result = resultChan
 		job.ID = id
 
-		// send job to workers
-		select {
-		case <-r.Context().Done():
-			http.Error(w, "request cancelled by host", http.StatusGone)
-			return
-		case mtbulk.Service.Jobs <- job:
+		jobs := []entities.Job{job}
+		if job.Selector != "" {
+			var err error
+			if jobs, err = mtbulk.selectJobs(r.Context(), job); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		} else {
+			jobs[0].Host.Parse()
 		}
 
-		// fetch result
-		select {
-		case <-r.Context().Done():
-			http.Error(w, "request cancelled by host", http.StatusGone)
-			return
-		case result := <-resultChan:
-			if result.Errors != nil {
-				w.WriteHeader(http.StatusNotAcceptable)
+		for idx := range jobs {
+			if err := mtbulk.AuthorizeRequest(r, &jobs[idx]); err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+		}
+
+		mtbulk.sugar.Infow("processing job", "commands", job.Commands, "selector", job.Selector, "hosts", len(jobs), "id", id)
+
+		// each job gets own result channel closed by worker, buffered so workers never block on results of cancelled request
+		for idx := range jobs {
+			jobs[idx].Result = make(chan entities.Result, 1)
+
+			// send job to workers
+			select {
+			case <-r.Context().Done():
+				http.Error(w, "request cancelled by host", http.StatusGone)
+				return
+			case mtbulk.Service.Jobs <- jobs[idx]:
 			}
+		}
 
-			if err := json.NewEncoder(w).Encode(&result); err != nil {
+		// fetch results
+		results := make([]entities.Result, 0, len(jobs))
+		var failed bool
+		for idx := range jobs {
+			select {
+			case <-r.Context().Done():
+				http.Error(w, "request cancelled by host", http.StatusGone)
+				return
+			case result := <-jobs[idx].Result:
+				failed = failed || result.Failed()
+				results = append(results, result)
+			}
+		}
+		sortResults(results)
+		if failed {
+			w.WriteHeader(http.StatusNotAcceptable)
+		}
+
+		if format != "" {
+			audit := report.New(mtbulk.Config.Service.Version)
+			for _, result := range results {
+				audit.Add(result)
+			}
+			if err := audit.Write(w, format); err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 			}
+			return
+		}
+
+		var response interface{} = &results[0]
+		if job.Selector != "" {
+			response = results
 		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// selectJobs returns copies of job for each host of hosts inventory matching job's selector.
+func (mtbulk *MTbulkRESTGateway) selectJobs(ctx context.Context, job entities.Job) ([]entities.Job, error) {
+	if mtbulk.HostsFile == "" {
+		return nil, errors.New("hosts inventory not configured, selector not allowed")
+	}
+	if job.Host.IP != "" {
+		return nil, errors.New("job with selector can't define host")
+	}
+
+	jobs, err := driver.FileLoadJobs(ctx, job, mtbulk.HostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("can't load hosts inventory: %v", err)
+	}
+
+	config := mtbulk.Hosts
+	config.Select = job.Selector
+	jobs, _, err = driver.FilterJobs(ctx, jobs, config, mtbulk.Config.Service.Clients.SSH.DefaultPort)
+	if err != nil {
+		return nil, err
 	}
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("no hosts matching selector %s", job.Selector)
+	}
+
+	// each job is processed concurrently, so it gets own id and copy of data
+	for idx := range jobs {
+		jobs[idx].ID = fmt.Sprintf("%s-%d", job.ID, idx)
+		jobs[idx].Data = make(map[string]string, len(job.Data))
+		for key, value := range job.Data {
+			jobs[idx].Data[key] = value
+		}
+	}
+	return jobs, nil
+}
+
+// sortResults sorts results by address of host.
+func sortResults(results []entities.Result) {
+	ipKey := func(IP string) []byte {
+		if parsed := net.ParseIP(IP); parsed != nil {
+			return parsed.To16()
+		}
+		return []byte(IP)
+	}
+
+	sort.SliceStable(results, func(a, b int) bool {
+		hostA, hostB := results[a].Job.Host, results[b].Job.Host
+		if compared := bytes.Compare(ipKey(hostA.IP), ipKey(hostB.IP)); compared != 0 {
+			return compared < 0
+		}
+		return hostA.Port < hostB.Port
+	})
 }
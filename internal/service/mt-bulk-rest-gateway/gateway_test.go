@@ -0,0 +1,128 @@
+package mtbulkrestapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+	"github.com/migotom/mt-bulk/internal/service"
+)
+
+// processJobs processes jobs like service workers, result channel of each job is closed after sending result.
+func processJobs(ctx context.Context, jobs <-chan entities.Job) {
+	for job := range jobs {
+		go func(job entities.Job) {
+			result := entities.Result{Job: job, Results: []entities.CommandResult{{Body: job.Host.IP, Responses: []string{job.ID, job.Data["host"]}}}}
+			if job.Host.IP == "10.0.0.2" {
+				result.Errors = []error{errors.New("can't connect")}
+			}
+			job.Data["host"] = job.Host.IP
+
+			select {
+			case <-ctx.Done():
+				return
+			case job.Result <- result:
+			}
+			close(job.Result)
+		}(job)
+	}
+}
+
+func TestJobHandlerSelector(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mt-bulk-gateway")
+	if err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	hostsFile := filepath.Join(dir, "hosts.yml")
+	hosts := `host:
+  - ip: "10.0.0.3"
+    tags: ["site=waw"]
+  - ip: "10.0.0.1"
+    tags: ["site=waw"]
+  - ip: "10.0.0.2"
+    tags: ["site=waw"]
+  - ip: "10.0.1.1"
+    tags: ["site=krk"]
+`
+	if err := ioutil.WriteFile(hostsFile, []byte(hosts), 0600); err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gateway := &MTbulkRESTGateway{
+		Service: &service.Service{Jobs: make(chan entities.Job)},
+		sugar:   zap.NewNop().Sugar(),
+		Config:  Config{HostsFile: hostsFile},
+	}
+	go processJobs(ctx, gateway.Service.Jobs)
+
+	request := httptest.NewRequest(http.MethodPost, "/job", strings.NewReader(`{"kind": "CustomSSH", "selector": "site=waw", "data": {"vlan": "100"}}`))
+	requestCtx := context.WithValue(request.Context(), "id", "request")
+	requestCtx = context.WithValue(requestCtx, "claims", TokenClaims{AllowedHostPatterns: []string{".*"}})
+	response := httptest.NewRecorder()
+	gateway.JobHandler(ctx)(response, request.WithContext(requestCtx))
+
+	if response.Code != http.StatusNotAcceptable {
+		t.Errorf("got:%v, expected:%v", response.Code, http.StatusNotAcceptable)
+	}
+
+	var results []struct {
+		Results []struct {
+			Body      string   `json:"body"`
+			Responses []string `json:"responses"`
+		} `json:"results"`
+		Errors []string `json:"errors"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&results); err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+
+	var got [][]string
+	IDs := make(map[string]bool)
+	for _, result := range results {
+		if len(result.Results) != 1 {
+			t.Fatalf("got:%v, expected single command result", result)
+		}
+		got = append(got, []string{result.Results[0].Body, result.Results[0].Responses[1], strings.Join(result.Errors, ",")})
+		IDs[result.Results[0].Responses[0]] = true
+	}
+	expected := [][]string{{"10.0.0.1", "", ""}, {"10.0.0.2", "", "can't connect"}, {"10.0.0.3", "", ""}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got:%v, expected:%v", got, expected)
+	}
+	if len(IDs) != len(expected) {
+		t.Errorf("got:%v, expected unique ids of jobs", IDs)
+	}
+}
+
+func TestJobHandlerAcceptHostKey(t *testing.T) {
+	gateway := &MTbulkRESTGateway{
+		Service: &service.Service{Jobs: make(chan entities.Job)},
+		sugar:   zap.NewNop().Sugar(),
+	}
+
+	request := httptest.NewRequest(http.MethodPost, "/job", strings.NewReader(`{"kind": "AcceptHostKey", "host": {"ip": "10.0.0.1"}}`))
+	requestCtx := context.WithValue(request.Context(), "id", "request")
+	requestCtx = context.WithValue(requestCtx, "claims", TokenClaims{AllowedHostPatterns: []string{".*"}})
+	response := httptest.NewRecorder()
+	gateway.JobHandler(context.Background())(response, request.WithContext(requestCtx))
+
+	if response.Code != http.StatusBadRequest {
+		t.Errorf("got:%v, expected:%v", response.Code, http.StatusBadRequest)
+	}
+}
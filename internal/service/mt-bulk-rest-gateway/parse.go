@@ -2,6 +2,7 @@ package mtbulkrestapi
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -17,6 +18,8 @@ func configParser(arguments map[string]interface{}, version string) (mtbulkConfi
 	mtbulkConfig.Service = service.NewConfig(version)
 	mtbulkConfig.Service.Clients.SSH = clients.NewConfig(clients.SSHDefaultPort)
 	mtbulkConfig.Service.Clients.MikrotikAPI = clients.NewConfig(clients.MikrotikAPIDefaultPort)
+	mtbulkConfig.Service.Clients.MikrotikAPIPlain = clients.NewConfig(clients.MikrotikAPIPlainDefaultPort)
+	mtbulkConfig.Service.Clients.REST = clients.NewConfig(clients.RESTDefaultPort)
 
 	configFileName, _ := arguments["-C"].(string)
 	if err := config.LoadConfigFile(&mtbulkConfig, configFileName); err != nil {
@@ -41,9 +44,15 @@ func configParser(arguments map[string]interface{}, version string) (mtbulkConfi
 	if mtbulkConfig.Service.CVEURLs.DB == "" {
 		mtbulkConfig.Service.CVEURLs.DB = vulnerabilities.CVEURL
 	}
-	if mtbulkConfig.Service.CVEURLs.DBInfo == "" {
+	if mtbulkConfig.Service.CVEURLs.DBInfo == "" && !vulnerabilities.IsFileURL(mtbulkConfig.Service.CVEURLs.DB) {
 		mtbulkConfig.Service.CVEURLs.DBInfo = vulnerabilities.CVEURLDBInfo
 	}
+	if err := mtbulkConfig.Hosts.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid hosts configuration: %v", err)
+	}
+	if err := mtbulkConfig.Service.SecurityAudit.Load(); err != nil {
+		return Config{}, err
+	}
 
 	var needGenerateCerts bool
 	if _, err := os.Stat(filepath.FromSlash(filepath.Join(mtbulkConfig.KeyStore, "rest-api.crt"))); os.IsNotExist(err) {
@@ -0,0 +1,354 @@
+package mtbulk
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/migotom/mt-bulk/internal/backups"
+	"github.com/migotom/mt-bulk/internal/clients"
+	"github.com/migotom/mt-bulk/internal/entities"
+	"github.com/migotom/mt-bulk/internal/inventory"
+	"github.com/migotom/mt-bulk/internal/kvdb"
+	"github.com/migotom/mt-bulk/internal/rules"
+	"github.com/migotom/mt-bulk/internal/vulnerabilities"
+)
+
+type databaseCommandFunc func(kv kvdb.KV) error
+
+// databaseCommands executes operations working directly on MT-bulk database, returns true if any of them was requested.
+func databaseCommands(sugar *zap.SugaredLogger, arguments map[string]interface{}, config Config) (bool, error) {
+	var command databaseCommandFunc
+
+	hosts, _ := arguments["<hosts>"].([]string)
+
+	if m, _ := arguments["known-hosts"].(bool); m {
+		if l, _ := arguments["list"].(bool); l {
+			command = knownHostsList(config.Service.Clients.SSH)
+		}
+		if r, _ := arguments["revoke"].(bool); r {
+			command = knownHostsRevoke(config.Service.Clients.SSH, hosts)
+		}
+	}
+
+	if m, _ := arguments["api-certs"].(bool); m {
+		if l, _ := arguments["list"].(bool); l {
+			command = deviceCertificatesList()
+		}
+		if r, _ := arguments["revoke"].(bool); r {
+			command = deviceCertificatesRevoke(hosts)
+		}
+	}
+
+	if m, _ := arguments["backups"].(bool); m {
+		if l, _ := arguments["list"].(bool); l {
+			command = backupsList(hosts)
+		}
+		if p, _ := arguments["prune"].(bool); p {
+			command = backupsPrune(config.Service.Backups, hosts)
+		}
+	}
+
+	if m, _ := arguments["cve"].(bool); m {
+		if i, _ := arguments["import"].(bool); i {
+			file, _ := arguments["<file>"].(string)
+			command = cveImport(sugar, file)
+		}
+		if s, _ := arguments["status"].(bool); s {
+			command = cveStatus(sugar, config.Service.CVEURLs)
+		}
+
+		waiver := entities.Waiver{}
+		waiver.ID, _ = arguments["<id>"].(string)
+		waiver.Host, _ = arguments["--host"].(string)
+		waiver.Expires, _ = arguments["--expires"].(string)
+		waiver.Justification, _ = arguments["--justification"].(string)
+
+		if w, _ := arguments["waive"].(bool); w {
+			command = cveWaive(sugar, waiver)
+		}
+		if u, _ := arguments["unwaive"].(bool); u {
+			command = cveUnwaive(sugar, waiver)
+		}
+		if l, _ := arguments["waivers"].(bool); l {
+			command = cveWaivers(sugar, config.Service.SecurityAudit)
+		}
+	}
+
+	if m, _ := arguments["inventory"].(bool); m {
+		format, ok := arguments["--format"].(string)
+		if !ok {
+			format = inventory.FormatCSV
+		}
+		output, _ := arguments["--output"].(string)
+		command = inventoryExport(format, output, hosts)
+	}
+
+	if command == nil {
+		return false, nil
+	}
+
+	kv, err := kvdb.OpenKV(sugar, config.Service.KVStore)
+	if err != nil {
+		return true, fmt.Errorf("creating cache KV store:%s", err)
+	}
+	defer kv.Close()
+
+	return true, command(kv)
+}
+
+func knownHostsList(sshConfig clients.Config) databaseCommandFunc {
+	return func(kv kvdb.KV) error {
+		list, err := clients.NewKnownHosts(kv, sshConfig.KnownHostsFile).List()
+		if err != nil {
+			return err
+		}
+
+		for _, knownHost := range list {
+			fmt.Println(knownHost)
+		}
+		return nil
+	}
+}
+
+func knownHostsRevoke(sshConfig clients.Config, hosts []string) databaseCommandFunc {
+	return func(kv kvdb.KV) error {
+		knownHosts := clients.NewKnownHosts(kv, sshConfig.KnownHostsFile)
+
+		for _, entry := range hosts {
+			host := entities.Host{IP: entry}
+			if err := host.Parse(); err != nil {
+				return err
+			}
+			host.SetDefaults(sshConfig.DefaultPort, sshConfig.DefaultUser, sshConfig.DefaultPassword)
+
+			address := clients.KnownHostAddress(host.IP, host.Port)
+			if err := knownHosts.Remove(address); err != nil {
+				return fmt.Errorf("can't revoke host key of %s: %v", address, err)
+			}
+			fmt.Printf("Revoked host key of %s\n", address)
+		}
+		return nil
+	}
+}
+
+func deviceCertificatesList() databaseCommandFunc {
+	return func(kv kvdb.KV) error {
+		list, err := clients.NewDeviceCertificates(kv).List()
+		if err != nil {
+			return err
+		}
+
+		for _, certificate := range list {
+			fmt.Println(certificate)
+		}
+		return nil
+	}
+}
+
+func deviceCertificatesRevoke(hosts []string) databaseCommandFunc {
+	return func(kv kvdb.KV) error {
+		deviceCertificates := clients.NewDeviceCertificates(kv)
+
+		for _, entry := range hosts {
+			host := entities.Host{IP: entry}
+			if err := host.Parse(); err != nil {
+				return err
+			}
+
+			if err := deviceCertificates.Revoke(host.IP); err != nil {
+				return fmt.Errorf("can't revoke certificate of %s: %v", host.IP, err)
+			}
+			fmt.Printf("Revoked certificate of %s\n", host.IP)
+		}
+		return nil
+	}
+}
+
+func backupsList(hosts []string) databaseCommandFunc {
+	return func(kv kvdb.KV) error {
+		IPs, err := backupsHosts(hosts)
+		if err != nil {
+			return err
+		}
+
+		index := backups.NewIndex(kv)
+		for _, IP := range IPs {
+			list, err := index.List(IP)
+			if err != nil {
+				return err
+			}
+
+			for _, backup := range list {
+				fmt.Println(backup)
+			}
+		}
+		return nil
+	}
+}
+
+func backupsPrune(retention backups.Retention, hosts []string) databaseCommandFunc {
+	return func(kv kvdb.KV) error {
+		if !retention.Enabled() {
+			return fmt.Errorf("backups retention policy not configured")
+		}
+
+		IPs, err := backupsHosts(hosts)
+		if err != nil {
+			return err
+		}
+
+		index := backups.NewIndex(kv)
+		for _, IP := range IPs {
+			pruned, err := backups.Prune(index, retention, IP, time.Now())
+			for _, backup := range pruned {
+				fmt.Printf("Pruned backup %s\n", backup)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func cveImport(sugar *zap.SugaredLogger, file string) databaseCommandFunc {
+	return func(kv kvdb.KV) error {
+		format, count, err := vulnerabilities.NewManager(sugar, nil, kv).Import(file)
+		if err != nil {
+			return fmt.Errorf("can't import CVEs from %s: %v", file, err)
+		}
+		fmt.Printf("Imported %d CVEs affecting RouterOS from %s feed %s\n", count, format, file)
+		return nil
+	}
+}
+
+func cveStatus(sugar *zap.SugaredLogger, cveURLs vulnerabilities.CVEURLs) databaseCommandFunc {
+	return func(kv kvdb.KV) error {
+		status, err := vulnerabilities.NewManager(sugar, nil, kv).Status()
+		if err != nil {
+			return err
+		}
+
+		if status.LastUpdate.IsZero() {
+			fmt.Println("Last update: never")
+		} else {
+			fmt.Printf("Last update: %s (%s ago)\n", status.LastUpdate.Format(time.RFC3339), time.Since(status.LastUpdate).Round(time.Minute))
+		}
+		fmt.Printf("Database version: %d (required %d)\n", status.Version, vulnerabilities.RequiredKVDBVersion)
+		fmt.Printf("CVEs: %d\n", status.CVEs)
+		fmt.Printf("Vulnerable versions: %d\n", status.Versions)
+		fmt.Printf("Source: %s\n", cveURLs.DB)
+		if cveURLs.DBInfo != "" {
+			fmt.Printf("Source timestamps: %s\n", cveURLs.DBInfo)
+		}
+		return nil
+	}
+}
+
+func cveWaive(sugar *zap.SugaredLogger, waiver entities.Waiver) databaseCommandFunc {
+	return func(kv kvdb.KV) error {
+		if err := vulnerabilities.NewManager(sugar, nil, kv).Waive(waiver); err != nil {
+			return fmt.Errorf("can't waive %s: %v", waiver.ID, err)
+		}
+		fmt.Printf("Waived %s\n", formatWaiver(waiver))
+		return nil
+	}
+}
+
+func cveUnwaive(sugar *zap.SugaredLogger, waiver entities.Waiver) databaseCommandFunc {
+	return func(kv kvdb.KV) error {
+		if err := vulnerabilities.NewManager(sugar, nil, kv).Unwaive(waiver.ID, waiver.Host); err != nil {
+			return fmt.Errorf("can't unwaive %s: %v", waiver.ID, err)
+		}
+		fmt.Printf("Removed waiver of %s\n", waiver.ID)
+		return nil
+	}
+}
+
+func cveWaivers(sugar *zap.SugaredLogger, audit rules.Audit) databaseCommandFunc {
+	return func(kv kvdb.KV) error {
+		waivers, err := vulnerabilities.NewManager(sugar, nil, kv).Waivers()
+		if err != nil {
+			return err
+		}
+
+		for _, waiver := range audit.CVEWaivers {
+			fmt.Printf("%s (configuration)\n", formatWaiver(waiver))
+		}
+		for _, waiver := range waivers {
+			fmt.Println(formatWaiver(waiver))
+		}
+		return nil
+	}
+}
+
+// formatWaiver returns waiver as single line description.
+func formatWaiver(waiver entities.Waiver) string {
+	host, expires := waiver.Host, waiver.Expires
+	if host == "" {
+		host = "*"
+	}
+	if expires == "" {
+		expires = "never"
+	}
+	return fmt.Sprintf("%s host:%s expires:%s justification:%q", waiver.ID, host, expires, waiver.Justification)
+}
+
+// inventoryExport writes stored facts of given hosts (all hosts if none given) in given format to output file or stdout.
+func inventoryExport(format, output string, hosts []string) databaseCommandFunc {
+	return func(kv kvdb.KV) error {
+		list, err := inventory.New(kv).List()
+		if err != nil {
+			return err
+		}
+
+		if len(hosts) > 0 {
+			IPs, err := backupsHosts(hosts)
+			if err != nil {
+				return err
+			}
+
+			selected := make(map[string]bool, len(IPs))
+			for _, IP := range IPs {
+				selected[IP] = true
+			}
+
+			filtered := list[:0]
+			for _, facts := range list {
+				if selected[facts.Host] {
+					filtered = append(filtered, facts)
+				}
+			}
+			list = filtered
+		}
+
+		if output == "" {
+			return inventory.Write(os.Stdout, format, list)
+		}
+
+		return writeFile(output, func(w io.Writer) error {
+			return inventory.Write(w, format, list)
+		})
+	}
+}
+
+// backupsHosts returns IP addresses of given hosts, or single empty address standing for all hosts if none given.
+func backupsHosts(hosts []string) ([]string, error) {
+	if len(hosts) == 0 {
+		return []string{""}, nil
+	}
+
+	list := make([]string, 0, len(hosts))
+	for _, entry := range hosts {
+		host := entities.Host{IP: entry}
+		if err := host.Parse(); err != nil {
+			return nil, err
+		}
+		list = append(list, host.IP)
+	}
+	return list, nil
+}
@@ -1,6 +1,7 @@
 package mtbulk
 
 import (
+	"github.com/migotom/mt-bulk/internal/discovery"
 	"github.com/migotom/mt-bulk/internal/driver"
 	"github.com/migotom/mt-bulk/internal/entities"
 	"github.com/migotom/mt-bulk/internal/service"
@@ -11,11 +12,19 @@ type Config struct {
 	Version     int  `toml:"version" yaml:"version"`
 	Verbose     bool `toml:"verbose" yaml:"verbose"`
 	SkipSummary bool `toml:"skip_summary" yaml:"skip_summary"`
+	JSON        bool `toml:"json" yaml:"json"`
 
-	Service           service.Config  `toml:"service" yaml:"service"`
-	DB                driver.DBConfig `toml:"db" yaml:"db"`
-	CustomSSHSequence *CustomSequence `toml:"custom-ssh" yaml:"custom-ssh"`
-	CustomAPISequence *CustomSequence `toml:"custom-api" yaml:"custom-api"`
+	Report     string `toml:"report" yaml:"report"`
+	ReportFile string `toml:"report_file" yaml:"report_file"`
+
+	Hosts     driver.HostsConfig `toml:"hosts" yaml:"hosts"`
+	Discovery discovery.Config   `toml:"discovery" yaml:"discovery"`
+
+	Service            service.Config  `toml:"service" yaml:"service"`
+	DB                 driver.DBConfig `toml:"db" yaml:"db"`
+	CustomSSHSequence  *CustomSequence `toml:"custom-ssh" yaml:"custom-ssh"`
+	CustomAPISequence  *CustomSequence `toml:"custom-api" yaml:"custom-api"`
+	CustomRESTSequence *CustomSequence `toml:"custom-rest" yaml:"custom-rest"`
 }
 
 // CustomSequence is sequence of custom commands.
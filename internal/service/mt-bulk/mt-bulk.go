@@ -2,14 +2,20 @@ package mtbulk
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"reflect"
 	"strings"
 	"sync"
 
+	"github.com/migotom/mt-bulk/internal/discovery"
+	"github.com/migotom/mt-bulk/internal/driver"
 	"github.com/migotom/mt-bulk/internal/entities"
 	"github.com/migotom/mt-bulk/internal/kvdb"
 	"github.com/migotom/mt-bulk/internal/mode"
+	"github.com/migotom/mt-bulk/internal/report"
 	"github.com/migotom/mt-bulk/internal/service"
 	"github.com/migotom/mt-bulk/internal/vulnerabilities"
 
@@ -38,6 +44,10 @@ func NewMTbulk(sugar *zap.SugaredLogger, arguments map[string]interface{}, versi
 		return &MTbulk{}, fmt.Errorf("configuration parser:%s", err)
 	}
 
+	if handled, err := databaseCommands(sugar, arguments, config); handled {
+		return nil, err
+	}
+
 	if reflect.DeepEqual(entities.Job{}, jobTemplate) {
 		return nil, nil
 	}
@@ -70,42 +80,156 @@ func (mtbulk *MTbulk) LoadJobs(ctx context.Context) {
 			Kind: mode.CheckMTbulkVersionMode,
 		})
 	}
+
+	loadedJobs := make([]entities.Job, 0, 256)
 	for _, jobsLoader := range mtbulk.jobsLoaders {
 		jobs, err := jobsLoader(ctx, mtbulk.jobTemplate)
 		if err != nil {
 			mtbulk.Results <- entities.Result{Errors: []error{err}}
 			break
 		}
-		jobsToProcess = append(jobsToProcess, jobs...)
+		loadedJobs = append(loadedJobs, jobs...)
+	}
+
+	loadedJobs, unreachable, err := driver.FilterJobs(ctx, loadedJobs, mtbulk.Hosts, mtbulk.Config.Service.Clients.SSH.DefaultPort)
+	if err != nil {
+		mtbulk.Results <- entities.Result{Errors: []error{err}}
+	}
+	for _, host := range unreachable {
+		mtbulk.sugar.Infow("skipping unreachable host", "host", host.IP, "port", host.Port)
+	}
+	jobsToProcess = append(jobsToProcess, loadedJobs...)
+
+	// discovery crawler extends list of jobs by neighbors found by processed ones
+	var crawler *discovery.Crawler
+	if mtbulk.jobTemplate.Kind == mode.DiscoverMode {
+		var err error
+		if crawler, err = discovery.NewCrawler(mtbulk.Discovery); err != nil {
+			mtbulk.Results <- entities.Result{Errors: []error{err}}
+			return
+		}
+		defer mtbulk.writeDiscovery(crawler)
 	}
 
 	wgJobs := new(sync.WaitGroup)
+	var process func(job entities.Job)
+	process = func(job entities.Job) {
+		defer wgJobs.Done()
+
+		job.ID = xid.New().String()
+		job.Result = make(chan entities.Result)
+
+		select {
+		case <-ctx.Done():
+		case mtbulk.Service.Jobs <- job:
+		}
+
+		select {
+		case <-ctx.Done():
+		case result := <-job.Result:
+			if crawler != nil && job.Kind == mode.DiscoverMode {
+				for _, next := range mtbulk.discoveredJobs(ctx, crawler, result) {
+					wgJobs.Add(1)
+					go process(next)
+				}
+			}
+			mtbulk.Results <- result
+		}
+	}
+
 	for _, job := range jobsToProcess {
+		if crawler != nil && job.Kind == mode.DiscoverMode {
+			crawler.Seed(job.Host)
+		}
 		wgJobs.Add(1)
-		go func(job entities.Job) {
-			defer wgJobs.Done()
+		go process(job)
+	}
+	wgJobs.Wait()
+}
 
-			job.ID = xid.New().String()
-			job.Result = make(chan entities.Result)
+// discoveredJobs returns jobs visiting neighbors found by crawler in result, neighbors are filtered by exclusions, selector and probe of hosts configuration.
+func (mtbulk *MTbulk) discoveredJobs(ctx context.Context, crawler *discovery.Crawler, result entities.Result) []entities.Job {
+	hosts := crawler.Add(result)
+	jobs := make([]entities.Job, 0, len(hosts))
+	for _, host := range hosts {
+		job := mtbulk.jobTemplate
+		job.Host = host
+		jobs = append(jobs, job)
+	}
 
-			select {
-			case <-ctx.Done():
-			case mtbulk.Service.Jobs <- job:
-			}
+	jobs, unreachable, err := driver.FilterJobs(ctx, jobs, mtbulk.Hosts, mtbulk.Config.Service.Clients.SSH.DefaultPort)
+	if err != nil {
+		mtbulk.sugar.Errorw("can't filter discovered hosts", "error", err)
+		jobs = nil
+	}
+	for _, host := range unreachable {
+		mtbulk.sugar.Infow("skipping unreachable host", "host", host.IP, "port", host.Port)
+	}
 
-			select {
-			case <-ctx.Done():
-			case result := <-job.Result:
-				mtbulk.Results <- result
+	visited := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		visited[job.Host.IP] = true
+	}
+	for _, host := range hosts {
+		if !visited[host.IP] {
+			crawler.Skip(host)
+		}
+	}
+	return jobs
+}
+
+// writeDiscovery writes out hosts and topology discovered by crawler to configured files.
+func (mtbulk *MTbulk) writeDiscovery(crawler *discovery.Crawler) {
+	if file := mtbulk.Discovery.HostsFile; file != "" {
+		err := writeFile(file, func(w io.Writer) error {
+			return discovery.WriteHosts(w, discovery.HostsFormat(file), crawler.Hosts())
+		})
+		if err != nil {
+			mtbulk.sugar.Errorw("can't write discovered hosts", "file", file, "error", err)
+			mtbulk.Status.SetCode(1)
+		}
+	}
+
+	if file := mtbulk.Discovery.TopologyFile; file != "" {
+		err := writeFile(file, func(w io.Writer) error {
+			format, err := discovery.TopologyFormat(file)
+			if err != nil {
+				return err
 			}
-		}(job)
+			return crawler.Topology().Write(w, format)
+		})
+		if err != nil {
+			mtbulk.sugar.Errorw("can't write topology", "file", file, "error", err)
+			mtbulk.Status.SetCode(1)
+		}
 	}
-	wgJobs.Wait()
+}
+
+// writeFile creates file and writes its content by given function.
+func writeFile(name string, write func(io.Writer) error) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
 }
 
 // ResponseCollector collects and prints out results of processed jobs.
 func (mtbulk *MTbulk) ResponseCollector(ctx context.Context) {
-	hostsErrors := make(map[entities.Host][]error)
+	// errors are grouped by device's address, generic errors by empty one
+	hostsErrors := make(map[string][]error)
+	// waived CVEs and CVEs below threshold are reported without failing
+	failed := false
+
+	var audit *report.Report
+	if mtbulk.Report != "" {
+		audit = report.New(mtbulk.Config.Service.Version)
+		defer mtbulk.writeReport(audit)
+	}
 
 collectorLooop:
 	for {
@@ -117,10 +241,23 @@ collectorLooop:
 				break collectorLooop
 			}
 			if result.Errors != nil {
-				hostsErrors[result.Job.Host] = append(hostsErrors[result.Job.Host], result.Errors...)
+				address := ""
+				if result.Job.Host.IP != "" {
+					address = fmt.Sprintf("%s:%s", result.Job.Host.IP, result.Job.Host.Port)
+				}
+				hostsErrors[address] = append(hostsErrors[address], result.Errors...)
+			}
+			failed = failed || result.Failed()
+			if audit != nil {
+				audit.Add(result)
+			}
+
+			if mtbulk.JSON && !result.Job.Host.Equal(entities.Host{}) {
+				printJSON(result)
+				continue
 			}
 
-			if (mtbulk.Verbose && result.Job.Host != entities.Host{}) {
+			if mtbulk.Verbose && !result.Job.Host.Equal(entities.Host{}) {
 				fmt.Printf("%s > /// job: \"%s\"\n", result.Job.Host, result.Job.Kind)
 				for _, commandResult := range result.Results {
 					for _, response := range commandResult.Responses {
@@ -138,9 +275,11 @@ collectorLooop:
 		return
 	}
 
-	mtbulk.Status.SetCode(1)
+	if failed {
+		mtbulk.Status.SetCode(1)
+	}
 
-	if mtbulk.SkipSummary {
+	if mtbulk.SkipSummary || mtbulk.JSON || (mtbulk.Report != "" && mtbulk.ReportFile == "") {
 		return
 	}
 
@@ -148,9 +287,9 @@ collectorLooop:
 
 	fmt.Println()
 	fmt.Println("Errors list:")
-	for host, errors := range hostsErrors {
-		if host.IP != "" {
-			fmt.Printf("Device: %s:%s\n", host.IP, host.Port)
+	for address, errors := range hostsErrors {
+		if address != "" {
+			fmt.Printf("Device: %s\n", address)
 		} else {
 			fmt.Println("Generic:")
 		}
@@ -160,7 +299,7 @@ collectorLooop:
 				continue
 			}
 			fmt.Printf("\t%s\n", err)
-			if vul, ok := err.(vulnerabilities.VulnerabilityError); ok && vul.Vulnerabilities != nil {
+			if _, ok := err.(vulnerabilities.VulnerabilityError); ok {
 				vulnerabilitiesDetected = true
 			}
 		}
@@ -169,14 +308,88 @@ collectorLooop:
 	if vulnerabilitiesDetected {
 		cves := ExtractCVEs(hostsErrors)
 
-		fmt.Println()
-		fmt.Println("Deetected CVE:")
-		for _, cve := range cves {
-			fmt.Printf("%s\n", cve)
+		if len(cves.New) > 0 {
+			fmt.Println()
+			fmt.Println("Detected CVE:")
+			for _, cve := range cves.New {
+				fmt.Printf("%s\n", cve)
+			}
+		}
+		if len(cves.Waived) > 0 {
+			fmt.Println()
+			fmt.Println("Waived CVE:")
+			for _, cve := range cves.Waived {
+				fmt.Printf("%s", cve.CVE)
+				fmt.Printf("- waived: %s", cve.Waiver.Justification)
+				if cve.Waiver.Expires != "" {
+					fmt.Printf(" (expires %s)", cve.Waiver.Expires)
+				}
+				fmt.Println()
+			}
+		}
+		if len(cves.BelowThreshold) > 0 {
+			fmt.Println()
+			fmt.Println("CVE below CVSS threshold:")
+			for _, cve := range cves.BelowThreshold {
+				fmt.Printf("%s\n", cve)
+			}
 		}
 	}
 }
 
+// writeReport writes out report to configured file or standard output.
+func (mtbulk *MTbulk) writeReport(audit *report.Report) {
+	output := os.Stdout
+	if mtbulk.ReportFile != "" {
+		file, err := os.Create(mtbulk.ReportFile)
+		if err != nil {
+			mtbulk.sugar.Errorw("can't create report file", "file", mtbulk.ReportFile, "error", err)
+			mtbulk.Status.SetCode(1)
+			return
+		}
+		defer file.Close()
+		output = file
+	}
+
+	if err := audit.Write(output, mtbulk.Report); err != nil {
+		mtbulk.sugar.Errorw("can't write report", "error", err)
+		mtbulk.Status.SetCode(1)
+	}
+}
+
+// printJSON prints out result of processed job as single line JSON document.
+func printJSON(result entities.Result) {
+	var errors []string
+	for _, err := range result.Errors {
+		if err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+
+	output, err := json.Marshal(&struct {
+		Host    string                   `json:"host"`
+		Kind    string                   `json:"kind"`
+		Results []entities.CommandResult `json:"results,omitempty"`
+		Rules   []entities.RuleResult    `json:"rules,omitempty"`
+		Drift   bool                     `json:"drift,omitempty"`
+		Facts   *entities.Facts          `json:"facts,omitempty"`
+		Errors  []string                 `json:"errors,omitempty"`
+	}{
+		Host:    result.Job.Host.String(),
+		Kind:    result.Job.Kind,
+		Results: result.Results,
+		Rules:   result.Rules,
+		Drift:   result.Drift,
+		Facts:   result.Facts,
+		Errors:  errors,
+	})
+	if err != nil {
+		fmt.Printf("{\"host\":%q,\"errors\":[%q]}\n", result.Job.Host.String(), err.Error())
+		return
+	}
+	fmt.Println(string(output))
+}
+
 // Listen runs service workers and process all provided jobs.
 // Returns after process of all jobs.
 func (mtbulk *MTbulk) Listen(ctx context.Context, cancel context.CancelFunc) {
@@ -207,9 +420,24 @@ func (app *ApplicationStatus) Get() int {
 	return app.code
 }
 
-// ExtractCVEs extracts list of unique CVEs from list of hosts' errors.
-func ExtractCVEs(hostsErrors map[entities.Host][]error) []vulnerabilities.CVE {
-	cves := make([]vulnerabilities.CVE, 0, len(hostsErrors))
+// CVEs is list of unique CVEs found on hosts grouped by triage, CVE waived on some hosts may be new on other ones.
+type CVEs struct {
+	New            []vulnerabilities.CVE
+	Waived         []vulnerabilities.WaivedCVE
+	BelowThreshold []vulnerabilities.CVE
+}
+
+// ExtractCVEs extracts lists of unique new, waived and below CVSS threshold CVEs from list of hosts' errors.
+func ExtractCVEs(hostsErrors map[string][]error) CVEs {
+	var cves CVEs
+	known := make(map[string]bool)
+	unique := func(group, ID string) bool {
+		if known[group+ID] {
+			return false
+		}
+		known[group+ID] = true
+		return true
+	}
 
 	for _, errors := range hostsErrors {
 		for _, err := range errors {
@@ -218,17 +446,22 @@ func ExtractCVEs(hostsErrors map[entities.Host][]error) []vulnerabilities.CVE {
 				continue
 			}
 
-		vulnerabilitiesScan:
 			for _, cve := range vul.Vulnerabilities {
-				for _, storedCve := range cves {
-					if storedCve.ID == cve.ID {
-						continue vulnerabilitiesScan
-					}
+				if unique("new", cve.ID) {
+					cves.New = append(cves.New, cve)
+				}
+			}
+			for _, cve := range vul.Waived {
+				if unique("waived", cve.ID) {
+					cves.Waived = append(cves.Waived, cve)
+				}
+			}
+			for _, cve := range vul.BelowThreshold {
+				if unique("below", cve.ID) {
+					cves.BelowThreshold = append(cves.BelowThreshold, cve)
 				}
-				cves = append(cves, cve)
 			}
 		}
-
 	}
 	return cves
 }
@@ -4,12 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/migotom/mt-bulk/internal/clients"
 	"github.com/migotom/mt-bulk/internal/config"
 	"github.com/migotom/mt-bulk/internal/driver"
 	"github.com/migotom/mt-bulk/internal/entities"
 	"github.com/migotom/mt-bulk/internal/mode"
+	"github.com/migotom/mt-bulk/internal/report"
+	"github.com/migotom/mt-bulk/internal/rules"
 	"github.com/migotom/mt-bulk/internal/service"
 	"github.com/migotom/mt-bulk/internal/vulnerabilities"
 )
@@ -20,12 +26,41 @@ func configParser(arguments map[string]interface{}, version string) (mtbulkConfi
 	mtbulkConfig.Service = service.NewConfig(version)
 	mtbulkConfig.Service.Clients.SSH = clients.NewConfig(clients.SSHDefaultPort)
 	mtbulkConfig.Service.Clients.MikrotikAPI = clients.NewConfig(clients.MikrotikAPIDefaultPort)
+	mtbulkConfig.Service.Clients.MikrotikAPIPlain = clients.NewConfig(clients.MikrotikAPIPlainDefaultPort)
+	mtbulkConfig.Service.Clients.REST = clients.NewConfig(clients.RESTDefaultPort)
 
 	configFileName, _ := arguments["-C"].(string)
 	if err := config.LoadConfigFile(&mtbulkConfig, configFileName); err != nil {
 		return Config{}, nil, entities.Job{}, err
 	}
 
+	if jsonOutput, _ := arguments["--json"].(bool); jsonOutput {
+		mtbulkConfig.JSON = true
+	}
+	if format, ok := arguments["--report"].(string); ok {
+		mtbulkConfig.Report = format
+	}
+	if file, ok := arguments["--report-file"].(string); ok {
+		mtbulkConfig.ReportFile = file
+	}
+	if mtbulkConfig.Report != "" {
+		if err := report.ValidFormat(mtbulkConfig.Report); err != nil {
+			return Config{}, nil, entities.Job{}, err
+		}
+	}
+	if exclude, ok := arguments["--exclude"].(string); ok {
+		mtbulkConfig.Hosts.Exclude = append(mtbulkConfig.Hosts.Exclude, strings.Split(exclude, ",")...)
+	}
+	if probe, _ := arguments["--probe"].(bool); probe {
+		mtbulkConfig.Hosts.Probe = true
+	}
+	if selector, ok := arguments["--select"].(string); ok {
+		mtbulkConfig.Hosts.Select = selector
+	}
+	if err := mtbulkConfig.Hosts.Validate(); err != nil {
+		return Config{}, nil, entities.Job{}, fmt.Errorf("invalid hosts configuration: %v", err)
+	}
+
 	if mtbulkConfig.Version < 2 {
 		return Config{}, nil, entities.Job{}, errors.New("incompatible configuration version, required version 2 or above")
 	}
@@ -35,17 +70,17 @@ func configParser(arguments map[string]interface{}, version string) (mtbulkConfi
 	if mtbulkConfig.Service.CVEURLs.DB == "" {
 		mtbulkConfig.Service.CVEURLs.DB = vulnerabilities.CVEURL
 	}
-	if mtbulkConfig.Service.CVEURLs.DBInfo == "" {
+	if mtbulkConfig.Service.CVEURLs.DBInfo == "" && !vulnerabilities.IsFileURL(mtbulkConfig.Service.CVEURLs.DB) {
 		mtbulkConfig.Service.CVEURLs.DBInfo = vulnerabilities.CVEURLDBInfo
 	}
+	if err := mtbulkConfig.Service.SecurityAudit.Load(); err != nil {
+		return Config{}, nil, entities.Job{}, err
+	}
 
 	if gen, _ := arguments["gen-api-certs"].(bool); gen {
 		if err := clients.GenerateCA(mtbulkConfig.Service.Clients.MikrotikAPI.KeyStore); err != nil {
 			return Config{}, nil, entities.Job{}, err
 		}
-		if err := clients.GenerateCerts(mtbulkConfig.Service.Clients.MikrotikAPI.KeyStore, "device"); err != nil {
-			return Config{}, nil, entities.Job{}, err
-		}
 		if err := clients.GenerateCerts(mtbulkConfig.Service.Clients.MikrotikAPI.KeyStore, "client"); err != nil {
 			return Config{}, nil, entities.Job{}, err
 		}
@@ -58,7 +93,8 @@ func configParser(arguments map[string]interface{}, version string) (mtbulkConfi
 		return Config{}, nil, entities.Job{}, nil
 	}
 
-	if m, _ := arguments["init-secure-api"].(bool); m {
+	reissue, _ := arguments["reissue"].(bool)
+	if m, _ := arguments["init-secure-api"].(bool); m || reissue {
 		jobTemplate = entities.Job{
 			Kind: mode.InitSecureAPIMode,
 			Data: map[string]string{"keys_directory": mtbulkConfig.Service.Clients.MikrotikAPI.KeyStore},
@@ -83,6 +119,10 @@ func configParser(arguments map[string]interface{}, version string) (mtbulkConfi
 			Data: map[string]string{"new_password": newPass},
 		}
 
+		if plain, _ := arguments["--plain-api"].(bool); plain {
+			jobTemplate.Kind = mode.ChangePasswordAPIPlainMode
+		}
+
 		user, ok := arguments["--user"].(string)
 		if ok {
 			jobTemplate.Data["user"] = user
@@ -120,6 +160,36 @@ func configParser(arguments map[string]interface{}, version string) (mtbulkConfi
 		}
 
 	}
+	if m, _ := arguments["custom-api-plain"].(bool); m {
+		if mtbulkConfig.CustomAPISequence == nil {
+			return Config{}, nil, entities.Job{}, fmt.Errorf("missing custom-api.command sequence in configuration")
+		}
+		if f, ok := arguments["--commands-file"].(string); ok {
+			mtbulkConfig.CustomAPISequence.Command = nil
+			if err := config.LoadConfigFile(mtbulkConfig.CustomAPISequence, f); err != nil {
+				return Config{}, nil, entities.Job{}, err
+			}
+		}
+		jobTemplate = entities.Job{
+			Kind:     mode.CustomAPIPlainMode,
+			Commands: mtbulkConfig.CustomAPISequence.Command,
+		}
+	}
+	if m, _ := arguments["custom-rest"].(bool); m {
+		if mtbulkConfig.CustomRESTSequence == nil {
+			return Config{}, nil, entities.Job{}, fmt.Errorf("missing custom-rest.command sequence in configuration")
+		}
+		if f, ok := arguments["--commands-file"].(string); ok {
+			mtbulkConfig.CustomRESTSequence.Command = nil
+			if err := config.LoadConfigFile(mtbulkConfig.CustomRESTSequence, f); err != nil {
+				return Config{}, nil, entities.Job{}, err
+			}
+		}
+		jobTemplate = entities.Job{
+			Kind:     mode.CustomRESTMode,
+			Commands: mtbulkConfig.CustomRESTSequence.Command,
+		}
+	}
 
 	if m, _ := arguments["sftp"].(bool); m {
 		source, ok := arguments["<source>"].(string)
@@ -155,10 +225,158 @@ func configParser(arguments map[string]interface{}, version string) (mtbulkConfi
 		}
 	}
 
+	if m, _ := arguments["system-restore"].(bool); m {
+		file, ok := arguments["--file"].(string)
+		if !ok {
+			return Config{}, nil, entities.Job{}, fmt.Errorf("missing backup or configuration file")
+		}
+
+		data := map[string]string{"file": file}
+		if password, ok := arguments["--password"].(string); ok {
+			data["password"] = password
+		}
+		if waitTimeout, ok := arguments["--wait-timeout"].(string); ok {
+			if _, err := time.ParseDuration(waitTimeout); err != nil {
+				return Config{}, nil, entities.Job{}, fmt.Errorf("invalid wait timeout: %v", err)
+			}
+			data["wait_timeout"] = waitTimeout
+		}
+
+		jobTemplate = entities.Job{
+			Kind: mode.SystemRestoreMode,
+			Data: data,
+		}
+	}
+
+	if m, _ := arguments["system-upgrade"].(bool); m {
+		data := make(map[string]string)
+		if channel, ok := arguments["--channel"].(string); ok {
+			data["channel"] = channel
+		}
+		if packageFile, ok := arguments["--package"].(string); ok {
+			data["package"] = packageFile
+		}
+		if firmware, _ := arguments["--firmware"].(bool); firmware {
+			data["firmware"] = "true"
+		}
+		if waitTimeout, ok := arguments["--wait-timeout"].(string); ok {
+			if _, err := time.ParseDuration(waitTimeout); err != nil {
+				return Config{}, nil, entities.Job{}, fmt.Errorf("invalid wait timeout: %v", err)
+			}
+			data["wait_timeout"] = waitTimeout
+		}
+
+		jobTemplate = entities.Job{
+			Kind: mode.SystemUpgradeMode,
+			Data: data,
+		}
+	}
+
+	if m, _ := arguments["config-diff"].(bool); m {
+		data := make(map[string]string)
+		if hideSensitive, _ := arguments["--hide-sensitive"].(bool); hideSensitive {
+			data["hide_sensitive"] = "true"
+		}
+		if fromBackups, _ := arguments["--from-backups"].(bool); fromBackups {
+			data["baseline"] = "backups"
+		}
+		if ignore, ok := arguments["--ignore"].(string); ok {
+			if _, err := regexp.Compile(ignore); err != nil {
+				return Config{}, nil, entities.Job{}, fmt.Errorf("invalid ignore expression: %v", err)
+			}
+			data["ignore"] = ignore
+		}
+
+		jobTemplate = entities.Job{
+			Kind: mode.ConfigDiffMode,
+			Data: data,
+		}
+	}
+
+	if m, _ := arguments["compliance"].(bool); m {
+		rulesFile, _ := arguments["--rules"].(string)
+		complianceRules, err := rules.LoadFile(rulesFile)
+		if err != nil {
+			return Config{}, nil, entities.Job{}, err
+		}
+
+		data := make(map[string]string)
+		if fix, _ := arguments["--fix"].(bool); fix {
+			data["fix"] = "true"
+		}
+
+		jobTemplate = entities.Job{
+			Kind:  mode.ComplianceMode,
+			Data:  data,
+			Rules: complianceRules,
+		}
+	}
+
 	if m, _ := arguments["security-audit"].(bool); m {
+		data := make(map[string]string)
+		fix, _ := arguments["--fix"].(bool)
+		dryRun, _ := arguments["--dry-run"].(bool)
+		switch {
+		case dryRun && !fix:
+			return Config{}, nil, entities.Job{}, errors.New("--dry-run previews remediation and requires --fix")
+		case dryRun:
+			data["fix"] = mode.RemediationPreview
+		case fix:
+			data["fix"] = mode.RemediationApply
+		}
+
 		jobTemplate = entities.Job{
 			Kind: mode.SecurityAuditMode,
+			Data: data,
+		}
+	}
+
+	if m, _ := arguments["collect-facts"].(bool); m {
+		jobTemplate = entities.Job{
+			Kind: mode.CollectFactsMode,
+		}
+	}
+
+	if m, _ := arguments["discover"].(bool); m {
+		if depth, ok := arguments["--depth"].(string); ok {
+			value, err := strconv.Atoi(depth)
+			if err != nil {
+				return Config{}, nil, entities.Job{}, fmt.Errorf("invalid discovery depth: %v", err)
+			}
+			mtbulkConfig.Discovery.Depth = &value
+		}
+		if scope, ok := arguments["--scope"].(string); ok {
+			mtbulkConfig.Discovery.Scope = strings.Split(scope, ",")
+		}
+		if file, ok := arguments["--hosts-file"].(string); ok {
+			mtbulkConfig.Discovery.HostsFile = file
+		}
+		if file, ok := arguments["--topology-file"].(string); ok {
+			mtbulkConfig.Discovery.TopologyFile = file
+		}
+		if err := mtbulkConfig.Discovery.Validate(); err != nil {
+			return Config{}, nil, entities.Job{}, err
+		}
+
+		jobTemplate = entities.Job{
+			Kind: mode.DiscoverMode,
+		}
+	}
+
+	if m, _ := arguments["known-hosts"].(bool); m {
+		if accept, _ := arguments["accept"].(bool); accept {
+			jobTemplate = entities.Job{
+				Kind: mode.AcceptHostKeyMode,
+			}
+		}
+	}
+
+	if timeout, ok := arguments["--job-timeout"].(string); ok && jobTemplate.Kind != "" {
+		deadline, err := time.ParseDuration(timeout)
+		if err != nil {
+			return Config{}, nil, entities.Job{}, fmt.Errorf("invalid job timeout: %v", err)
 		}
+		jobTemplate.TimeoutMs = int(deadline / time.Millisecond)
 	}
 
 	if hosts, ok := arguments["<hosts>"].([]string); ok {
@@ -6,6 +6,7 @@ import (
 
 	"go.uber.org/zap"
 
+	"github.com/migotom/mt-bulk/internal/clients"
 	"github.com/migotom/mt-bulk/internal/entities"
 	"github.com/migotom/mt-bulk/internal/kvdb"
 	"github.com/migotom/mt-bulk/internal/vulnerabilities"
@@ -29,6 +30,10 @@ func NewService(sugar *zap.SugaredLogger, kv kvdb.KV, config Config) *Service {
 			DBInfo: vulnerabilities.CVEURLfallbackDBInfo,
 			DB:     vulnerabilities.CVEURLfallback,
 		})
+	config.Clients.SSH.KnownHosts = clients.NewKnownHosts(kv, config.Clients.SSH.KnownHostsFile)
+	config.Clients.MikrotikAPI.DeviceCertificates = clients.NewDeviceCertificates(kv)
+	config.Clients.REST.DeviceCertificates = config.Clients.MikrotikAPI.DeviceCertificates
+
 	return &Service{
 		sugar:                  sugar,
 		config:                 config,
@@ -44,7 +49,7 @@ func (service *Service) Listen(ctx context.Context, cancel context.CancelFunc) {
 
 	workerPool := NewWorkerPool(service.config.Workers)
 	for i := 0; i < service.config.Workers; i++ {
-		w := NewWorker(service.sugar, 8, service.config.Version, service.kv, service.VulnerabilitiesManager)
+		w := NewWorker(service.sugar, 8, service.config.Version, service.kv, service.VulnerabilitiesManager, service.config.Backups, service.config.SecurityAudit)
 		workerPool.Add(w)
 
 		wg.Add(1)
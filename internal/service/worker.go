@@ -4,13 +4,17 @@ import (
 	"context"
 	"errors"
 	"sort"
+	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/migotom/mt-bulk/internal/backups"
 	"github.com/migotom/mt-bulk/internal/clients"
 	"github.com/migotom/mt-bulk/internal/entities"
+	"github.com/migotom/mt-bulk/internal/inventory"
 	"github.com/migotom/mt-bulk/internal/kvdb"
 	"github.com/migotom/mt-bulk/internal/mode"
+	"github.com/migotom/mt-bulk/internal/rules"
 	"github.com/migotom/mt-bulk/internal/vulnerabilities"
 )
 
@@ -23,22 +27,26 @@ type Worker struct {
 	processingHosts []entities.Host
 
 	vulnerabilitiesManager *vulnerabilities.Manager
+	backupsRetention       backups.Retention
+	securityAudit          rules.Audit
 }
 
 // NewWorker returns new worker.
-func NewWorker(sugar *zap.SugaredLogger, jobsQueueSize int, version string, kv kvdb.KV, vulnerabilitiesManager *vulnerabilities.Manager) *Worker {
+func NewWorker(sugar *zap.SugaredLogger, jobsQueueSize int, version string, kv kvdb.KV, vulnerabilitiesManager *vulnerabilities.Manager, backupsRetention backups.Retention, securityAudit rules.Audit) *Worker {
 	return &Worker{
 		sugar:                  sugar,
 		version:                version,
 		jobs:                   make(chan entities.Job, jobsQueueSize),
 		kv:                     kv,
 		vulnerabilitiesManager: vulnerabilitiesManager,
+		backupsRetention:       backupsRetention,
+		securityAudit:          securityAudit,
 	}
 }
 
 // ProcessingHost returns true if worker is already processing job for given host.
 func (w *Worker) ProcessingHost(host entities.Host) bool {
-	return sort.Search(len(w.processingHosts), func(i int) bool { return w.processingHosts[i] == host }) < len(w.processingHosts)
+	return sort.Search(len(w.processingHosts), func(i int) bool { return w.processingHosts[i].Equal(host) }) < len(w.processingHosts)
 }
 
 // ProcessJobs processes job's channel using given clients configuration.
@@ -61,16 +69,25 @@ func (w *Worker) ProcessJobs(ctx context.Context, clientConfig clients.Clients)
 				handler = mode.InitPublicKeySSH
 			case mode.InitSecureAPIMode:
 				client = clients.NewSSHClient(clientConfig.SSH)
-				handler = mode.InitSecureAPI
+				handler = mode.InitSecureAPI(clients.NewDeviceCertificates(w.kv))
 			case mode.CustomSSHMode:
 				client = clients.NewSSHClient(clientConfig.SSH)
 				handler = mode.Custom
 			case mode.CustomAPIMode:
 				client = clients.NewMikrotikAPIClient(clientConfig.MikrotikAPI)
 				handler = mode.Custom
+			case mode.CustomAPIPlainMode:
+				client = clients.NewMikrotikAPIPlainClient(clientConfig.MikrotikAPIPlain)
+				handler = mode.Custom
+			case mode.CustomRESTMode:
+				client = clients.NewRESTClient(clientConfig.REST)
+				handler = mode.Custom
 			case mode.ChangePasswordMode:
 				client = clients.NewMikrotikAPIClient(clientConfig.MikrotikAPI)
 				handler = mode.ChangePassword
+			case mode.ChangePasswordAPIPlainMode:
+				client = clients.NewMikrotikAPIPlainClient(clientConfig.MikrotikAPIPlain)
+				handler = mode.ChangePassword
 			case mode.CheckMTbulkVersionMode:
 				handler = mode.CheckMTbulkVersion(w.version, w.kv)
 			case mode.SFTPMode:
@@ -78,10 +95,37 @@ func (w *Worker) ProcessJobs(ctx context.Context, clientConfig clients.Clients)
 				handler = mode.SFTP
 			case mode.SystemBackupMode:
 				client = clients.NewSSHClient(clientConfig.SSH)
-				handler = mode.SystemBackup
+				handler = mode.SystemBackup(backups.NewIndex(w.kv), w.backupsRetention)
+			case mode.SystemRestoreMode:
+				client = clients.NewSSHClient(clientConfig.SSH)
+				handler = mode.SystemRestore
+			case mode.SystemUpgradeMode:
+				client = clients.NewSSHClient(clientConfig.SSH)
+				handler = mode.SystemUpgrade
+			case mode.ConfigDiffMode:
+				client = clients.NewSSHClient(clientConfig.SSH)
+				baselines := backups.NewBaselines(w.kv)
+				if source := job.Data["baseline"]; source == "backups" {
+					baselines = backups.NewBackupBaselines(backups.NewIndex(w.kv))
+				}
+				handler = mode.ConfigDiff(baselines)
+			case mode.ComplianceMode:
+				client = clients.NewSSHClient(clientConfig.SSH)
+				handler = mode.Compliance
 			case mode.SecurityAuditMode:
 				client = clients.NewSSHClient(clientConfig.SSH)
-				handler = mode.SecurityAudit(w.vulnerabilitiesManager)
+				handler = mode.SecurityAudit(w.vulnerabilitiesManager, w.securityAudit)
+			case mode.CollectFactsMode:
+				client = clients.NewSSHClient(clientConfig.SSH)
+				handler = mode.CollectFacts(inventory.New(w.kv))
+			case mode.DiscoverMode:
+				client = clients.NewSSHClient(clientConfig.SSH)
+				handler = mode.Discover
+			case mode.AcceptHostKeyMode:
+				config := clientConfig.SSH
+				config.HostKeyPolicy = clients.HostKeyPolicyAccept
+				client = clients.NewSSHClient(config)
+				handler = mode.AcceptHostKey
 
 			default:
 				w.sugar.Infow("unexpected job", "kind", job.Kind)
@@ -89,7 +133,9 @@ func (w *Worker) ProcessJobs(ctx context.Context, clientConfig clients.Clients)
 				continue
 			}
 
-			result := handler(ctx, w.sugar, client, &job)
+			jobCtx, cancel := jobContext(ctx, job)
+			result := handler(jobCtx, w.sugar, client, &job)
+			cancel()
 			result.Job = job
 
 			select {
@@ -101,3 +147,11 @@ func (w *Worker) ProcessJobs(ctx context.Context, clientConfig clients.Clients)
 		}
 	}
 }
+
+// jobContext returns context of job processing limited by job's deadline if defined.
+func jobContext(ctx context.Context, job entities.Job) (context.Context, context.CancelFunc) {
+	if job.TimeoutMs > 0 {
+		return context.WithTimeout(ctx, time.Duration(job.TimeoutMs)*time.Millisecond)
+	}
+	return context.WithCancel(ctx)
+}
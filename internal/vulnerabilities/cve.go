@@ -3,12 +3,11 @@ package vulnerabilities
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"net/http"
 	"strings"
 	"time"
 
+	"github.com/migotom/mt-bulk/internal/entities"
 	"github.com/migotom/mt-bulk/internal/kvdb"
 	"go.uber.org/zap"
 )
@@ -27,6 +26,19 @@ type CVE struct {
 	Summary  string  `json:"summary"`
 
 	References []string `json:"references"`
+	// Affected is list of version ranges of RouterOS affected by CVE, e.g. `>=6.41 <6.42.1`.
+	Affected []string `json:"affected,omitempty"`
+}
+
+// Affects returns true if given RouterOS version is in any of CVE's affected ranges.
+func (cve CVE) Affects(version entities.Version) bool {
+	for _, affected := range cve.Affected {
+		versionRange, err := entities.ParseVersionRange(affected)
+		if err == nil && versionRange.Contains(version) {
+			return true
+		}
+	}
+	return false
 }
 
 func (cve CVE) String() string {
@@ -48,13 +60,16 @@ func (vm *Manager) CVEsDownload(ctx context.Context) error {
 	for _, cveURLs := range vm.cvesURLs {
 		var dbUpToDate bool
 		var dbInfo cveDBInfo
-		dbUpToDate, dbInfo, err = isDBinfoUpToDate(ctx, vm.sugar, vm.kv, cveURLs.DBInfo)
-		if err != nil {
-			continue
-		}
-
-		if dbUpToDate {
-			return nil
+		// local database without timestamps information is always loaded
+		if cveURLs.DBInfo != "" || !IsFileURL(cveURLs.DB) {
+			dbUpToDate, dbInfo, err = isDBinfoUpToDate(ctx, vm.sugar, vm.kv, cveURLs.DBInfo)
+			if err != nil {
+				continue
+			}
+
+			if dbUpToDate {
+				return nil
+			}
 		}
 
 		err = downloadCVEs(ctx, vm.sugar, txn, cveURLs.DB)
@@ -74,74 +89,58 @@ func (vm *Manager) CVEsDownload(ctx context.Context) error {
 }
 
 func downloadCVEs(ctx context.Context, sugar *zap.SugaredLogger, txn kvdb.Txn, url string) error {
-	res, err := downloadWithRetries(ctx, sugar, url)
+	body, err := openURL(ctx, sugar, url)
 	if err != nil {
 		return err
 	}
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf("invalid response status code %v", res.StatusCode)
-	}
-
-	// find begin of stream with Data objects
-	dec := json.NewDecoder(res.Body)
-	for {
-		t, err := dec.Token()
-		if err != nil {
-			return fmt.Errorf("invalid response body %v", err)
-		}
+	defer body.Close()
 
-		delim, ok := t.(json.Delim)
-		if !ok {
-			continue
-		}
-		if delim == '[' {
-			break
-		}
+	cves, err := decodeCircl(body)
+	if err != nil {
+		return err
 	}
+	return storeCVEs(txn, cves, false)
+}
 
-	// extract CVEs from JSON and store in K/V db
+// storeCVEs stores CVEs and lists of CVEs indexed by last affected versions,
+// CVEs and lists of versions are merged with already stored ones if requested.
+func storeCVEs(txn kvdb.Txn, cves []CVE, merge bool) error {
 	versionsCVEs := make(map[int][]string)
-	for dec.More() {
-		var cve struct {
-			ID       string  `json:"id"`
-			CVSS     float32 `json:"cvss"`
-			Modified string  `json:"Modified"`
-			Summary  string  `json:"summary"`
-
-			References               []string `json:"references"`
-			VulnerableConfigurations []string `json:"vulnerable_configuration"`
-		}
-		err := dec.Decode(&cve)
-		if err != nil {
-			return errors.New("couldn't decode cve record")
+	for _, cve := range cves {
+		key := fmt.Sprintf("%s%s", kvTagCVE, cve.ID)
+		if merge {
+			var stored CVE
+			if err := txn.GetCopy(key, &stored); err == nil {
+				cve.Affected = appendRanges(stored.Affected, cve.Affected...)
+			}
 		}
 
-		err = txn.Store(fmt.Sprintf("%s%s", kvTagCVE, cve.ID), CVE{
-			ID:         cve.ID,
-			CVSS:       cve.CVSS,
-			Modified:   cve.Modified,
-			Summary:    cve.Summary,
-			References: cve.References,
-		})
+		err := txn.Store(key, cve)
 		if err != nil {
 			return err
 		}
 
-		for _, version := range ConfigurationsToVersions(cve.VulnerableConfigurations) {
+		for _, version := range versionKeys(cve.Affected) {
 			versionsCVEs[version] = append(versionsCVEs[version], cve.ID)
 		}
 	}
 
 	for version, cves := range versionsCVEs {
-		err = txn.Store(fmt.Sprintf("%s%d", kvTagVersion, version), cves)
+		key := fmt.Sprintf("%s%d", kvTagVersion, version)
+		if merge {
+			var stored []string
+			if err := txn.GetCopy(key, &stored); err == nil {
+				cves = mergeIDs(stored, cves)
+			}
+		}
+
+		err := txn.Store(key, cves)
 		if err != nil {
 			return err
 		}
 	}
 
-	err = txn.Store(kvTagDBLastUpdate, time.Now())
+	err := txn.Store(kvTagDBLastUpdate, time.Now())
 	if err != nil {
 		return err
 	}
@@ -154,20 +153,29 @@ func downloadCVEs(ctx context.Context, sugar *zap.SugaredLogger, txn kvdb.Txn, u
 	return nil
 }
 
+func mergeIDs(list, IDs []string) []string {
+	known := make(map[string]struct{}, len(list))
+	for _, ID := range list {
+		known[ID] = struct{}{}
+	}
+	for _, ID := range IDs {
+		if _, ok := known[ID]; !ok {
+			known[ID] = struct{}{}
+			list = append(list, ID)
+		}
+	}
+	return list
+}
+
 func isDBinfoUpToDate(ctx context.Context, sugar *zap.SugaredLogger, kv kvdb.KV, url string) (bool, cveDBInfo, error) {
-	res, err := downloadWithRetries(ctx, sugar, url)
+	body, err := openURL(ctx, sugar, url)
 	if err != nil {
 		return false, cveDBInfo{}, err
 	}
-
-	if res.StatusCode != http.StatusOK {
-		return false, cveDBInfo{}, fmt.Errorf("invalid response status code %v", res.StatusCode)
-	}
-
-	defer res.Body.Close()
+	defer body.Close()
 
 	dbInfoRemote := cveDBInfo{}
-	err = json.NewDecoder(res.Body).Decode(&dbInfoRemote)
+	err = json.NewDecoder(body).Decode(&dbInfoRemote)
 	if err != nil {
 		return false, cveDBInfo{}, err
 	}
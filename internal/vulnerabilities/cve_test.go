@@ -65,12 +65,12 @@ func TestCVEsDownload(t *testing.T) {
 			ExpectedMocks: func(kv *mocks.KVMock) {
 				kv.Txn.On("GetCopy", "DB:CVE:DBInfo", mock.Anything).Return(cveDBInfo{CAPEC: cveDBInfoEntry{cveTime{time.Date(2016, time.October, 28, 17, 22, 15, 0, time.UTC)}}}, nil)
 				kv.Txn.On("Store", "DB:CVE:DBInfo", cveDBInfo{CAPEC: cveDBInfoEntry{cveTime{time.Date(2016, time.October, 28, 17, 22, 15, 0, time.UTC)}}}).Return(nil)
-				kv.Txn.On("Store", "CVE:CVE-1-2", CVE{ID: "CVE-1-2", CVSS: 6.4, Modified: "2017-08-29T01:32:00", Summary: "Some issue", References: []string{}}).Return(nil)
-				kv.Txn.On("Store", "CVE:CVE-2-2", CVE{ID: "CVE-2-2", CVSS: 1.4, Modified: "2018-08-29T01:32:00", Summary: "Some issue no 2", References: []string{}}).Return(nil)
+				kv.Txn.On("Store", "CVE:CVE-1-2", CVE{ID: "CVE-1-2", CVSS: 6.4, Modified: "2017-08-29T01:32:00", Summary: "Some issue", References: []string{}, Affected: []string{"=5.15"}}).Return(nil)
+				kv.Txn.On("Store", "CVE:CVE-2-2", CVE{ID: "CVE-2-2", CVSS: 1.4, Modified: "2018-08-29T01:32:00", Summary: "Some issue no 2", References: []string{}, Affected: []string{"=2.15", "=5.15"}}).Return(nil)
 				kv.Txn.On("Store", "Version:51500", []string{"CVE-1-2", "CVE-2-2"}).Return(nil)
 				kv.Txn.On("Store", "Version:21500", []string{"CVE-2-2"}).Return(nil)
 				kv.Txn.On("Store", "DB:LastUpdate", mock.Anything).Return(nil)
-				kv.Txn.On("Store", "DB:Version", 1).Return(nil)
+				kv.Txn.On("Store", "DB:Version", 2).Return(nil)
 				kv.Txn.On("Commit").Return(nil)
 				kv.Txn.On("Discard").Return()
 			},
@@ -99,7 +99,7 @@ func TestCVEsDownload(t *testing.T) {
 			ExpectedMocks: func(kv *mocks.KVMock) {
 				kv.Txn.On("GetCopy", "DB:CVE:DBInfo", mock.Anything).Return(cveDBInfo{}, nil)
 
-				kv.Txn.On("Store", "CVE:CVE-1-2", CVE{ID: "CVE-1-2", CVSS: 6.4, Modified: "2017-08-29T01:32:00", Summary: "Some issue", References: []string{}}).Return(nil)
+				kv.Txn.On("Store", "CVE:CVE-1-2", CVE{ID: "CVE-1-2", CVSS: 6.4, Modified: "2017-08-29T01:32:00", Summary: "Some issue", References: []string{}, Affected: []string{"=5.15"}}).Return(nil)
 				kv.Txn.On("Store", "Version:51500", []string{"CVE-1-2"}).Return(errors.New("wrong"))
 				kv.Txn.On("Discard").Return()
 			},
@@ -3,37 +3,89 @@ package vulnerabilities
 import (
 	"fmt"
 	"strings"
+
+	"github.com/migotom/mt-bulk/internal/entities"
 )
 
 // VulnerabilityError is error containing all found vulnerabilities.
+// Vulnerabilities are new CVEs failing check, waived CVEs and CVEs below CVSS threshold are only reported.
 type VulnerabilityError struct {
 	Vulnerabilities []CVE
+	Waived          []WaivedCVE
+	BelowThreshold  []CVE
 	err             error
 }
 
+// WaivedCVE is found CVE accepted by waiver.
+type WaivedCVE struct {
+	CVE
+	Waiver entities.Waiver `json:"waiver"`
+}
+
 func (e VulnerabilityError) Error() string {
 	if e.err != nil {
 		return e.err.Error()
 	}
 
-	if len(e.Vulnerabilities) == 0 {
-		return ""
+	var groups []string
+	if len(e.Vulnerabilities) > 0 {
+		groups = append(groups, "vulnerabilities found: "+cvesList(e.Vulnerabilities))
+	}
+	if len(e.Waived) > 0 {
+		waived := make([]CVE, 0, len(e.Waived))
+		for _, cve := range e.Waived {
+			waived = append(waived, cve.CVE)
+		}
+		groups = append(groups, "waived vulnerabilities: "+cvesList(waived))
 	}
-	var err strings.Builder
+	if len(e.BelowThreshold) > 0 {
+		groups = append(groups, "vulnerabilities below CVSS threshold: "+cvesList(e.BelowThreshold))
+	}
+	return strings.Join(groups, "; ")
+}
 
-	err.WriteString("vulnerabilities found: ")
+// Failed returns true if any of found vulnerabilities is neither waived nor below CVSS threshold.
+func (e VulnerabilityError) Failed() bool {
+	return e.err != nil || len(e.Vulnerabilities) > 0
+}
+
+// Triage moves vulnerabilities accepted by waiver and of CVSS score lower than threshold out of failing ones.
+func (e VulnerabilityError) Triage(threshold float32, waiver func(id string) (entities.Waiver, bool)) VulnerabilityError {
+	triaged := VulnerabilityError{Waived: e.Waived, BelowThreshold: e.BelowThreshold, err: e.err}
 	for _, cve := range e.Vulnerabilities {
-		err.WriteString(fmt.Sprintf("%s (%.1f), ", cve.ID, cve.CVSS))
+		if w, ok := waiver(cve.ID); ok {
+			triaged.Waived = append(triaged.Waived, WaivedCVE{CVE: cve, Waiver: w})
+			continue
+		}
+		if cve.CVSS < threshold {
+			triaged.BelowThreshold = append(triaged.BelowThreshold, cve)
+			continue
+		}
+		triaged.Vulnerabilities = append(triaged.Vulnerabilities, cve)
 	}
-	return strings.TrimRight(err.String(), ", ")
+	return triaged
 }
 
 // Details returns details about found vulnerabilities.
 func (e VulnerabilityError) Details() []string {
-	details := make([]string, 0, len(e.Vulnerabilities))
+	details := make([]string, 0, len(e.Vulnerabilities)+len(e.Waived)+len(e.BelowThreshold))
 
 	for _, cve := range e.Vulnerabilities {
 		details = append(details, cve.String())
 	}
+	for _, cve := range e.Waived {
+		details = append(details, fmt.Sprintf("[waived: %s] %s", cve.Waiver.Justification, cve.CVE))
+	}
+	for _, cve := range e.BelowThreshold {
+		details = append(details, fmt.Sprintf("[below CVSS threshold] %s", cve))
+	}
 	return details
 }
+
+func cvesList(cves []CVE) string {
+	list := make([]string, 0, len(cves))
+	for _, cve := range cves {
+		list = append(list, fmt.Sprintf("%s (%.1f)", cve.ID, cve.CVSS))
+	}
+	return strings.Join(list, ", ")
+}
@@ -0,0 +1,80 @@
+package vulnerabilities
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+func TestVulnerabilityErrorTriage(t *testing.T) {
+	found := VulnerabilityError{Vulnerabilities: []CVE{
+		{ID: "CVE-2018-14847", CVSS: 9.1},
+		{ID: "CVE-2019-3976", CVSS: 6.5},
+		{ID: "CVE-2017-7285", CVSS: 5.0},
+	}}
+	waivers := []entities.Waiver{{ID: "CVE-2019-3976", Justification: "FTP disabled"}}
+	waiver := func(id string) (entities.Waiver, bool) {
+		for _, w := range waivers {
+			if w.ID == id {
+				return w, true
+			}
+		}
+		return entities.Waiver{}, false
+	}
+
+	cases := []struct {
+		Name          string
+		Threshold     float32
+		Expected      VulnerabilityError
+		ExpectedError string
+		ExpectedFail  bool
+	}{
+		{
+			Name:      "No threshold",
+			Threshold: 0,
+			Expected: VulnerabilityError{
+				Vulnerabilities: []CVE{{ID: "CVE-2018-14847", CVSS: 9.1}, {ID: "CVE-2017-7285", CVSS: 5.0}},
+				Waived:          []WaivedCVE{{CVE: CVE{ID: "CVE-2019-3976", CVSS: 6.5}, Waiver: waivers[0]}},
+			},
+			ExpectedError: "vulnerabilities found: CVE-2018-14847 (9.1), CVE-2017-7285 (5.0); waived vulnerabilities: CVE-2019-3976 (6.5)",
+			ExpectedFail:  true,
+		},
+		{
+			Name:      "Threshold",
+			Threshold: 7,
+			Expected: VulnerabilityError{
+				Vulnerabilities: []CVE{{ID: "CVE-2018-14847", CVSS: 9.1}},
+				Waived:          []WaivedCVE{{CVE: CVE{ID: "CVE-2019-3976", CVSS: 6.5}, Waiver: waivers[0]}},
+				BelowThreshold:  []CVE{{ID: "CVE-2017-7285", CVSS: 5.0}},
+			},
+			ExpectedError: "vulnerabilities found: CVE-2018-14847 (9.1); waived vulnerabilities: CVE-2019-3976 (6.5); vulnerabilities below CVSS threshold: CVE-2017-7285 (5.0)",
+			ExpectedFail:  true,
+		},
+		{
+			Name:      "Nothing failing",
+			Threshold: 10,
+			Expected: VulnerabilityError{
+				Waived:         []WaivedCVE{{CVE: CVE{ID: "CVE-2019-3976", CVSS: 6.5}, Waiver: waivers[0]}},
+				BelowThreshold: []CVE{{ID: "CVE-2018-14847", CVSS: 9.1}, {ID: "CVE-2017-7285", CVSS: 5.0}},
+			},
+			ExpectedError: "waived vulnerabilities: CVE-2019-3976 (6.5); vulnerabilities below CVSS threshold: CVE-2018-14847 (9.1), CVE-2017-7285 (5.0)",
+			ExpectedFail:  false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			triaged := found.Triage(tc.Threshold, waiver)
+			if !reflect.DeepEqual(triaged, tc.Expected) {
+				t.Errorf("got:%v, expected:%v", triaged, tc.Expected)
+			}
+			if triaged.Error() != tc.ExpectedError {
+				t.Errorf("got:%v, expected:%v", triaged.Error(), tc.ExpectedError)
+			}
+			if triaged.Failed() != tc.ExpectedFail {
+				t.Errorf("got:%v, expected:%v", triaged.Failed(), tc.ExpectedFail)
+			}
+		})
+	}
+}
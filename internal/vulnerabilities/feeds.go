@@ -0,0 +1,713 @@
+package vulnerabilities
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+// Formats of CVE feeds.
+const (
+	FeedCircl = "circl"
+	FeedNVD11 = "nvd-1.1"
+	FeedNVD20 = "nvd-2.0"
+	FeedCSAF  = "csaf"
+	FeedOSV   = "osv"
+)
+
+// anyVersion is version range containing all versions of RouterOS.
+const anyVersion = ">=0"
+
+// cpeMatch is vulnerable configuration of CVE defined by CPE and optional range of versions.
+type cpeMatch struct {
+	Vulnerable            bool   `json:"vulnerable"`
+	CPE23URI              string `json:"cpe23Uri"`
+	Criteria              string `json:"criteria"`
+	VersionStartIncluding string `json:"versionStartIncluding"`
+	VersionStartExcluding string `json:"versionStartExcluding"`
+	VersionEndIncluding   string `json:"versionEndIncluding"`
+	VersionEndExcluding   string `json:"versionEndExcluding"`
+}
+
+// DetectFeedFormat returns format of CVE feed recognized by its top level structure.
+func DetectFeedFormat(content []byte) (string, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(content, &object); err != nil {
+		var list []map[string]json.RawMessage
+		if err := json.Unmarshal(content, &list); err != nil || len(list) == 0 {
+			return "", fmt.Errorf("unknown format of CVE feed: %v", err)
+		}
+		object = list[0]
+	}
+
+	has := func(key string) bool {
+		_, ok := object[key]
+		return ok
+	}
+	switch {
+	case has("data"):
+		return FeedCircl, nil
+	case has("CVE_Items"):
+		return FeedNVD11, nil
+	case has("document") && has("vulnerabilities"):
+		return FeedCSAF, nil
+	case has("vulnerabilities"):
+		return FeedNVD20, nil
+	case has("id") && has("affected"):
+		return FeedOSV, nil
+	}
+	return "", errors.New("unknown format of CVE feed")
+}
+
+// decodeFeed decodes list of CVEs affecting RouterOS from feed of given format.
+func decodeFeed(format string, content []byte) ([]CVE, error) {
+	switch format {
+	case FeedCircl:
+		return decodeCircl(bytes.NewReader(content))
+	case FeedNVD11:
+		return decodeNVD11(content)
+	case FeedNVD20:
+		return decodeNVD20(content)
+	case FeedCSAF:
+		return decodeCSAF(content)
+	case FeedOSV:
+		return decodeOSV(content)
+	}
+	return nil, fmt.Errorf("unknown format of CVE feed %s", format)
+}
+
+// decodeCircl decodes stream of cve-search (circl.lu) search results.
+func decodeCircl(r io.Reader) ([]CVE, error) {
+	// find begin of stream with Data objects
+	dec := json.NewDecoder(r)
+	for {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid response body %v", err)
+		}
+
+		delim, ok := t.(json.Delim)
+		if !ok {
+			continue
+		}
+		if delim == '[' {
+			break
+		}
+	}
+
+	var records []CVE
+	for dec.More() {
+		var cve struct {
+			ID       string  `json:"id"`
+			CVSS     float32 `json:"cvss"`
+			Modified string  `json:"Modified"`
+			Summary  string  `json:"summary"`
+
+			References               []string `json:"references"`
+			VulnerableConfigurations []string `json:"vulnerable_configuration"`
+		}
+		err := dec.Decode(&cve)
+		if err != nil {
+			return nil, errors.New("couldn't decode cve record")
+		}
+
+		records = append(records, CVE{
+			ID:         cve.ID,
+			CVSS:       cve.CVSS,
+			Modified:   cve.Modified,
+			Summary:    cve.Summary,
+			References: cve.References,
+			Affected:   configurationsToRanges(cve.VulnerableConfigurations),
+		})
+	}
+	return records, nil
+}
+
+type nvdDescription struct {
+	Lang  string `json:"lang"`
+	Value string `json:"value"`
+}
+
+type nvdReference struct {
+	URL string `json:"url"`
+}
+
+type nvd11Node struct {
+	Children []nvd11Node `json:"children"`
+	Matches  []cpeMatch  `json:"cpe_match"`
+}
+
+func (n nvd11Node) matches() []cpeMatch {
+	matches := n.Matches
+	for _, child := range n.Children {
+		matches = append(matches, child.matches()...)
+	}
+	return matches
+}
+
+// decodeNVD11 decodes NVD JSON 1.1 feed.
+func decodeNVD11(content []byte) ([]CVE, error) {
+	var feed struct {
+		Items []struct {
+			CVE struct {
+				Meta struct {
+					ID string `json:"ID"`
+				} `json:"CVE_data_meta"`
+				References struct {
+					Data []nvdReference `json:"reference_data"`
+				} `json:"references"`
+				Description struct {
+					Data []nvdDescription `json:"description_data"`
+				} `json:"description"`
+			} `json:"cve"`
+			Configurations struct {
+				Nodes []nvd11Node `json:"nodes"`
+			} `json:"configurations"`
+			Impact struct {
+				V3 struct {
+					CVSS struct {
+						BaseScore float32 `json:"baseScore"`
+					} `json:"cvssV3"`
+				} `json:"baseMetricV3"`
+				V2 struct {
+					CVSS struct {
+						BaseScore float32 `json:"baseScore"`
+					} `json:"cvssV2"`
+				} `json:"baseMetricV2"`
+			} `json:"impact"`
+			LastModified string `json:"lastModifiedDate"`
+		} `json:"CVE_Items"`
+	}
+	if err := json.Unmarshal(content, &feed); err != nil {
+		return nil, fmt.Errorf("invalid NVD 1.1 feed: %v", err)
+	}
+
+	var records []CVE
+	for _, item := range feed.Items {
+		var matches []cpeMatch
+		for _, node := range item.Configurations.Nodes {
+			matches = append(matches, node.matches()...)
+		}
+		affected := matchesToRanges(matches)
+		if len(affected) == 0 {
+			continue
+		}
+
+		cvss := item.Impact.V3.CVSS.BaseScore
+		if cvss == 0 {
+			cvss = item.Impact.V2.CVSS.BaseScore
+		}
+
+		records = append(records, CVE{
+			ID:         item.CVE.Meta.ID,
+			CVSS:       cvss,
+			Modified:   item.LastModified,
+			Summary:    englishDescription(item.CVE.Description.Data),
+			References: referencesURLs(item.CVE.References.Data),
+			Affected:   affected,
+		})
+	}
+	return records, nil
+}
+
+// decodeNVD20 decodes NVD JSON 2.0 feed or NVD CVE API 2.0 response.
+func decodeNVD20(content []byte) ([]CVE, error) {
+	type metric struct {
+		Data struct {
+			BaseScore float32 `json:"baseScore"`
+		} `json:"cvssData"`
+	}
+	var feed struct {
+		Vulnerabilities []struct {
+			CVE struct {
+				ID             string           `json:"id"`
+				LastModified   string           `json:"lastModified"`
+				Descriptions   []nvdDescription `json:"descriptions"`
+				References     []nvdReference   `json:"references"`
+				Configurations []struct {
+					Nodes []struct {
+						Matches []cpeMatch `json:"cpeMatch"`
+					} `json:"nodes"`
+				} `json:"configurations"`
+				Metrics struct {
+					V31 []metric `json:"cvssMetricV31"`
+					V30 []metric `json:"cvssMetricV30"`
+					V2  []metric `json:"cvssMetricV2"`
+				} `json:"metrics"`
+			} `json:"cve"`
+		} `json:"vulnerabilities"`
+	}
+	if err := json.Unmarshal(content, &feed); err != nil {
+		return nil, fmt.Errorf("invalid NVD 2.0 feed: %v", err)
+	}
+
+	var records []CVE
+	for _, vulnerability := range feed.Vulnerabilities {
+		cve := vulnerability.CVE
+
+		var matches []cpeMatch
+		for _, configuration := range cve.Configurations {
+			for _, node := range configuration.Nodes {
+				matches = append(matches, node.Matches...)
+			}
+		}
+		affected := matchesToRanges(matches)
+		if len(affected) == 0 {
+			continue
+		}
+
+		var cvss float32
+		for _, metrics := range [][]metric{cve.Metrics.V31, cve.Metrics.V30, cve.Metrics.V2} {
+			if len(metrics) > 0 {
+				cvss = metrics[0].Data.BaseScore
+				break
+			}
+		}
+
+		records = append(records, CVE{
+			ID:         cve.ID,
+			CVSS:       cvss,
+			Modified:   cve.LastModified,
+			Summary:    englishDescription(cve.Descriptions),
+			References: referencesURLs(cve.References),
+			Affected:   affected,
+		})
+	}
+	return records, nil
+}
+
+type csafProduct struct {
+	ProductID string `json:"product_id"`
+	Name      string `json:"name"`
+	Helper    struct {
+		CPE string `json:"cpe"`
+	} `json:"product_identification_helper"`
+}
+
+type csafBranch struct {
+	Category string       `json:"category"`
+	Name     string       `json:"name"`
+	Product  *csafProduct `json:"product"`
+	Branches []csafBranch `json:"branches"`
+}
+
+// ranges collects version ranges of RouterOS products of branch, identified by CPE or by version branch of RouterOS product.
+func (b csafBranch) ranges(routerOS bool, products map[string][]string) {
+	routerOS = routerOS || strings.Contains(strings.ToLower(b.Name), "routeros")
+	if b.Product != nil {
+		if affected := csafProductRanges(*b.Product); len(affected) > 0 {
+			products[b.Product.ProductID] = affected
+		} else if routerOS {
+			switch b.Category {
+			case "product_version":
+				products[b.Product.ProductID] = appendRanges(nil, "="+b.Name)
+			case "product_version_range":
+				products[b.Product.ProductID] = appendRanges(nil, versRange(b.Name))
+			}
+		}
+	}
+	for _, branch := range b.Branches {
+		branch.ranges(routerOS, products)
+	}
+}
+
+func csafProductRanges(product csafProduct) []string {
+	return matchesToRanges([]cpeMatch{{Vulnerable: true, Criteria: product.Helper.CPE}})
+}
+
+// decodeCSAF decodes CSAF 2.0 security advisory or VEX document.
+func decodeCSAF(content []byte) ([]CVE, error) {
+	var document struct {
+		Document struct {
+			Title    string `json:"title"`
+			Tracking struct {
+				CurrentReleaseDate string `json:"current_release_date"`
+			} `json:"tracking"`
+		} `json:"document"`
+		ProductTree struct {
+			Branches         []csafBranch  `json:"branches"`
+			FullProductNames []csafProduct `json:"full_product_names"`
+		} `json:"product_tree"`
+		Vulnerabilities []struct {
+			CVE   string `json:"cve"`
+			Notes []struct {
+				Category string `json:"category"`
+				Text     string `json:"text"`
+			} `json:"notes"`
+			References []nvdReference `json:"references"`
+			Scores     []struct {
+				CVSSV3 struct {
+					BaseScore float32 `json:"baseScore"`
+				} `json:"cvss_v3"`
+				CVSSV2 struct {
+					BaseScore float32 `json:"baseScore"`
+				} `json:"cvss_v2"`
+			} `json:"scores"`
+			ProductStatus struct {
+				FirstAffected []string `json:"first_affected"`
+				KnownAffected []string `json:"known_affected"`
+				LastAffected  []string `json:"last_affected"`
+			} `json:"product_status"`
+		} `json:"vulnerabilities"`
+	}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("invalid CSAF document: %v", err)
+	}
+
+	products := make(map[string][]string)
+	for _, branch := range document.ProductTree.Branches {
+		branch.ranges(false, products)
+	}
+	for _, product := range document.ProductTree.FullProductNames {
+		if affected := csafProductRanges(product); len(affected) > 0 {
+			products[product.ProductID] = affected
+		}
+	}
+
+	var records []CVE
+	for _, vulnerability := range document.Vulnerabilities {
+		if vulnerability.CVE == "" {
+			continue
+		}
+
+		var affected []string
+		status := vulnerability.ProductStatus
+		for _, list := range [][]string{status.FirstAffected, status.KnownAffected, status.LastAffected} {
+			for _, productID := range list {
+				affected = appendRanges(affected, products[productID]...)
+			}
+		}
+		if len(affected) == 0 {
+			continue
+		}
+
+		var cvss float32
+		for _, score := range vulnerability.Scores {
+			if score.CVSSV3.BaseScore > cvss {
+				cvss = score.CVSSV3.BaseScore
+			}
+			if score.CVSSV3.BaseScore == 0 && score.CVSSV2.BaseScore > cvss {
+				cvss = score.CVSSV2.BaseScore
+			}
+		}
+
+		summary := document.Document.Title
+		for _, note := range vulnerability.Notes {
+			if note.Category == "description" || note.Category == "summary" {
+				summary = note.Text
+				break
+			}
+		}
+
+		records = append(records, CVE{
+			ID:         vulnerability.CVE,
+			CVSS:       cvss,
+			Modified:   document.Document.Tracking.CurrentReleaseDate,
+			Summary:    summary,
+			References: referencesURLs(vulnerability.References),
+			Affected:   affected,
+		})
+	}
+	return records, nil
+}
+
+type osvEntry struct {
+	ID       string   `json:"id"`
+	Aliases  []string `json:"aliases"`
+	Modified string   `json:"modified"`
+	Summary  string   `json:"summary"`
+	Details  string   `json:"details"`
+	Severity []struct {
+		Type  string `json:"type"`
+		Score string `json:"score"`
+	} `json:"severity"`
+	Affected []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Type   string              `json:"type"`
+			Events []map[string]string `json:"events"`
+		} `json:"ranges"`
+		Versions []string `json:"versions"`
+	} `json:"affected"`
+	References []nvdReference `json:"references"`
+}
+
+// decodeOSV decodes single OSV entry or list of OSV entries.
+func decodeOSV(content []byte) ([]CVE, error) {
+	var entries []osvEntry
+	if err := json.Unmarshal(content, &entries); err != nil {
+		var entry osvEntry
+		if err := json.Unmarshal(content, &entry); err != nil {
+			return nil, fmt.Errorf("invalid OSV entry: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	var records []CVE
+	for _, entry := range entries {
+		var affected []string
+		for _, pkg := range entry.Affected {
+			if !strings.Contains(strings.ToLower(pkg.Package.Name), "routeros") {
+				continue
+			}
+			for _, version := range pkg.Versions {
+				affected = appendRanges(affected, "="+version)
+			}
+			for _, r := range pkg.Ranges {
+				affected = appendRanges(affected, osvRanges(r.Events)...)
+			}
+		}
+		if len(affected) == 0 {
+			continue
+		}
+
+		ID := entry.ID
+		for _, alias := range entry.Aliases {
+			if strings.HasPrefix(alias, "CVE-") {
+				ID = alias
+				break
+			}
+		}
+
+		var cvss float32
+		for _, severity := range entry.Severity {
+			if score, err := cvss3BaseScore(severity.Score); err == nil && severity.Type == "CVSS_V3" {
+				cvss = score
+			}
+		}
+
+		summary := entry.Summary
+		if summary == "" {
+			summary = entry.Details
+		}
+
+		records = append(records, CVE{
+			ID:         ID,
+			CVSS:       cvss,
+			Modified:   entry.Modified,
+			Summary:    summary,
+			References: referencesURLs(entry.References),
+			Affected:   affected,
+		})
+	}
+	return records, nil
+}
+
+// configurationsToRanges converts vulnerable configurations of cve-search CVE into version ranges of RouterOS.
+// cve-search lists each vulnerable version explicitly, configurations without version are skipped.
+func configurationsToRanges(configurations []string) (affected []string) {
+	for _, configuration := range configurations {
+		if versionRange := cpeRange(cpeMatch{Vulnerable: true, Criteria: configuration}); versionRange != anyVersion {
+			affected = appendRanges(affected, versionRange)
+		}
+	}
+	return
+}
+
+// matchesToRanges converts vulnerable RouterOS configurations into version ranges, configuration without version affects all versions.
+func matchesToRanges(matches []cpeMatch) (affected []string) {
+	for _, match := range matches {
+		if match.Vulnerable {
+			affected = appendRanges(affected, cpeRange(match))
+		}
+	}
+	return
+}
+
+// cpeRange returns version range of RouterOS configuration defined by CPE, e.g. `=7.1rc4` or `>=6.46 <6.46.1`,
+// returns empty range if CPE doesn't describe RouterOS.
+func cpeRange(match cpeMatch) string {
+	cpe := match.Criteria
+	if cpe == "" {
+		cpe = match.CPE23URI
+	}
+
+	fields := strings.Split(cpe, ":")
+	if len(fields) < 6 || fields[0] != "cpe" || (fields[3] != "mikrotik" && fields[3] != "microtik") || fields[4] != "routeros" {
+		return ""
+	}
+
+	version := fields[5]
+	switch version {
+	case "-":
+		return ""
+	case "*":
+	default:
+		// pre-releases are defined as update of version, e.g. `cpe:2.3:o:mikrotik:routeros:6.44:beta9`
+		if len(fields) > 6 && fields[6] != "*" && fields[6] != "-" {
+			version += strings.TrimFunc(fields[6], func(r rune) bool { return r == '_' })
+		}
+		return "=" + version
+	}
+
+	var constraints []string
+	for _, constraint := range []struct{ operator, version string }{
+		{">=", match.VersionStartIncluding},
+		{">", match.VersionStartExcluding},
+		{"<=", match.VersionEndIncluding},
+		{"<", match.VersionEndExcluding},
+	} {
+		if constraint.version != "" {
+			constraints = append(constraints, constraint.operator+constraint.version)
+		}
+	}
+	if len(constraints) == 0 {
+		return anyVersion
+	}
+	return strings.Join(constraints, " ")
+}
+
+// versRange converts version range defined by vers specification, e.g. `vers:generic/>=6.41|<7.1`, into version range.
+func versRange(vers string) string {
+	vers = vers[strings.LastIndex(vers, "/")+1:]
+	if vers == "*" {
+		return anyVersion
+	}
+	return strings.ReplaceAll(vers, "|", " ")
+}
+
+// osvRanges converts events of OSV range into version ranges, e.g. events introduced 6.41 and fixed 6.42.1 into `>=6.41 <6.42.1`.
+func osvRanges(events []map[string]string) (affected []string) {
+	var introduced string
+	for _, event := range events {
+		if version, ok := event["introduced"]; ok {
+			introduced = ">=" + version
+			if version == "0" {
+				introduced = anyVersion
+			}
+		}
+		if version, ok := event["fixed"]; ok && introduced != "" {
+			affected, introduced = append(affected, strings.TrimPrefix(introduced+" <"+version, anyVersion+" ")), ""
+		}
+		if version, ok := event["last_affected"]; ok && introduced != "" {
+			affected, introduced = append(affected, strings.TrimPrefix(introduced+" <="+version, anyVersion+" ")), ""
+		}
+	}
+	if introduced != "" {
+		affected = append(affected, introduced)
+	}
+	return
+}
+
+// appendRanges appends valid and not yet known version ranges to list.
+func appendRanges(affected []string, ranges ...string) []string {
+rangesScan:
+	for _, versionRange := range ranges {
+		if _, err := entities.ParseVersionRange(versionRange); err != nil || versionRange == "" {
+			continue
+		}
+		for _, known := range affected {
+			if known == versionRange {
+				continue rangesScan
+			}
+		}
+		affected = append(affected, versionRange)
+	}
+	return affected
+}
+
+func englishDescription(descriptions []nvdDescription) string {
+	for _, description := range descriptions {
+		if description.Lang == "en" {
+			return description.Value
+		}
+	}
+	if len(descriptions) > 0 {
+		return descriptions[0].Value
+	}
+	return ""
+}
+
+func referencesURLs(references []nvdReference) []string {
+	urls := make([]string, 0, len(references))
+	for _, reference := range references {
+		urls = append(urls, reference.URL)
+	}
+	return urls
+}
+
+// cvss3BaseScore calculates base score of CVSS v3 vector, e.g. `CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H`.
+func cvss3BaseScore(vector string) (float32, error) {
+	weights := map[string]map[string]float64{
+		"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+		"AC": {"L": 0.77, "H": 0.44},
+		"UI": {"N": 0.85, "R": 0.62},
+		"C":  {"H": 0.56, "L": 0.22, "N": 0},
+		"I":  {"H": 0.56, "L": 0.22, "N": 0},
+		"A":  {"H": 0.56, "L": 0.22, "N": 0},
+	}
+
+	if !strings.HasPrefix(vector, "CVSS:3.") {
+		return 0, fmt.Errorf("unsupported CVSS vector %s", vector)
+	}
+
+	metrics := make(map[string]string)
+	for _, metric := range strings.Split(vector, "/")[1:] {
+		parts := strings.SplitN(metric, ":", 2)
+		if len(parts) != 2 {
+			return 0, fmt.Errorf("invalid CVSS metric %s", metric)
+		}
+		metrics[parts[0]] = parts[1]
+	}
+
+	values := make(map[string]float64)
+	for metric, weight := range weights {
+		value, ok := weight[metrics[metric]]
+		if !ok {
+			return 0, fmt.Errorf("invalid or missing CVSS metric %s", metric)
+		}
+		values[metric] = value
+	}
+
+	changed := metrics["S"] == "C"
+	switch metrics["PR"] {
+	case "N":
+		values["PR"] = 0.85
+	case "L":
+		values["PR"] = 0.62
+		if changed {
+			values["PR"] = 0.68
+		}
+	case "H":
+		values["PR"] = 0.27
+		if changed {
+			values["PR"] = 0.5
+		}
+	default:
+		return 0, errors.New("invalid or missing CVSS metric PR")
+	}
+
+	iss := 1 - (1-values["C"])*(1-values["I"])*(1-values["A"])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, nil
+	}
+
+	exploitability := 8.22 * values["AV"] * values["AC"] * values["PR"] * values["UI"]
+	score := impact + exploitability
+	if changed {
+		score *= 1.08
+	}
+	return float32(roundUp(math.Min(score, 10))), nil
+}
+
+// roundUp rounds up value to one decimal place as defined by CVSS v3.1 specification.
+func roundUp(value float64) float64 {
+	integer := int(math.Round(value * 100000))
+	if integer%10000 == 0 {
+		return float64(integer) / 100000
+	}
+	return float64(integer/10000+1) / 10
+}
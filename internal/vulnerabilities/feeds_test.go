@@ -0,0 +1,282 @@
+package vulnerabilities
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+)
+
+func TestDecodeFeed(t *testing.T) {
+	cases := []struct {
+		Name           string
+		Feed           string
+		ExpectedFormat string
+		Expected       []CVE
+	}{
+		{
+			Name: "cve-search",
+			Feed: `{"data": [{
+				"Modified": "2017-08-29T01:32:00", "cvss": 6.4, "id": "CVE-2012-6050", "summary": "Winbox issue", "references": ["https://example.com"],
+				"vulnerable_configuration": ["cpe:2.3:o:mikrotik:routeros:5.15:*:*:*:*:*:*:*", "cpe:2.3:o:mikrotik:routeros:5.15"]
+			}]}`,
+			ExpectedFormat: FeedCircl,
+			Expected: []CVE{
+				{ID: "CVE-2012-6050", CVSS: 6.4, Modified: "2017-08-29T01:32:00", Summary: "Winbox issue", References: []string{"https://example.com"}, Affected: []string{"=5.15"}},
+			},
+		},
+		{
+			Name: "NVD 1.1",
+			Feed: `{"CVE_data_type": "CVE", "CVE_Items": [
+				{
+					"cve": {
+						"CVE_data_meta": {"ID": "CVE-2019-3976"},
+						"references": {"reference_data": [{"url": "https://mikrotik.com/supportsec"}]},
+						"description": {"description_data": [{"lang": "en", "value": "Relative path traversal"}]}
+					},
+					"configurations": {"nodes": [{"operator": "OR", "children": [{"cpe_match": [
+						{"vulnerable": true, "cpe23Uri": "cpe:2.3:o:mikrotik:routeros:*:*:*:*:*:*:*:*", "versionEndIncluding": "6.45.6"},
+						{"vulnerable": true, "cpe23Uri": "cpe:2.3:o:mikrotik:routeros:*:*:*:*:*:*:*:*", "versionStartIncluding": "6.46", "versionEndExcluding": "6.46.1"}
+					]}]}]},
+					"impact": {"baseMetricV3": {"cvssV3": {"baseScore": 6.5}}, "baseMetricV2": {"cvssV2": {"baseScore": 4.0}}},
+					"lastModifiedDate": "2019-11-14T16:15Z"
+				},
+				{
+					"cve": {"CVE_data_meta": {"ID": "CVE-2019-0001"}},
+					"configurations": {"nodes": [{"cpe_match": [{"vulnerable": true, "cpe23Uri": "cpe:2.3:o:juniper:junos:15.1:*:*:*:*:*:*:*"}]}]}
+				}
+			]}`,
+			ExpectedFormat: FeedNVD11,
+			Expected: []CVE{
+				{ID: "CVE-2019-3976", CVSS: 6.5, Modified: "2019-11-14T16:15Z", Summary: "Relative path traversal", References: []string{"https://mikrotik.com/supportsec"}, Affected: []string{"<=6.45.6", ">=6.46 <6.46.1"}},
+			},
+		},
+		{
+			Name: "NVD 2.0",
+			Feed: `{"format": "NVD_CVE", "version": "2.0", "vulnerabilities": [{"cve": {
+				"id": "CVE-2023-30799",
+				"lastModified": "2023-08-02T15:15:00",
+				"descriptions": [{"lang": "es", "value": "Escalada"}, {"lang": "en", "value": "Privilege escalation"}],
+				"references": [{"url": "https://example.com/cve-2023-30799"}],
+				"metrics": {"cvssMetricV31": [{"cvssData": {"baseScore": 9.1}}], "cvssMetricV2": [{"cvssData": {"baseScore": 6.0}}]},
+				"configurations": [{"nodes": [{"cpeMatch": [
+					{"vulnerable": true, "criteria": "cpe:2.3:o:mikrotik:routeros:*:*:*:*:stable:*:*:*", "versionEndExcluding": "6.49.7"},
+					{"vulnerable": true, "criteria": "cpe:2.3:o:mikrotik:routeros:7.1:*:*:*:*:*:*:*"},
+					{"vulnerable": false, "criteria": "cpe:2.3:o:mikrotik:routeros:7.2:*:*:*:*:*:*:*"}
+				]}]}]
+			}}]}`,
+			ExpectedFormat: FeedNVD20,
+			Expected: []CVE{
+				{ID: "CVE-2023-30799", CVSS: 9.1, Modified: "2023-08-02T15:15:00", Summary: "Privilege escalation", References: []string{"https://example.com/cve-2023-30799"}, Affected: []string{"<6.49.7", "=7.1"}},
+			},
+		},
+		{
+			Name: "CSAF",
+			Feed: `{
+				"document": {"category": "csaf_security_advisory", "title": "RouterOS advisory", "tracking": {"current_release_date": "2023-07-25T00:00:00Z"}},
+				"product_tree": {"branches": [{"category": "vendor", "name": "MikroTik", "branches": [{"category": "product_name", "name": "RouterOS", "branches": [
+					{"category": "product_version_range", "name": "vers:generic/<6.49.8", "product": {"product_id": "ROS-6", "name": "RouterOS < 6.49.8"}},
+					{"category": "product_version", "name": "7.1", "product": {"product_id": "ROS-7.1", "name": "RouterOS 7.1"}}
+				]}]}],
+				"full_product_names": [{"product_id": "ROS-7.2", "name": "RouterOS 7.2", "product_identification_helper": {"cpe": "cpe:2.3:o:mikrotik:routeros:7.2:*:*:*:*:*:*:*"}}]},
+				"vulnerabilities": [{
+					"cve": "CVE-2023-30799",
+					"notes": [{"category": "description", "text": "Privilege escalation"}],
+					"references": [{"url": "https://example.com/advisory"}],
+					"scores": [{"products": ["ROS-6"], "cvss_v3": {"baseScore": 9.1}}],
+					"product_status": {"known_affected": ["ROS-6", "ROS-7.1", "ROS-7.2"], "fixed": ["ROS-7.3"]}
+				}]
+			}`,
+			ExpectedFormat: FeedCSAF,
+			Expected: []CVE{
+				{ID: "CVE-2023-30799", CVSS: 9.1, Modified: "2023-07-25T00:00:00Z", Summary: "Privilege escalation", References: []string{"https://example.com/advisory"}, Affected: []string{"<6.49.8", "=7.1", "=7.2"}},
+			},
+		},
+		{
+			Name: "OSV",
+			Feed: `[{
+				"id": "MT-2019-01",
+				"aliases": ["CVE-2019-3924"],
+				"modified": "2019-02-01T00:00:00Z",
+				"summary": "Intermediary device",
+				"severity": [{"type": "CVSS_V3", "score": "CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N"}],
+				"affected": [{"package": {"ecosystem": "MikroTik", "name": "routeros"}, "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "6.43.12"}]}], "versions": ["6.42"]}],
+				"references": [{"type": "ADVISORY", "url": "https://example.com/osv"}]
+			}, {
+				"id": "GHSA-0000",
+				"affected": [{"package": {"ecosystem": "Go", "name": "example.com/other"}, "versions": ["1.0"]}]
+			}]`,
+			ExpectedFormat: FeedOSV,
+			Expected: []CVE{
+				{ID: "CVE-2019-3924", CVSS: 7.5, Modified: "2019-02-01T00:00:00Z", Summary: "Intermediary device", References: []string{"https://example.com/osv"}, Affected: []string{"=6.42", "<6.43.12"}},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			format, err := DetectFeedFormat([]byte(tc.Feed))
+			if err != nil {
+				t.Fatalf("not expected error %v", err)
+			}
+			if format != tc.ExpectedFormat {
+				t.Errorf("got:%v, expected:%v", format, tc.ExpectedFormat)
+			}
+
+			records, err := decodeFeed(format, []byte(tc.Feed))
+			if err != nil {
+				t.Fatalf("not expected error %v", err)
+			}
+			if !reflect.DeepEqual(records, tc.Expected) {
+				t.Errorf("got:%v, expected:%v", records, tc.Expected)
+			}
+		})
+	}
+}
+
+func TestDecodeFeedMirror(t *testing.T) {
+	content, err := ioutil.ReadFile(filepath.Join("..", "..", "utils", "cves", "cve_circl_mikrotik.json"))
+	if err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+
+	format, err := DetectFeedFormat(content)
+	if err != nil || format != FeedCircl {
+		t.Fatalf("got:%v %v, expected:%v", format, err, FeedCircl)
+	}
+	records, err := decodeFeed(format, content)
+	if err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	if len(records) == 0 {
+		t.Errorf("expected CVEs decoded from mirror")
+	}
+}
+
+func TestMirrorAffectedVersions(t *testing.T) {
+	content, err := ioutil.ReadFile(filepath.Join("..", "..", "utils", "cves", "cve_circl_mikrotik.json"))
+	if err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	list, err := decodeFeed(FeedCircl, content)
+	if err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	cves := make(map[string]CVE, len(list))
+	for _, cve := range list {
+		cves[cve.ID] = cve
+	}
+
+	cases := []struct {
+		Version  string
+		CVE      string
+		Expected bool
+	}{
+		{"6.42", "CVE-2018-14847", true},
+		{"6.42rc9 (testing)", "CVE-2018-14847", true},
+		{"6.42.1", "CVE-2018-14847", false},
+		{"6.40.9 (long-term)", "CVE-2018-14847", true},
+		{"6.43.12 (long-term)", "CVE-2019-3943", true},
+		{"6.44beta9 (testing)", "CVE-2019-3943", true},
+		{"6.44", "CVE-2019-3943", false},
+		{"6.42.1", "CVE-2018-1156", true},
+		{"6.43", "CVE-2018-1156", false},
+		{"6.48.6 (long-term)", "CVE-2019-3943", false},
+		{"7.1rc4 (testing)", "CVE-2018-14847", false},
+	}
+
+	for _, tc := range cases {
+		version, err := entities.ParseVersion(tc.Version)
+		if err != nil {
+			t.Fatalf("not expected error %v", err)
+		}
+		if affected := cves[tc.CVE].Affects(version); affected != tc.Expected {
+			t.Errorf("%s of %s got:%v, expected:%v", tc.CVE, tc.Version, affected, tc.Expected)
+		}
+	}
+}
+
+func TestVersionKeys(t *testing.T) {
+	affected := []string{"=7.1rc4", "<=6.45.6", ">=6.46 <6.46.1", "=6.45.6", ">=7.2", anyVersion}
+	expected := []int{70100, 64506, 64601, allVersions}
+
+	if keys := versionKeys(affected); !reflect.DeepEqual(keys, expected) {
+		t.Errorf("got:%v, expected:%v", keys, expected)
+	}
+}
+
+func TestDetectFeedFormatUnknown(t *testing.T) {
+	for _, feed := range []string{`{"foo": []}`, `[]`, `not json`} {
+		if _, err := DetectFeedFormat([]byte(feed)); err == nil {
+			t.Errorf("expected error of %s", feed)
+		}
+	}
+}
+
+func TestCVSS3BaseScore(t *testing.T) {
+	cases := []struct {
+		Vector   string
+		Expected float32
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8},
+		{"CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:C/C:L/I:L/A:N", 6.4},
+		{"CVSS:3.0/AV:L/AC:H/PR:H/UI:R/S:U/C:N/I:N/A:N", 0},
+		{"CVSS:3.1/AV:N/AC:L/PR:H/UI:N/S:U/C:H/I:H/A:H", 7.2},
+	}
+
+	for _, tc := range cases {
+		score, err := cvss3BaseScore(tc.Vector)
+		if err != nil {
+			t.Fatalf("not expected error %v", err)
+		}
+		if score != tc.Expected {
+			t.Errorf("%s got:%v, expected:%v", tc.Vector, score, tc.Expected)
+		}
+	}
+
+	if _, err := cvss3BaseScore("AV:N/AC:L/Au:N/C:P/I:N/A:P"); err == nil {
+		t.Errorf("expected error of CVSS v2 vector")
+	}
+}
+
+func TestOpenFileURL(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	sugar := logger.Sugar()
+
+	dir, err := ioutil.TempDir("", "mt-bulk-cves")
+	if err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "cves.json")
+	if err := ioutil.WriteFile(file, []byte(`{"data": []}`), 0600); err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+
+	url := "file://" + filepath.ToSlash(file)
+	if !IsFileURL(url) || IsFileURL(CVEURL) {
+		t.Errorf("invalid recognition of file URLs")
+	}
+
+	body, err := openURL(context.Background(), sugar, url)
+	if err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	defer body.Close()
+
+	records, err := decodeCircl(body)
+	if err != nil || len(records) != 0 {
+		t.Errorf("got:%v %v, expected empty list", records, err)
+	}
+
+	if _, err := openURL(context.Background(), sugar, url+".missing"); err == nil {
+		t.Errorf("expected error of missing file")
+	}
+}
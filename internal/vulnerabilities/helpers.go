@@ -3,53 +3,77 @@ package vulnerabilities
 import (
 	"context"
 	"errors"
-	"math"
+	"fmt"
+	"io"
 	"net/http"
-	"regexp"
-	"strconv"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
+
+	"github.com/migotom/mt-bulk/internal/entities"
 )
 
-// ConfigurationsToVersions converts list of CVEdefined vulnerable configurations into list of Mikrotik versions as integers.
-func ConfigurationsToVersions(vulnerableConfigurations []string) []int {
-	versions := make([]int, 0, len(vulnerableConfigurations))
+// allVersions is version key of CVEs without last affected version.
+const allVersions = 999999
 
-	extractVersionRe := regexp.MustCompile(`:mi[ck]rotik:routeros:([\d\.]+):?`)
+// versionKeys returns list of keys of last affected versions of ranges, used to index CVEs by versions they may affect.
+func versionKeys(affected []string) []int {
+	keys := make([]int, 0, len(affected))
 
-configurations:
-	for _, configuration := range vulnerableConfigurations {
-		versionMatch := extractVersionRe.FindStringSubmatch(configuration)
-		if versionMatch == nil || len(versionMatch) != 2 {
+ranges:
+	for _, versionRange := range affected {
+		constraints, err := entities.ParseVersionRange(versionRange)
+		if err != nil {
 			continue
 		}
 
-		version := versionToInt(versionMatch[1])
-		for _, storedVersion := range versions {
-			if storedVersion == version {
-				continue configurations
+		key := allVersions
+		for _, constraint := range constraints {
+			switch constraint.Operator {
+			case "=", "<=", "<":
+				key = versionKey(constraint.Version)
 			}
 		}
 
-		versions = append(versions, version)
+		for _, known := range keys {
+			if known == key {
+				continue ranges
+			}
+		}
+		keys = append(keys, key)
 	}
-	return versions
+	return keys
+}
+
+// versionKey converts version into integer key, pre-releases share key with final release, e.g. `6.48.6` is 64806.
+func versionKey(version entities.Version) int {
+	return version.Numbers[0]*10000 + version.Numbers[1]*100 + version.Numbers[2]
 }
 
-func versionToInt(input string) (version int) {
-	re := regexp.MustCompile(`^(\d+).?(\d+)?.?(\d+)?`)
-	numbersMatch := re.FindStringSubmatch(input)
-	if numbersMatch == nil {
-		return
+// IsFileURL returns true if URL addresses local file, e.g. `file:///var/lib/mt-bulk/cves.json`.
+func IsFileURL(url string) bool {
+	return strings.HasPrefix(url, fileURLPrefix)
+}
+
+// openURL opens content of given URL, local files are addressed by `file://` URLs.
+func openURL(ctx context.Context, sugar *zap.SugaredLogger, url string) (io.ReadCloser, error) {
+	if IsFileURL(url) {
+		sugar.Infof("Loading %s", url)
+		return os.Open(filepath.FromSlash(strings.TrimPrefix(url, fileURLPrefix)))
 	}
 
-	for step, match := range numbersMatch[1:] {
-		if number, err := strconv.Atoi(match); err == nil {
-			version += int(number) * int(math.Pow10(4-(step*2)))
-		}
+	res, err := downloadWithRetries(ctx, sugar, url)
+	if err != nil {
+		return nil, err
 	}
-	return
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("invalid response status code %v", res.StatusCode)
+	}
+	return res.Body, nil
 }
 
 func downloadWithRetries(ctx context.Context, sugar *zap.SugaredLogger, url string) (res *http.Response, err error) {
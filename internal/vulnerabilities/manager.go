@@ -5,13 +5,16 @@ import (
 	"context"
 	"encoding/gob"
 	"fmt"
+	"io/ioutil"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dgraph-io/badger"
 	"go.uber.org/zap"
 
+	"github.com/migotom/mt-bulk/internal/entities"
 	"github.com/migotom/mt-bulk/internal/kvdb"
 )
 
@@ -23,6 +26,9 @@ type Manager struct {
 	sugar *zap.SugaredLogger
 
 	jobs chan vulnerabilityCheckJob
+
+	// offline is set once refresh of local database failed, e.g. in air-gapped network using imported database
+	offline bool
 }
 
 // NewManager returns new vulnerability manager.
@@ -71,16 +77,27 @@ func (vm *Manager) check(ctx context.Context, input string) error {
 		return nil
 	})
 
-	if lastUpdate.Before(time.Now().Add(-24*time.Duration(time.Hour))) || dbVersion < RequiredKVDBVersion {
+	if !vm.offline && (lastUpdate.Before(time.Now().Add(-24*time.Duration(time.Hour))) || dbVersion < RequiredKVDBVersion) {
 		if err := vm.CVEsDownload(ctx); err != nil {
-			return fmt.Errorf("can't download vulnerabilities: %v", err)
+			status, statusErr := vm.Status()
+			if statusErr != nil || status.CVEs == 0 {
+				return fmt.Errorf("can't download vulnerabilities: %v", err)
+			}
+
+			// outdated local database is still used, refresh is not retried by next checks
+			vm.offline = true
+			vm.sugar.Warnw("can't refresh vulnerabilities database, using local one", "error", err, "last_update", lastUpdate, "cves", status.CVEs)
 		}
 	}
 
-	versionToCheck := versionToInt(input)
+	version, err := entities.ParseVersion(input)
+	if err != nil {
+		return err
+	}
+	versionToCheck := versionKey(version)
 	vulnerabilityError := VulnerabilityError{}
 
-	err := vm.kv.View(func(txn kvdb.Txn) error {
+	err = vm.kv.View(func(txn kvdb.Txn) error {
 		extractVersionRe := regexp.MustCompile(fmt.Sprintf("^%s(.*)", kvTagVersion))
 
 		it := txn.NewIterator(badger.DefaultIteratorOptions)
@@ -127,6 +144,10 @@ func (vm *Manager) check(ctx context.Context, input string) error {
 				if err != nil {
 					return err
 				}
+				// CVEs stored without affected ranges are matched by version keys only
+				if len(cve.Affected) > 0 && !cve.Affects(version) {
+					continue
+				}
 				vulnerabilityError.Vulnerabilities = append(vulnerabilityError.Vulnerabilities, cve)
 			}
 		}
@@ -141,3 +162,61 @@ func (vm *Manager) check(ctx context.Context, input string) error {
 	}
 	return vulnerabilityError
 }
+
+// Import loads CVEs from local feed file into database, returns detected format of feed and number of imported CVEs.
+// Imported CVEs are merged with already stored ones.
+func (vm *Manager) Import(file string) (format string, count int, err error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", 0, err
+	}
+
+	format, err = DetectFeedFormat(content)
+	if err != nil {
+		return "", 0, err
+	}
+
+	cves, err := decodeFeed(format, content)
+	if err != nil {
+		return format, 0, err
+	}
+
+	txn := vm.kv.NewTransaction()
+	defer txn.Discard()
+
+	if err = storeCVEs(txn, cves, true); err != nil {
+		return format, 0, err
+	}
+	return format, len(cves), txn.Commit()
+}
+
+// Status describes state of local CVE database.
+type Status struct {
+	LastUpdate time.Time
+	Version    int
+	CVEs       int
+	Versions   int
+}
+
+// Status returns state of local CVE database.
+func (vm *Manager) Status() (status Status, err error) {
+	err = vm.kv.View(func(txn kvdb.Txn) error {
+		_ = txn.GetCopy(kvTagDBLastUpdate, &status.LastUpdate)
+		_ = txn.GetCopy(kvTagDBVersion, &status.Version)
+
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			key := string(it.Item().KeyCopy(nil))
+			switch {
+			case strings.HasPrefix(key, kvTagCVE):
+				status.CVEs++
+			case strings.HasPrefix(key, kvTagVersion):
+				status.Versions++
+			}
+		}
+		return nil
+	})
+	return
+}
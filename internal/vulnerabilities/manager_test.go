@@ -6,6 +6,7 @@ import (
 	"encoding/gob"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -69,7 +70,7 @@ func TestCheck(t *testing.T) {
 			ExpectedErr: "vulnerabilities found: CVE-1 (0.0)",
 			ExpectedMocks: func(kv *mocks.KVMock) {
 				kv.Txn.On("GetCopy", "DB:LastUpdate", mock.Anything).Return(time.Now(), nil)
-				kv.Txn.On("GetCopy", "DB:Version", mock.Anything).Return(1, nil)
+				kv.Txn.On("GetCopy", "DB:Version", mock.Anything).Return(RequiredKVDBVersion, nil)
 
 				item := &mocks.ItemMock{}
 				item.On("KeyCopy", mock.Anything).Return([]byte("Version:61200"))
@@ -83,13 +84,53 @@ func TestCheck(t *testing.T) {
 				kv.Txn.On("GetCopy", "CVE:CVE-1", mock.Anything).Return(CVE{ID: "CVE-1", Summary: "URGENT"}, nil)
 			},
 		},
+		{
+			Name:        "Pre-release in affected range",
+			Version:     "6.44beta9 (testing)",
+			ExpectedErr: "vulnerabilities found: CVE-2019-3943 (5.5)",
+			ExpectedMocks: func(kv *mocks.KVMock) {
+				kv.Txn.On("GetCopy", "DB:LastUpdate", mock.Anything).Return(time.Now(), nil)
+				kv.Txn.On("GetCopy", "DB:Version", mock.Anything).Return(RequiredKVDBVersion, nil)
+
+				item := &mocks.ItemMock{}
+				item.On("KeyCopy", mock.Anything).Return([]byte("Version:64400"))
+
+				kv.Txn.It = mocks.IteratorMock{Items: []kvdb.Item{item}}
+
+				buffer := bytes.NewBuffer(nil)
+				_ = gob.NewEncoder(buffer).Encode([]string{"CVE-2019-3943"})
+
+				item.On("ValueCopy", mock.Anything).Return(buffer.Bytes(), nil)
+				kv.Txn.On("GetCopy", "CVE:CVE-2019-3943", mock.Anything).Return(CVE{ID: "CVE-2019-3943", CVSS: 5.5, Affected: []string{"<6.44"}}, nil)
+			},
+		},
+		{
+			Name:        "Fixed in checked version",
+			Version:     "6.44",
+			ExpectedErr: "",
+			ExpectedMocks: func(kv *mocks.KVMock) {
+				kv.Txn.On("GetCopy", "DB:LastUpdate", mock.Anything).Return(time.Now(), nil)
+				kv.Txn.On("GetCopy", "DB:Version", mock.Anything).Return(RequiredKVDBVersion, nil)
+
+				item := &mocks.ItemMock{}
+				item.On("KeyCopy", mock.Anything).Return([]byte("Version:64400"))
+
+				kv.Txn.It = mocks.IteratorMock{Items: []kvdb.Item{item}}
+
+				buffer := bytes.NewBuffer(nil)
+				_ = gob.NewEncoder(buffer).Encode([]string{"CVE-2019-3943"})
+
+				item.On("ValueCopy", mock.Anything).Return(buffer.Bytes(), nil)
+				kv.Txn.On("GetCopy", "CVE:CVE-2019-3943", mock.Anything).Return(CVE{ID: "CVE-2019-3943", CVSS: 5.5, Affected: []string{"<6.44"}}, nil)
+			},
+		},
 		{
 			Name:        "Not found any vulnerability",
 			Version:     "5.12",
 			ExpectedErr: "",
 			ExpectedMocks: func(kv *mocks.KVMock) {
 				kv.Txn.On("GetCopy", "DB:LastUpdate", mock.Anything).Return(time.Now(), nil)
-				kv.Txn.On("GetCopy", "DB:Version", mock.Anything).Return(1, nil)
+				kv.Txn.On("GetCopy", "DB:Version", mock.Anything).Return(RequiredKVDBVersion, nil)
 
 				item := &mocks.ItemMock{}
 				item.On("KeyCopy", mock.Anything).Return([]byte("Version:11200"))
@@ -103,7 +144,7 @@ func TestCheck(t *testing.T) {
 			ExpectedErr: "",
 			ExpectedMocks: func(kv *mocks.KVMock) {
 				kv.Txn.On("GetCopy", "DB:LastUpdate", mock.Anything).Return(time.Now(), nil)
-				kv.Txn.On("GetCopy", "DB:Version", mock.Anything).Return(1, nil)
+				kv.Txn.On("GetCopy", "DB:Version", mock.Anything).Return(RequiredKVDBVersion, nil)
 			},
 		},
 	}
@@ -132,3 +173,69 @@ func TestCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckOffline(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	sugar := logger.Sugar()
+
+	var requests int32
+	testServerDBInfo := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		res.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer func() { testServerDBInfo.Close() }()
+
+	cases := []struct {
+		Name             string
+		Imported         bool
+		ExpectedErr      string
+		ExpectedRequests int32
+	}{
+		{
+			Name:             "Outdated imported database",
+			Imported:         true,
+			ExpectedErr:      "vulnerabilities found: CVE-1 (0.0)",
+			ExpectedRequests: 1,
+		},
+		{
+			Name:             "Missing database",
+			ExpectedErr:      "can't download vulnerabilities: invalid response status code 503",
+			ExpectedRequests: 2,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			atomic.StoreInt32(&requests, 0)
+
+			kvMock := mocks.KVMock{Txn: mocks.TxnMock{It: mocks.IteratorMock{}}}
+			kvMock.Txn.On("GetCopy", "DB:LastUpdate", mock.Anything).Return(time.Now().Add(-48*time.Hour), nil)
+			kvMock.Txn.On("GetCopy", "DB:Version", mock.Anything).Return(RequiredKVDBVersion, nil)
+			kvMock.Txn.On("Discard").Return()
+			if tc.Imported {
+				cveItem := &mocks.ItemMock{}
+				cveItem.On("KeyCopy", mock.Anything).Return([]byte("CVE:CVE-1"))
+
+				versionItem := &mocks.ItemMock{}
+				versionItem.On("KeyCopy", mock.Anything).Return([]byte("Version:61200"))
+				buffer := bytes.NewBuffer(nil)
+				_ = gob.NewEncoder(buffer).Encode([]string{"CVE-1"})
+				versionItem.On("ValueCopy", mock.Anything).Return(buffer.Bytes(), nil)
+
+				kvMock.Txn.It = mocks.IteratorMock{Items: []kvdb.Item{cveItem, versionItem}}
+				kvMock.Txn.On("GetCopy", "CVE:CVE-1", mock.Anything).Return(CVE{ID: "CVE-1"}, nil)
+			}
+
+			vm := NewManager(sugar, []CVEURLs{CVEURLs{DBInfo: testServerDBInfo.URL, DB: testServerDBInfo.URL}}, &kvMock)
+			ctx, cancel := context.WithCancel(context.Background())
+			go vm.Listen(ctx)
+			defer cancel()
+
+			// refresh of database failed once is not retried
+			for i := 0; i < 2; i++ {
+				assert.EqualError(t, vm.Check("5.12"), tc.ExpectedErr)
+			}
+			assert.Equal(t, tc.ExpectedRequests, atomic.LoadInt32(&requests))
+		})
+	}
+}
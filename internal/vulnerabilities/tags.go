@@ -1,7 +1,7 @@
 package vulnerabilities
 
 // RequiredKVDBVersion defines latest KV structure version.
-const RequiredKVDBVersion = 1
+const RequiredKVDBVersion = 2
 
 const (
 	kvTagCVE          = "CVE:"
@@ -9,8 +9,11 @@ const (
 	kvTagDBLastUpdate = "DB:LastUpdate"
 	kvTagDBVersion    = "DB:Version"
 	kvTagDBCVEdbInfo  = "DB:CVE:DBInfo"
+	kvTagCVEWaiver    = "Waiver:CVE:"
 )
 
+const fileURLPrefix = "file://"
+
 // CVEURL is default CVE search API endpoint.
 const CVEURL = "https://cve.circl.lu/api/search/mikrotik"
 
@@ -0,0 +1,75 @@
+package vulnerabilities
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"strings"
+
+	"github.com/dgraph-io/badger"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+	"github.com/migotom/mt-bulk/internal/kvdb"
+)
+
+// Waive stores waiver accepting CVE, waiver of the same CVE id and host pattern is replaced.
+func (vm *Manager) Waive(waiver entities.Waiver) error {
+	if err := waiver.Validate(); err != nil {
+		return err
+	}
+
+	txn := vm.kv.NewTransaction()
+	defer txn.Discard()
+
+	if err := txn.Store(waiverKey(waiver.ID, waiver.Host), waiver); err != nil {
+		return err
+	}
+	return txn.Commit()
+}
+
+// Unwaive removes stored waiver of CVE with given host pattern.
+func (vm *Manager) Unwaive(id, host string) error {
+	txn := vm.kv.NewTransaction()
+	defer txn.Discard()
+
+	var waiver entities.Waiver
+	if err := txn.GetCopy(waiverKey(id, host), &waiver); err != nil {
+		return fmt.Errorf("waiver of %s not found", id)
+	}
+	if err := txn.Delete(waiverKey(id, host)); err != nil {
+		return err
+	}
+	return txn.Commit()
+}
+
+// Waivers returns list of stored waivers of CVEs.
+func (vm *Manager) Waivers() (waivers []entities.Waiver, err error) {
+	err = vm.kv.View(func(txn kvdb.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			if !strings.HasPrefix(string(item.KeyCopy(nil)), kvTagCVEWaiver) {
+				continue
+			}
+
+			value, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			var waiver entities.Waiver
+			if err = gob.NewDecoder(bytes.NewReader(value)).Decode(&waiver); err != nil {
+				return err
+			}
+			waivers = append(waivers, waiver)
+		}
+		return nil
+	})
+	return
+}
+
+func waiverKey(id, host string) string {
+	return fmt.Sprintf("%s%s:%s", kvTagCVEWaiver, id, host)
+}
@@ -0,0 +1,61 @@
+package vulnerabilities
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"github.com/migotom/mt-bulk/internal/entities"
+	"github.com/migotom/mt-bulk/internal/kvdb"
+	"github.com/migotom/mt-bulk/internal/kvdb/mocks"
+)
+
+func TestWaivers(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	sugar := logger.Sugar()
+
+	waiver := entities.Waiver{ID: "CVE-2018-14847", Host: "10.0.0.*", Expires: "2027-01-31", Justification: "Winbox firewalled"}
+
+	t.Run("Waive", func(t *testing.T) {
+		kvMock := mocks.KVMock{Txn: mocks.TxnMock{}}
+		kvMock.Txn.On("Store", "Waiver:CVE:CVE-2018-14847:10.0.0.*", waiver).Return(nil)
+		kvMock.Txn.On("Commit").Return(nil)
+		kvMock.Txn.On("Discard").Return()
+
+		if err := NewManager(sugar, nil, &kvMock).Waive(waiver); err != nil {
+			t.Fatalf("not expected error %v", err)
+		}
+		kvMock.Txn.AssertExpectations(t)
+	})
+
+	t.Run("Waive invalid", func(t *testing.T) {
+		kvMock := mocks.KVMock{Txn: mocks.TxnMock{}}
+		if err := NewManager(sugar, nil, &kvMock).Waive(entities.Waiver{ID: "CVE-1", Expires: "tomorrow"}); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		buffer := bytes.NewBuffer(nil)
+		_ = gob.NewEncoder(buffer).Encode(waiver)
+
+		waiverItem := &mocks.ItemMock{}
+		waiverItem.On("KeyCopy", mock.Anything).Return([]byte("Waiver:CVE:CVE-2018-14847:10.0.0.*"))
+		waiverItem.On("ValueCopy", mock.Anything).Return(buffer.Bytes(), nil)
+		cveItem := &mocks.ItemMock{}
+		cveItem.On("KeyCopy", mock.Anything).Return([]byte("CVE:CVE-2018-14847"))
+
+		kvMock := mocks.KVMock{Txn: mocks.TxnMock{It: mocks.IteratorMock{Items: []kvdb.Item{cveItem, waiverItem}}}}
+		waivers, err := NewManager(sugar, nil, &kvMock).Waivers()
+		if err != nil {
+			t.Fatalf("not expected error %v", err)
+		}
+		if !reflect.DeepEqual(waivers, []entities.Waiver{waiver}) {
+			t.Errorf("got:%v, expected:%v", waivers, []entities.Waiver{waiver})
+		}
+	})
+}